@@ -0,0 +1,263 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the vertices of the smallest convex polygon containing
+// all points, counter-clockwise starting from the lowest-leftmost point,
+// using Andrew's monotone chain algorithm. Points on the hull's edges are left
+// out. Fewer than three vertices are returned when all points lie on a line.
+func ConvexHull(points []Point) []Point {
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	// Drop duplicates
+	unique := sorted[:0]
+	for i, p := range sorted {
+		if i == 0 || p != sorted[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) < 3 {
+		return unique
+	}
+
+	hull := make([]Point, 0, 2*len(unique))
+	// Lower hull, then upper hull
+	for _, p := range unique {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(unique) - 2; i >= 0; i-- {
+		p := unique[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// The last point is the first one again
+	return hull[:len(hull)-1]
+}
+
+// ConvexHull returns the convex hull of the polygon
+func (p *Polygon) ConvexHull() *Polygon {
+	return &Polygon{Vertices: ConvexHull(p.Vertices)}
+}
+
+// Triangulate splits the polygon into triangles by ear clipping. The areas of
+// the triangles add up to the area of the polygon.
+func (p *Polygon) Triangulate() ([]*Triangle, error) {
+	corners, err := triangulate(p.Vertices)
+	if err != nil {
+		return nil, err
+	}
+	triangles := make([]*Triangle, 0, len(corners))
+	for _, c := range corners {
+		a, b, d := p.Vertices[c[0]], p.Vertices[c[1]], p.Vertices[c[2]]
+		t, err := NewTriangle(distance(b, d), distance(a, d), distance(a, b))
+		if err != nil {
+			return nil, fmt.Errorf("triangle %v %v %v: %w", a, b, d, err)
+		}
+		triangles = append(triangles, t)
+	}
+	return triangles, nil
+}
+
+// triangulate returns the corners of the ears clipped from a counter-clockwise
+// simple polygon, as indexes into vertices
+func triangulate(vertices []Point) ([][3]int, error) {
+	remaining := make([]int, len(vertices))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	scale := boundaryLength(polygonLoop(vertices))
+
+	var res [][3]int
+	for len(remaining) > 3 {
+		clipped := false
+		n := len(remaining)
+		for i := 0; i < n; i++ {
+			prev, cur, next := remaining[(i+n-1)%n], remaining[i], remaining[(i+1)%n]
+			turn := cross(vertices[prev], vertices[cur], vertices[next])
+			if math.Abs(turn) <= 1e-12*scale*scale {
+				// A vertex in the middle of a straight edge: drop it, no area is lost
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				clipped = true
+				break
+			}
+			if turn < 0 || !isEar(vertices, remaining, prev, cur, next) {
+				continue
+			}
+			res = append(res, [3]int{prev, cur, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			return nil, errors.New("polygon cannot be triangulated, its edges may cross")
+		}
+	}
+	if cross(vertices[remaining[0]], vertices[remaining[1]], vertices[remaining[2]]) > 1e-12*scale*scale {
+		res = append(res, [3]int{remaining[0], remaining[1], remaining[2]})
+	}
+	return res, nil
+}
+
+// isEar reports whether no other remaining vertex lies in the triangle prev, cur, next
+func isEar(vertices []Point, remaining []int, prev, cur, next int) bool {
+	a, b, c := vertices[prev], vertices[cur], vertices[next]
+	for _, i := range remaining {
+		if i == prev || i == cur || i == next {
+			continue
+		}
+		p := vertices[i]
+		if p == a || p == b || p == c {
+			continue
+		}
+		if cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClosestPair returns the two points with the smallest distance between them,
+// in O(n log n) by divide and conquer
+func ClosestPair(points []Point) (Point, Point, error) {
+	if len(points) < 2 {
+		return Point{}, Point{}, errors.New("at least two points are needed")
+	}
+	byX := append([]Point(nil), points...)
+	sort.Slice(byX, func(i, j int) bool { return byX[i].X < byX[j].X })
+	byY := append([]Point(nil), byX...)
+	sort.SliceStable(byY, func(i, j int) bool { return byY[i].Y < byY[j].Y })
+
+	a, b, _ := closestPair(byX, byY)
+	return a, b, nil
+}
+
+// closestPair solves the problem for points sorted by X, given the same points sorted by Y
+func closestPair(byX, byY []Point) (Point, Point, float64) {
+	if len(byX) <= 3 {
+		best := math.Inf(1)
+		var a, b Point
+		for i := range byX {
+			for j := i + 1; j < len(byX); j++ {
+				if d := distance(byX[i], byX[j]); d < best {
+					best, a, b = d, byX[i], byX[j]
+				}
+			}
+		}
+		return a, b, best
+	}
+
+	mid := len(byX) / 2
+	split := byX[mid]
+	// Points with the same X as the split point can be in either half; count them
+	// so the halves sorted by Y hold exactly the same points as the halves by X
+	leftEqual := 0
+	for _, p := range byX[:mid] {
+		if p.X == split.X {
+			leftEqual++
+		}
+	}
+	leftY := make([]Point, 0, mid)
+	rightY := make([]Point, 0, len(byX)-mid)
+	for _, p := range byY {
+		if p.X < split.X || (p.X == split.X && leftEqual > 0) {
+			if p.X == split.X {
+				leftEqual--
+			}
+			leftY = append(leftY, p)
+		} else {
+			rightY = append(rightY, p)
+		}
+	}
+
+	a, b, best := closestPair(byX[:mid], leftY)
+	if c, d, dist := closestPair(byX[mid:], rightY); dist < best {
+		a, b, best = c, d, dist
+	}
+
+	// Check pairs across the split line, within best of it
+	var strip []Point
+	for _, p := range byY {
+		if math.Abs(p.X-split.X) < best {
+			strip = append(strip, p)
+		}
+	}
+	for i := range strip {
+		for j := i + 1; j < len(strip) && strip[j].Y-strip[i].Y < best; j++ {
+			if d := distance(strip[i], strip[j]); d < best {
+				a, b, best = strip[i], strip[j], d
+			}
+		}
+	}
+	return a, b, best
+}
+
+// Simplify reduces a polyline with the Douglas–Peucker algorithm: the
+// result keeps the first and last points, and no removed point is further
+// than epsilon from it
+func Simplify(points []Point, epsilon float64) []Point {
+	if len(points) < 3 {
+		return append([]Point(nil), points...)
+	}
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	douglasPeucker(points, 0, len(points)-1, epsilon, keep)
+
+	var res []Point
+	for i, p := range points {
+		if keep[i] {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func douglasPeucker(points []Point, first, last int, epsilon float64, keep []bool) {
+	farthest, maxDist := -1, epsilon
+	s := segment{points[first], points[last]}
+	for i := first + 1; i < last; i++ {
+		if d := distanceToSegment(points[i], s); d > maxDist {
+			farthest, maxDist = i, d
+		}
+	}
+	if farthest < 0 {
+		return
+	}
+	keep[farthest] = true
+	douglasPeucker(points, first, farthest, epsilon, keep)
+	douglasPeucker(points, farthest, last, epsilon, keep)
+}
+
+// Simplify reduces the polygon with the Douglas–Peucker algorithm, see the
+// Simplify function. The ring is cut at the first vertex and the vertex
+// farthest from it, which are both kept. An error is returned if the
+// simplified polygon is not valid, e.g. because its edges cross.
+func (p *Polygon) Simplify(epsilon float64) (*Polygon, error) {
+	if epsilon < 0 {
+		return nil, errors.New("epsilon should not be negative")
+	}
+	farthest := 0
+	for i, v := range p.Vertices {
+		if distance(v, p.Vertices[0]) > distance(p.Vertices[farthest], p.Vertices[0]) {
+			farthest = i
+		}
+	}
+	first := Simplify(p.Vertices[:farthest+1], epsilon)
+	second := Simplify(append(append([]Point(nil), p.Vertices[farthest:]...), p.Vertices[0]), epsilon)
+	return NewPolygon(append(first, second[1:len(second)-1]...))
+}
@@ -0,0 +1,183 @@
+package challenge10
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []Point
+		expected []Point
+	}{
+		{"Square with inner points", []Point{{1, 1}, {0, 0}, {2, 2}, {0, 2}, {2, 0}, {1, 0.5}}, []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}},
+		{"Points on edges are dropped", []Point{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {1, 1}, {0, 2}}, []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}},
+		{"Duplicates", []Point{{0, 0}, {0, 0}, {3, 0}, {0, 3}, {3, 0}}, []Point{{0, 0}, {3, 0}, {0, 3}}},
+		{"Collinear", []Point{{0, 0}, {1, 1}, {2, 2}}, []Point{{0, 0}, {2, 2}}},
+		{"Single point", []Point{{1, 1}}, []Point{{1, 1}}},
+		{"No points", nil, []Point{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hull := ConvexHull(tt.points)
+			if len(hull) == 0 && len(tt.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(hull, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, hull)
+			}
+		})
+	}
+
+	// The hull of random points contains all of them
+	rng := rand.New(rand.NewSource(1))
+	points := make([]Point, 200)
+	for i := range points {
+		points[i] = Point{rng.NormFloat64(), rng.NormFloat64()}
+	}
+	hull := ConvexHull(points)
+	for _, p := range points {
+		if !convexContains(hull, p) {
+			t.Fatalf("Expected hull to contain %v", p)
+		}
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	tests := []struct {
+		name     string
+		vertices []Point
+	}{
+		{"Triangle", []Point{{0, 0}, {4, 0}, {0, 3}}},
+		{"Square", []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}},
+		{"Notched", []Point{{0, 0}, {4, 0}, {4, 4}, {2, 1}, {0, 4}}},
+		{"Collinear vertices", []Point{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 1}}},
+		{"Comb", []Point{{0, 0}, {6, 0}, {6, 3}, {5, 3}, {5, 1}, {4, 1}, {4, 3}, {3, 3}, {3, 1}, {2, 1}, {2, 3}, {1, 3}, {1, 1}, {0, 1}}},
+		{"Spiral", []Point{{0, 0}, {5, 0}, {5, 5}, {1, 5}, {1, 2}, {3, 2}, {3, 3}, {2, 3}, {2, 4}, {4, 4}, {4, 1}, {0, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolygon(tt.vertices)
+			if err != nil {
+				t.Fatalf("Failed to create polygon: %v", err)
+			}
+			triangles, err := p.Triangulate()
+			if err != nil {
+				t.Fatalf("Failed to triangulate: %v", err)
+			}
+			var sum float64
+			for _, tri := range triangles {
+				sum += tri.Area()
+			}
+			if !floatEquals(sum, p.Area()) {
+				t.Errorf("Expected triangle areas to add up to %.6f, got %.6f", p.Area(), sum)
+			}
+		})
+	}
+
+	// A regular polygon has n - 2 triangles
+	circle, err := mustCircle(t, 1).ToPolygon(50)
+	if err != nil {
+		t.Fatalf("Failed to convert circle: %v", err)
+	}
+	triangles, err := circle.Triangulate()
+	if err != nil {
+		t.Fatalf("Failed to triangulate: %v", err)
+	}
+	if len(triangles) != 48 {
+		t.Errorf("Expected 48 triangles, got %d", len(triangles))
+	}
+}
+
+func TestClosestPair(t *testing.T) {
+	a, b, err := ClosestPair([]Point{{0, 0}, {5, 5}, {1, 1}, {5.5, 5}, {9, 0}})
+	if err != nil {
+		t.Fatalf("Failed to find closest pair: %v", err)
+	}
+	if !floatEquals(distance(a, b), 0.5) {
+		t.Errorf("Expected pair at distance 0.5, got %v and %v", a, b)
+	}
+
+	if _, _, err := ClosestPair([]Point{{1, 1}}); err == nil {
+		t.Errorf("Expected an error for a single point, but got none")
+	}
+
+	// Compare with brute force, including points sharing X coordinates
+	rng := rand.New(rand.NewSource(42))
+	for round := 0; round < 50; round++ {
+		points := make([]Point, 2+rng.Intn(200))
+		for i := range points {
+			points[i] = Point{float64(rng.Intn(50)), rng.Float64() * 1000}
+		}
+		best := math.Inf(1)
+		for i := range points {
+			for j := i + 1; j < len(points); j++ {
+				best = math.Min(best, distance(points[i], points[j]))
+			}
+		}
+		a, b, err := ClosestPair(points)
+		if err != nil {
+			t.Fatalf("Failed to find closest pair: %v", err)
+		}
+		if distance(a, b) != best {
+			t.Fatalf("Expected distance %v, got %v between %v and %v", best, distance(a, b), a, b)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	line := []Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}, {6, 8.1}, {7, 9}, {8, 9}, {9, 9}}
+
+	tests := []struct {
+		name     string
+		epsilon  float64
+		expected []Point
+	}{
+		{"Zero epsilon keeps bends", 0, []Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {5, 7}, {6, 8.1}, {7, 9}, {9, 9}}},
+		{"Small epsilon", 0.2, []Point{{0, 0}, {2, -0.1}, {3, 5}, {7, 9}, {9, 9}}},
+		{"Large epsilon", 10, []Point{{0, 0}, {9, 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Simplify(line, tt.epsilon)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPolygonSimplify(t *testing.T) {
+	circle, err := mustCircle(t, 10).ToPolygon(360)
+	if err != nil {
+		t.Fatalf("Failed to convert circle: %v", err)
+	}
+	simplified, err := circle.Simplify(0.1)
+	if err != nil {
+		t.Fatalf("Failed to simplify: %v", err)
+	}
+	if len(simplified.Vertices) >= len(circle.Vertices) || len(simplified.Vertices) < 8 {
+		t.Errorf("Expected fewer vertices, got %d", len(simplified.Vertices))
+	}
+	if math.Abs(simplified.Area()-circle.Area()) > 0.1*circle.Perimeter() {
+		t.Errorf("Expected area close to %.2f, got %.2f", circle.Area(), simplified.Area())
+	}
+
+	// Simplifying a square with a large epsilon leaves fewer than three vertices
+	square, err := NewPolygon([]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}})
+	if err != nil {
+		t.Fatalf("Failed to create polygon: %v", err)
+	}
+	if _, err := square.Simplify(5); err == nil {
+		t.Errorf("Expected an error when simplifying to a line, but got none")
+	}
+	if _, err := square.Simplify(-1); err == nil {
+		t.Errorf("Expected an error for a negative epsilon, but got none")
+	}
+}
@@ -0,0 +1,487 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SetOperation is the way a CompositeShape combines its two shapes
+type SetOperation int
+
+const (
+	Union        SetOperation = iota // area covered by either shape
+	Intersection                     // area covered by both shapes
+	Difference                       // area of the first shape not covered by the second
+)
+
+// String returns the name of the operation
+func (op SetOperation) String() string {
+	switch op {
+	case Union:
+		return "Union"
+	case Intersection:
+		return "Intersection"
+	case Difference:
+		return "Difference"
+	default:
+		return fmt.Sprintf("SetOperation(%d)", int(op))
+	}
+}
+
+// placeable is implemented by shapes that can be positioned and combined
+type placeable interface {
+	Shape
+	Contains(p Point) bool
+	// outline returns the boundary in the shape's own coordinates
+	outline() []segment
+}
+
+// PositionedShape is a shape moved so that the origin of its own coordinates
+// lies at Position
+type PositionedShape struct {
+	Shape    Shape
+	Position Point
+}
+
+// Place positions a shape with the origin of its coordinates at (x, y)
+func Place(s Shape, x, y float64) PositionedShape {
+	return PositionedShape{Shape: s, Position: Point{x, y}}
+}
+
+// Area returns the area of the shape
+func (ps PositionedShape) Area() float64 {
+	return ps.Shape.Area()
+}
+
+// Perimeter returns the perimeter of the shape
+func (ps PositionedShape) Perimeter() float64 {
+	return ps.Shape.Perimeter()
+}
+
+// String returns a string representation of the shape and its position
+func (ps PositionedShape) String() string {
+	return fmt.Sprintf("%s at %s", ps.Shape, ps.Position)
+}
+
+// Contains reports whether p lies inside or on the positioned shape
+func (ps PositionedShape) Contains(p Point) bool {
+	s, ok := ps.Shape.(placeable)
+	return ok && s.Contains(p.Sub(ps.Position))
+}
+
+func (ps PositionedShape) boundary() []segment {
+	return translate(ps.Shape.(placeable).outline(), ps.Position)
+}
+
+// CompositeShape combines two positioned shapes with a set operation, e.g. a
+// rectangle with a circular hole is the Difference of the two. Composite
+// shapes can be positioned and combined again.
+//
+// Area and perimeter are exact when the shapes are disjoint, when one lies
+// inside the other, for two overlapping circles and whenever no circle is
+// involved. Otherwise circles are treated as polygons with circleSegments edges.
+type CompositeShape struct {
+	Op SetOperation
+	A  PositionedShape
+	B  PositionedShape
+
+	area      float64
+	perimeter float64
+	edges     []segment
+}
+
+// NewCompositeShape combines a and b with op. Shapes in a and b use a shared
+// coordinate system, which becomes the coordinate system of the composite.
+func NewCompositeShape(op SetOperation, a, b PositionedShape) (*CompositeShape, error) {
+	if op < Union || op > Difference {
+		return nil, fmt.Errorf("unknown set operation %d", int(op))
+	}
+	for _, ps := range []PositionedShape{a, b} {
+		if ps.Shape == nil {
+			return nil, errors.New("shapes of a composite cannot be nil")
+		}
+		if _, ok := ps.Shape.(placeable); !ok {
+			return nil, fmt.Errorf("%s cannot be combined with other shapes", ps.Shape)
+		}
+	}
+
+	cs := &CompositeShape{Op: op, A: a, B: b}
+	cs.edges = combine(op, a.boundary(), b.boundary())
+	if area, perimeter, ok := exactMeasures(op, a, b); ok {
+		cs.area, cs.perimeter = area, perimeter
+	} else {
+		cs.area, cs.perimeter = boundaryArea(cs.edges), boundaryLength(cs.edges)
+	}
+	if cs.area <= 1e-9*math.Max(a.Area(), b.Area()) {
+		return nil, fmt.Errorf("%s of %s and %s is empty", op, a, b)
+	}
+	return cs, nil
+}
+
+// Area returns the area of the combined shape
+func (cs *CompositeShape) Area() float64 {
+	return cs.area
+}
+
+// Perimeter returns the length of the boundary of the combined shape,
+// including the boundaries of holes
+func (cs *CompositeShape) Perimeter() float64 {
+	return cs.perimeter
+}
+
+// String returns a string representation of the composite shape
+func (cs *CompositeShape) String() string {
+	return fmt.Sprintf("%s(%s, %s)", cs.Op, cs.A, cs.B)
+}
+
+// Contains reports whether p lies inside or on the combined shape
+func (cs *CompositeShape) Contains(p Point) bool {
+	inA, inB := cs.A.Contains(p), cs.B.Contains(p)
+	switch cs.Op {
+	case Union:
+		return inA || inB
+	case Intersection:
+		return inA && inB
+	default:
+		return inA && (!inB || onBoundary(cs.B.boundary(), p, tolerance(cs.edges)))
+	}
+}
+
+func (cs *CompositeShape) outline() []segment {
+	return cs.edges
+}
+
+func onBoundary(segments []segment, p Point, tol float64) bool {
+	for _, s := range segments {
+		if distanceToSegment(p, s) <= tol {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// Polygon clipping
+//
+
+// Where a piece of one boundary lies relative to the other shape
+type placement int
+
+const (
+	outside placement = iota
+	inside
+	sameBoundary     // on the other boundary, both shapes on the same side
+	oppositeBoundary // on the other boundary, the shapes on opposite sides
+)
+
+// combine returns the boundary of the combined shape: each boundary is cut
+// where it crosses the other, and the pieces are kept or dropped depending on
+// which side of the other shape they lie
+func combine(op SetOperation, a, b []segment) []segment {
+	tol := tolerance(a, b)
+	var res []segment
+	for _, s := range split(a, b, tol) {
+		switch classify(s, b, tol) {
+		case inside:
+			if op == Intersection {
+				res = append(res, s)
+			}
+		case outside:
+			if op != Intersection {
+				res = append(res, s)
+			}
+		case sameBoundary:
+			if op != Difference {
+				res = append(res, s)
+			}
+		case oppositeBoundary:
+			if op == Difference {
+				res = append(res, s)
+			}
+		}
+	}
+	// Pieces of b on the boundary of a were handled with a
+	for _, s := range split(b, a, tol) {
+		switch classify(s, a, tol) {
+		case inside:
+			if op == Intersection {
+				res = append(res, s)
+			} else if op == Difference {
+				res = append(res, s.reverse())
+			}
+		case outside:
+			if op == Union {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// split cuts every segment at the points where it meets the other boundary
+func split(segments, other []segment, tol float64) []segment {
+	var res []segment
+	for _, s := range segments {
+		cuts := []float64{0, 1}
+		d := s.B.Sub(s.A)
+		lengthSq := dot(d, d)
+		for _, o := range other {
+			if !boxesOverlap(s, o, tol) {
+				continue
+			}
+			e := o.B.Sub(o.A)
+			denom := d.X*e.Y - d.Y*e.X
+			if math.Abs(denom) <= 1e-9*math.Sqrt(lengthSq*dot(e, e)) {
+				// Parallel: cut where the ends of a collinear segment lie
+				if distanceToLine(o.A, s) <= tol {
+					cuts = append(cuts, dot(o.A.Sub(s.A), d)/lengthSq, dot(o.B.Sub(s.A), d)/lengthSq)
+				}
+				continue
+			}
+			w := o.A.Sub(s.A)
+			t := (w.X*e.Y - w.Y*e.X) / denom
+			u := (w.X*d.Y - w.Y*d.X) / denom
+			if u >= -1e-12 && u <= 1+1e-12 {
+				cuts = append(cuts, t)
+			}
+		}
+
+		sort.Float64s(cuts)
+		length := math.Sqrt(lengthSq)
+		prev := 0.0
+		for _, t := range cuts {
+			// Ignore cuts that would leave pieces shorter than the tolerance
+			if (t-prev)*length > tol && (1-t)*length > tol {
+				res = append(res, segment{s.at(prev), s.at(t)})
+				prev = t
+			}
+		}
+		res = append(res, segment{s.at(prev), s.B})
+	}
+	return res
+}
+
+func boxesOverlap(s, o segment, tol float64) bool {
+	return math.Max(s.A.X, s.B.X)+tol >= math.Min(o.A.X, o.B.X) &&
+		math.Max(o.A.X, o.B.X)+tol >= math.Min(s.A.X, s.B.X) &&
+		math.Max(s.A.Y, s.B.Y)+tol >= math.Min(o.A.Y, o.B.Y) &&
+		math.Max(o.A.Y, o.B.Y)+tol >= math.Min(s.A.Y, s.B.Y)
+}
+
+// distanceToLine returns the distance from p to the line through s
+func distanceToLine(p Point, s segment) float64 {
+	length := s.length()
+	if length == 0 {
+		return distance(p, s.A)
+	}
+	return math.Abs(cross(s.A, s.B, p)) / length
+}
+
+// classify places a piece of boundary relative to the shape enclosed by other
+func classify(s segment, other []segment, tol float64) placement {
+	mid := s.at(0.5)
+	d := s.B.Sub(s.A)
+	for _, o := range other {
+		if distanceToSegment(mid, o) > tol {
+			continue
+		}
+		e := o.B.Sub(o.A)
+		if math.Abs(d.X*e.Y-d.Y*e.X) <= 1e-9*s.length()*o.length() {
+			if dot(d, e) > 0 {
+				return sameBoundary
+			}
+			return oppositeBoundary
+		}
+	}
+	if winding(other, mid) != 0 {
+		return inside
+	}
+	return outside
+}
+
+//
+// Exact measures
+//
+
+// Relations between two shapes
+type relation int
+
+const (
+	overlapping relation = iota
+	disjoint
+	aInsideB
+	bInsideA
+)
+
+// primitive is a circle or a convex polygon in shared coordinates
+type primitive struct {
+	circle   bool
+	center   Point
+	radius   float64
+	vertices []Point
+}
+
+func toPrimitive(ps PositionedShape) (primitive, bool) {
+	var vertices []Point
+	switch s := ps.Shape.(type) {
+	case *Circle:
+		return primitive{circle: true, center: ps.Position, radius: s.Radius}, true
+	case *Rectangle:
+		vertices = s.vertices()
+	case *Triangle:
+		vertices = s.vertices()
+	default:
+		return primitive{}, false
+	}
+	for i := range vertices {
+		vertices[i] = vertices[i].Add(ps.Position)
+	}
+	return primitive{vertices: vertices}, true
+}
+
+// exactMeasures returns the area and perimeter of the combined shape when they
+// can be computed exactly: the shapes are disjoint, one lies strictly inside
+// the other, or both are circles
+func exactMeasures(op SetOperation, a, b PositionedShape) (area, perimeter float64, ok bool) {
+	pa, okA := toPrimitive(a)
+	pb, okB := toPrimitive(b)
+	if !okA || !okB {
+		return 0, 0, false
+	}
+	tol := tolerance(a.boundary(), b.boundary())
+
+	// Area of the intersection and the lengths of each boundary inside the other shape
+	var common, aIn, bIn float64
+	switch relate(pa, pb, tol) {
+	case disjoint:
+	case aInsideB:
+		common, aIn = a.Area(), a.Perimeter()
+	case bInsideA:
+		common, bIn = b.Area(), b.Perimeter()
+	default:
+		if !pa.circle || !pb.circle {
+			return 0, 0, false
+		}
+		d := distance(pa.center, pb.center)
+		alpha := 2 * math.Acos((d*d+pa.radius*pa.radius-pb.radius*pb.radius)/(2*d*pa.radius))
+		beta := 2 * math.Acos((d*d+pb.radius*pb.radius-pa.radius*pa.radius)/(2*d*pb.radius))
+		common = pa.radius*pa.radius*(alpha-math.Sin(alpha))/2 + pb.radius*pb.radius*(beta-math.Sin(beta))/2
+		aIn, bIn = pa.radius*alpha, pb.radius*beta
+	}
+
+	aOut, bOut := a.Perimeter()-aIn, b.Perimeter()-bIn
+	switch op {
+	case Union:
+		return a.Area() + b.Area() - common, aOut + bOut, true
+	case Intersection:
+		return common, aIn + bIn, true
+	default:
+		return a.Area() - common, aOut + bIn, true
+	}
+}
+
+// relate works out whether two primitives are strictly apart, strictly nested
+// or anything else, in which case they count as overlapping
+func relate(a, b primitive, tol float64) relation {
+	switch {
+	case a.circle && b.circle:
+		d := distance(a.center, b.center)
+		switch {
+		case d > a.radius+b.radius+tol:
+			return disjoint
+		case d+a.radius < b.radius-tol:
+			return aInsideB
+		case d+b.radius < a.radius-tol:
+			return bInsideA
+		}
+	case a.circle:
+		return swapRelation(relate(b, a, tol))
+	case b.circle:
+		if circleInsidePolygon(b, a.vertices, tol) {
+			return bInsideA
+		}
+		if verticesInsideCircle(a.vertices, b, tol) {
+			return aInsideB
+		}
+		if !convexContains(a.vertices, b.center) && polygonDistance(a.vertices, b.center) > b.radius+tol {
+			return disjoint
+		}
+	default:
+		if strictlyInside(b.vertices, a.vertices, tol) {
+			return bInsideA
+		}
+		if strictlyInside(a.vertices, b.vertices, tol) {
+			return aInsideB
+		}
+		if separated(a.vertices, b.vertices, tol) || separated(b.vertices, a.vertices, tol) {
+			return disjoint
+		}
+	}
+	return overlapping
+}
+
+func swapRelation(r relation) relation {
+	switch r {
+	case aInsideB:
+		return bInsideA
+	case bInsideA:
+		return aInsideB
+	default:
+		return r
+	}
+}
+
+// strictlyInside reports whether all points lie inside the convex polygon, away from its edges
+func strictlyInside(points, polygon []Point, tol float64) bool {
+	for _, p := range points {
+		for i := range polygon {
+			s := segment{polygon[i], polygon[(i+1)%len(polygon)]}
+			if cross(s.A, s.B, p) <= tol*s.length() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// separated reports whether an edge of polygon a separates it from polygon b
+func separated(a, b []Point, tol float64) bool {
+	for i := range a {
+		s := segment{a[i], a[(i+1)%len(a)]}
+		apart := true
+		for _, p := range b {
+			if cross(s.A, s.B, p) >= -tol*s.length() {
+				apart = false
+				break
+			}
+		}
+		if apart {
+			return true
+		}
+	}
+	return false
+}
+
+func circleInsidePolygon(c primitive, polygon []Point, tol float64) bool {
+	return strictlyInside([]Point{c.center}, polygon, tol) && polygonDistance(polygon, c.center) > c.radius+tol
+}
+
+func verticesInsideCircle(vertices []Point, c primitive, tol float64) bool {
+	for _, v := range vertices {
+		if distance(v, c.center) >= c.radius-tol {
+			return false
+		}
+	}
+	return true
+}
+
+// polygonDistance returns the distance from p to the closest edge of the polygon
+func polygonDistance(polygon []Point, p Point) float64 {
+	closest := math.Inf(1)
+	for _, s := range polygonLoop(polygon) {
+		closest = math.Min(closest, distanceToSegment(p, s))
+	}
+	return closest
+}
@@ -0,0 +1,180 @@
+package challenge10
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// approxEquals compares values that may come from polygon approximations of circles
+func approxEquals(a, b, relTol float64) bool {
+	return math.Abs(a-b) <= relTol*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func mustRectangle(t *testing.T, width, height float64) *Rectangle {
+	t.Helper()
+	r, err := NewRectangle(width, height)
+	if err != nil {
+		t.Fatalf("Failed to create rectangle: %v", err)
+	}
+	return r
+}
+
+func mustCircle(t *testing.T, radius float64) *Circle {
+	t.Helper()
+	c, err := NewCircle(radius)
+	if err != nil {
+		t.Fatalf("Failed to create circle: %v", err)
+	}
+	return c
+}
+
+func mustTriangle(t *testing.T, a, b, c float64) *Triangle {
+	t.Helper()
+	tri, err := NewTriangle(a, b, c)
+	if err != nil {
+		t.Fatalf("Failed to create triangle: %v", err)
+	}
+	return tri
+}
+
+func TestCompositeShapeMeasures(t *testing.T) {
+	square := mustRectangle(t, 2, 2)
+	unit := mustRectangle(t, 1, 1)
+	circle := mustCircle(t, 1)
+	hole := mustCircle(t, 0.5)
+	lens := 2*math.Pi/3 - math.Sqrt(3)/2 // two unit circles one radius apart
+
+	tests := []struct {
+		name              string
+		op                SetOperation
+		a, b              PositionedShape
+		expectedArea      float64
+		expectedPerimeter float64
+		relTol            float64
+	}{
+		{"Rectangle with a circular hole", Difference, Place(mustRectangle(t, 4, 2), 0, 0), Place(hole, 2, 1), 8 - math.Pi/4, 12 + math.Pi, epsilon},
+		{"Disjoint union", Union, Place(square, 0, 0), Place(circle, 5, 5), 4 + math.Pi, 8 + 2*math.Pi, epsilon},
+		{"Circle inside square intersection", Intersection, Place(square, 0, 0), Place(hole, 1, 1), math.Pi / 4, math.Pi, epsilon},
+		{"Circle lens", Intersection, Place(circle, 0, 0), Place(circle, 1, 0), lens, 4 * math.Pi / 3, epsilon},
+		{"Circle union", Union, Place(circle, 0, 0), Place(circle, 1, 0), 2*math.Pi - lens, 8 * math.Pi / 3, epsilon},
+		{"Circle crescent", Difference, Place(circle, 0, 0), Place(circle, 1, 0), math.Pi - lens, 2 * math.Pi, epsilon},
+		{"Overlapping squares union", Union, Place(square, 0, 0), Place(square, 1, 1), 7, 12, epsilon},
+		{"Overlapping squares intersection", Intersection, Place(square, 0, 0), Place(square, 1, 1), 1, 4, epsilon},
+		{"Overlapping squares difference", Difference, Place(square, 0, 0), Place(square, 1, 1), 3, 8, epsilon},
+		{"Squares sharing an edge", Union, Place(unit, 0, 0), Place(unit, 1, 0), 2, 6, epsilon},
+		{"Notch cut from an edge", Difference, Place(square, 0, 0), Place(unit, 0.5, 1), 3, 10, epsilon},
+		{"Triangle clipped by square", Intersection, Place(mustTriangle(t, 5, 5, 6), 0, 0), Place(mustRectangle(t, 6, 2), 0, 0), 9, 14, epsilon},
+		{"Quarter circle cut from square", Difference, Place(square, 0, 0), Place(circle, 0, 0), 4 - math.Pi/4, 6 + math.Pi/2, 1e-5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := NewCompositeShape(tt.op, tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Failed to create composite shape: %v", err)
+			}
+			if !approxEquals(cs.Area(), tt.expectedArea, tt.relTol) {
+				t.Errorf("Expected area %.9f, got %.9f", tt.expectedArea, cs.Area())
+			}
+			if !approxEquals(cs.Perimeter(), tt.expectedPerimeter, tt.relTol) {
+				t.Errorf("Expected perimeter %.9f, got %.9f", tt.expectedPerimeter, cs.Perimeter())
+			}
+		})
+	}
+}
+
+func TestNestedCompositeShape(t *testing.T) {
+	// A 4x4 plate with two holes, joined with a tab on its right side
+	plate := mustRectangle(t, 4, 4)
+	hole := mustCircle(t, 0.5)
+
+	oneHole, err := NewCompositeShape(Difference, Place(plate, 0, 0), Place(hole, 1, 1))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+	twoHoles, err := NewCompositeShape(Difference, Place(oneHole, 0, 0), Place(hole, 3, 3))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+	withTab, err := NewCompositeShape(Union, Place(twoHoles, 10, 10), Place(mustRectangle(t, 1, 2), 14, 11))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+
+	expectedArea := 16 - 2*hole.Area() + 2
+	if !approxEquals(withTab.Area(), expectedArea, 1e-5) {
+		t.Errorf("Expected area %.6f, got %.6f", expectedArea, withTab.Area())
+	}
+	expectedPerimeter := 16 + 2*hole.Perimeter() + 2
+	if !approxEquals(withTab.Perimeter(), expectedPerimeter, 1e-5) {
+		t.Errorf("Expected perimeter %.6f, got %.6f", expectedPerimeter, withTab.Perimeter())
+	}
+
+	points := []struct {
+		p        Point
+		expected bool
+	}{
+		{Point{10.1, 10.1}, true},
+		{Point{11, 11}, false}, // center of the first hole
+		{Point{13, 13}, false}, // center of the second hole
+		{Point{14.5, 12}, true},
+		{Point{14.5, 13.5}, false},
+		{Point{10, 12}, true}, // on the boundary
+		{Point{11.5, 11}, true},
+	}
+	for _, tp := range points {
+		if got := withTab.Contains(tp.p); got != tp.expected {
+			t.Errorf("Contains(%s): expected %v, got %v", tp.p, tp.expected, got)
+		}
+	}
+
+	// Composite shapes work with the existing calculator
+	calculator := NewShapeCalculator()
+	if largest := calculator.LargestShape([]Shape{plate, withTab}); largest != withTab {
+		t.Errorf("Expected the plate with tab to be the largest shape, got %s", largest)
+	}
+}
+
+func TestCompositeShapeErrors(t *testing.T) {
+	square := mustRectangle(t, 2, 2)
+
+	tests := []struct {
+		name string
+		op   SetOperation
+		a, b PositionedShape
+	}{
+		{"Empty intersection", Intersection, Place(square, 0, 0), Place(square, 5, 0)},
+		{"Touching intersection", Intersection, Place(square, 0, 0), Place(square, 2, 0)},
+		{"Nothing left", Difference, Place(square, 0, 0), Place(mustCircle(t, 3), 1, 1)},
+		{"Nil shape", Union, Place(square, 0, 0), PositionedShape{}},
+		{"Unknown operation", SetOperation(7), Place(square, 0, 0), Place(square, 1, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cs, err := NewCompositeShape(tt.op, tt.a, tt.b); err == nil {
+				t.Errorf("Expected an error, got %s", cs)
+			}
+		})
+	}
+}
+
+func TestCompositeShapeString(t *testing.T) {
+	cs, err := NewCompositeShape(Difference, Place(mustRectangle(t, 4, 2), 0, 0), Place(mustCircle(t, 0.5), 2, 1))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+	expected := "Difference(Rectangle(width=4.00, height=2.00) at (0.00, 0.00), Circle(radius=0.50) at (2.00, 1.00))"
+	if cs.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, cs.String())
+	}
+
+	nested, err := NewCompositeShape(Union, Place(cs, 1, 1), Place(mustRectangle(t, 1, 1), 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+	if !strings.HasPrefix(nested.String(), "Union(Difference(") {
+		t.Errorf("Expected nested rendering, got %q", nested.String())
+	}
+}
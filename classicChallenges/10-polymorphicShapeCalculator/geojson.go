@@ -0,0 +1,67 @@
+package challenge10
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// geoJSONObject is a GeoJSON geometry or feature. Only the members used for
+// polygons are decoded.
+type geoJSONObject struct {
+	Type        string         `json:"type"`
+	Coordinates [][][]float64  `json:"coordinates,omitempty"`
+	Geometry    *geoJSONObject `json:"geometry,omitempty"`
+}
+
+// MarshalGeoJSON returns a GeoJSON Polygon geometry for a Rectangle, Triangle
+// or Polygon, or for one of them positioned
+func MarshalGeoJSON(s Shape) ([]byte, error) {
+	vertices, err := ring(s)
+	if err != nil {
+		return nil, err
+	}
+	coordinates := make([][]float64, 0, len(vertices)+1)
+	for _, v := range append(vertices, vertices[0]) {
+		coordinates = append(coordinates, []float64{v.X, v.Y})
+	}
+	return json.Marshal(geoJSONObject{Type: "Polygon", Coordinates: [][][]float64{coordinates}})
+}
+
+// ParseGeoJSON reads a GeoJSON Polygon geometry, or a Feature holding one.
+// See shapeFromRing for the shape it is turned into. Polygons with holes are
+// not supported.
+func ParseGeoJSON(data []byte) (PositionedShape, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return PositionedShape{}, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if obj.Type == "Feature" {
+		if obj.Geometry == nil {
+			return PositionedShape{}, errors.New("invalid GeoJSON: feature has no geometry")
+		}
+		obj = *obj.Geometry
+	}
+	if obj.Type != "Polygon" {
+		return PositionedShape{}, fmt.Errorf("invalid GeoJSON: expected a Polygon, got %q", obj.Type)
+	}
+	switch len(obj.Coordinates) {
+	case 0:
+		return PositionedShape{}, errors.New("invalid GeoJSON: polygon has no coordinates")
+	case 1:
+	default:
+		return PositionedShape{}, errors.New("invalid GeoJSON: polygons with holes are not supported")
+	}
+
+	var vertices []Point
+	for _, position := range obj.Coordinates[0] {
+		if len(position) != 2 {
+			return PositionedShape{}, fmt.Errorf("invalid GeoJSON: expected 2D positions, got %v", position)
+		}
+		vertices = append(vertices, Point{position[0], position[1]})
+	}
+	if len(vertices) < 4 || vertices[0] != vertices[len(vertices)-1] {
+		return PositionedShape{}, errors.New("invalid GeoJSON: a polygon ring should have at least 4 positions and end where it starts")
+	}
+	return shapeFromRing(vertices)
+}
@@ -0,0 +1,83 @@
+package challenge10
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMarshalGeoJSON(t *testing.T) {
+	data, err := MarshalGeoJSON(Place(mustRectangle(t, 2, 1), 1, 1))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expected := `{"type":"Polygon","coordinates":[[[1,1],[3,1],[3,2],[1,2],[1,1]]]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	if _, err := MarshalGeoJSON(Place(mustCircle(t, 1), 0, 0)); !errors.Is(err, ErrCircleNotSupported) {
+		t.Errorf("Expected ErrCircleNotSupported, got %v", err)
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		expectedShape string
+		shouldError   bool
+	}{
+		{"Geometry", `{"type":"Polygon","coordinates":[[[0,0],[6,0],[3,4],[0,0]]]}`, "Triangle(sides=5.00, 5.00, 6.00)", false},
+		{"Feature", `{"type":"Feature","properties":{"name":"lot"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[2,0],[2,2],[0,2],[0,0]]]}}`, "Rectangle(width=2.00, height=2.00)", false},
+		{"Point", `{"type":"Point","coordinates":[1,2]}`, "", true},
+		{"Feature without geometry", `{"type":"Feature","geometry":null}`, "", true},
+		{"Hole", `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,0]],[[1,1],[2,1],[2,2],[1,1]]]}`, "", true},
+		{"Open ring", `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4]]]}`, "", true},
+		{"3D positions", `{"type":"Polygon","coordinates":[[[0,0,1],[4,0,1],[4,4,1],[0,0,1]]]}`, "", true},
+		{"Collinear", `{"type":"Polygon","coordinates":[[[0,0],[1,1],[2,2],[3,3],[0,0]]]}`, "", true},
+		{"Not JSON", `POLYGON`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := ParseGeoJSON([]byte(tt.json))
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected an error, but got %s", ps)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if ps.Shape.String() != tt.expectedShape {
+				t.Errorf("Expected %s, got %s", tt.expectedShape, ps.Shape)
+			}
+		})
+	}
+}
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+	polygon, err := NewPolygon([]Point{{0, 0}, {4, 0}, {4, 4}, {2, 1}, {0, 4}})
+	if err != nil {
+		t.Fatalf("Failed to create polygon: %v", err)
+	}
+	data, err := MarshalGeoJSON(polygon)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	parsed, err := ParseGeoJSON(data)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", data, err)
+	}
+	if !floatEquals(parsed.Area(), polygon.Area()) {
+		t.Errorf("Expected area %v, got %v", polygon.Area(), parsed.Area())
+	}
+	again, err := MarshalGeoJSON(parsed)
+	if err != nil {
+		t.Fatalf("Failed to marshal parsed shape: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("Expected %s after a round trip, got %s", data, again)
+	}
+}
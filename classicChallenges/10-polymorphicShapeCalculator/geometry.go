@@ -0,0 +1,196 @@
+package challenge10
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a position in the plane
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Add returns the point moved by q
+func (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+// Sub returns the vector from q to p
+func (p Point) Sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+// String returns a string representation of the point
+func (p Point) String() string {
+	return fmt.Sprintf("(%.2f, %.2f)", p.X, p.Y)
+}
+
+// circleSegments is the number of edges used when a circle has to be treated as a polygon
+const circleSegments = 1024
+
+// Each shape has its own coordinate system, used by Contains and when the
+// shape is positioned:
+//   - a Rectangle spans (0, 0) to (Width, Height)
+//   - a Circle is centered on (0, 0)
+//   - a Triangle has its first vertex at (0, 0), side C along the x axis
+//     and the third vertex above it
+
+// Contains reports whether p lies inside or on the rectangle
+func (r *Rectangle) Contains(p Point) bool {
+	return p.X >= 0 && p.X <= r.Width && p.Y >= 0 && p.Y <= r.Height
+}
+
+// Contains reports whether p lies inside or on the circle
+func (c *Circle) Contains(p Point) bool {
+	return p.X*p.X+p.Y*p.Y <= c.Radius*c.Radius
+}
+
+// Contains reports whether p lies inside or on the triangle
+func (t *Triangle) Contains(p Point) bool {
+	return convexContains(t.vertices(), p)
+}
+
+func (r *Rectangle) vertices() []Point {
+	return []Point{{0, 0}, {r.Width, 0}, {r.Width, r.Height}, {0, r.Height}}
+}
+
+// vertices places the triangle so that side C runs from the first to the
+// second vertex, side B from the first to the third and side A from the
+// second to the third
+func (t *Triangle) vertices() []Point {
+	x := (t.SideB*t.SideB + t.SideC*t.SideC - t.SideA*t.SideA) / (2 * t.SideC)
+	y := math.Sqrt(math.Max(0, t.SideB*t.SideB-x*x))
+	return []Point{{0, 0}, {t.SideC, 0}, {x, y}}
+}
+
+func (r *Rectangle) outline() []segment {
+	return polygonLoop(r.vertices())
+}
+
+func (c *Circle) outline() []segment {
+	vertices := make([]Point, circleSegments)
+	for i := range vertices {
+		angle := 2 * math.Pi * float64(i) / circleSegments
+		vertices[i] = Point{c.Radius * math.Cos(angle), c.Radius * math.Sin(angle)}
+	}
+	return polygonLoop(vertices)
+}
+
+func (t *Triangle) outline() []segment {
+	return polygonLoop(t.vertices())
+}
+
+// segment is a directed edge of a boundary. Boundaries run counter-clockwise
+// around the area they enclose, so the inside is on the left of every segment.
+type segment struct {
+	A Point
+	B Point
+}
+
+func (s segment) reverse() segment {
+	return segment{s.B, s.A}
+}
+
+func (s segment) length() float64 {
+	return math.Hypot(s.B.X-s.A.X, s.B.Y-s.A.Y)
+}
+
+func (s segment) at(t float64) Point {
+	return Point{s.A.X + t*(s.B.X-s.A.X), s.A.Y + t*(s.B.Y-s.A.Y)}
+}
+
+// polygonLoop returns the closed boundary through the vertices in order
+func polygonLoop(vertices []Point) []segment {
+	loop := make([]segment, len(vertices))
+	for i := range vertices {
+		loop[i] = segment{vertices[i], vertices[(i+1)%len(vertices)]}
+	}
+	return loop
+}
+
+func translate(segments []segment, offset Point) []segment {
+	res := make([]segment, len(segments))
+	for i, s := range segments {
+		res[i] = segment{s.A.Add(offset), s.B.Add(offset)}
+	}
+	return res
+}
+
+// cross returns the z component of (a - o) x (b - o): positive if b is to the
+// left of the line from o through a
+func cross(o, a, b Point) float64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+func dot(a, b Point) float64 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+func distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// distanceToSegment returns the distance from p to the closest point of s
+func distanceToSegment(p Point, s segment) float64 {
+	d := s.B.Sub(s.A)
+	lengthSq := dot(d, d)
+	if lengthSq == 0 {
+		return distance(p, s.A)
+	}
+	t := math.Max(0, math.Min(1, dot(p.Sub(s.A), d)/lengthSq))
+	return distance(p, s.at(t))
+}
+
+// convexContains reports whether p lies inside or on a counter-clockwise convex polygon
+func convexContains(vertices []Point, p Point) bool {
+	for i := range vertices {
+		if cross(vertices[i], vertices[(i+1)%len(vertices)], p) < -1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+// boundaryArea returns the area enclosed by counter-clockwise boundaries (shoelace formula)
+func boundaryArea(segments []segment) float64 {
+	var sum float64
+	for _, s := range segments {
+		sum += s.A.X*s.B.Y - s.B.X*s.A.Y
+	}
+	return sum / 2
+}
+
+func boundaryLength(segments []segment) float64 {
+	var sum float64
+	for _, s := range segments {
+		sum += s.length()
+	}
+	return sum
+}
+
+// winding returns the winding number of the boundary around p; non-zero means inside
+func winding(segments []segment, p Point) int {
+	wn := 0
+	for _, s := range segments {
+		if s.A.Y <= p.Y {
+			if s.B.Y > p.Y && cross(s.A, s.B, p) > 0 {
+				wn++
+			}
+		} else if s.B.Y <= p.Y && cross(s.A, s.B, p) < 0 {
+			wn--
+		}
+	}
+	return wn
+}
+
+// tolerance returns the distance below which points of the boundaries are considered equal
+func tolerance(boundaries ...[]segment) float64 {
+	scale := 1.0
+	for _, segments := range boundaries {
+		for _, s := range segments {
+			scale = math.Max(scale, math.Max(math.Max(math.Abs(s.A.X), math.Abs(s.A.Y)), math.Max(math.Abs(s.B.X), math.Abs(s.B.Y))))
+		}
+	}
+	return 1e-9 * scale
+}
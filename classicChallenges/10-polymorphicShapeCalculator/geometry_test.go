@@ -0,0 +1,67 @@
+package challenge10
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShapeContains(t *testing.T) {
+	rect := mustRectangle(t, 4, 2)
+	circle := mustCircle(t, 1)
+	tri := mustTriangle(t, 5, 5, 6) // vertices (0, 0), (6, 0) and (3, 4)
+
+	tests := []struct {
+		name     string
+		shape    interface{ Contains(Point) bool }
+		p        Point
+		expected bool
+	}{
+		{"Rectangle inside", rect, Point{1, 1}, true},
+		{"Rectangle corner", rect, Point{4, 2}, true},
+		{"Rectangle outside", rect, Point{4.1, 1}, false},
+		{"Rectangle below", rect, Point{1, -0.1}, false},
+		{"Circle center", circle, Point{0, 0}, true},
+		{"Circle edge", circle, Point{0, -1}, true},
+		{"Circle outside", circle, Point{0.8, 0.8}, false},
+		{"Triangle inside", tri, Point{3, 1}, true},
+		{"Triangle apex", tri, Point{3, 4}, true},
+		{"Triangle outside", tri, Point{1, 3}, false},
+		{"Positioned circle", Place(circle, 5, 5), Point{5.5, 5.5}, true},
+		{"Positioned circle origin", Place(circle, 5, 5), Point{0, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.Contains(tt.p); got != tt.expected {
+				t.Errorf("Contains(%s): expected %v, got %v", tt.p, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOutlineMatchesShape(t *testing.T) {
+	shapes := []placeable{mustRectangle(t, 3, 2), mustTriangle(t, 3, 4, 5), mustTriangle(t, 7, 5, 9)}
+	for _, s := range shapes {
+		t.Run(s.String(), func(t *testing.T) {
+			outline := s.outline()
+			if !floatEquals(boundaryArea(outline), s.Area()) {
+				t.Errorf("Expected outline area %.6f, got %.6f", s.Area(), boundaryArea(outline))
+			}
+			if !floatEquals(boundaryLength(outline), s.Perimeter()) {
+				t.Errorf("Expected outline length %.6f, got %.6f", s.Perimeter(), boundaryLength(outline))
+			}
+		})
+	}
+
+	circle := mustCircle(t, 2)
+	if area := boundaryArea(circle.outline()); math.Abs(area-circle.Area())/circle.Area() > 1e-5 {
+		t.Errorf("Expected circle outline area close to %.6f, got %.6f", circle.Area(), area)
+	}
+}
+
+func TestPointString(t *testing.T) {
+	p := Point{1, 2}.Add(Point{0.5, -3}).Sub(Point{1, 1})
+	if p.String() != "(0.50, -2.00)" {
+		t.Errorf("Expected (0.50, -2.00), got %s", p)
+	}
+}
@@ -0,0 +1,351 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// PathSegment is a curve from PointAt(0) to PointAt(1)
+type PathSegment interface {
+	PointAt(t float64) Point
+	// Derivative returns the velocity of PointAt at t
+	Derivative(t float64) Point
+	// Length returns the arc length, within tolerance of the exact value
+	Length(tolerance float64) float64
+}
+
+// LineSegment is a straight segment
+type LineSegment struct {
+	From Point
+	To   Point
+}
+
+// PointAt returns the point a fraction t along the line
+func (l LineSegment) PointAt(t float64) Point {
+	return segment{l.From, l.To}.at(t)
+}
+
+// Derivative returns the direction of the line
+func (l LineSegment) Derivative(t float64) Point {
+	return l.To.Sub(l.From)
+}
+
+// Length returns the exact length of the line
+func (l LineSegment) Length(tolerance float64) float64 {
+	return distance(l.From, l.To)
+}
+
+// ArcSegment is part of a circle, from StartAngle to EndAngle in radians.
+// The arc runs counter-clockwise if EndAngle > StartAngle and clockwise otherwise.
+type ArcSegment struct {
+	Center     Point
+	Radius     float64
+	StartAngle float64
+	EndAngle   float64
+}
+
+// PointAt returns the point a fraction t along the arc
+func (a ArcSegment) PointAt(t float64) Point {
+	angle := a.StartAngle + t*(a.EndAngle-a.StartAngle)
+	return Point{a.Center.X + a.Radius*math.Cos(angle), a.Center.Y + a.Radius*math.Sin(angle)}
+}
+
+// Derivative returns the velocity along the arc
+func (a ArcSegment) Derivative(t float64) Point {
+	sweep := a.EndAngle - a.StartAngle
+	angle := a.StartAngle + t*sweep
+	return Point{-a.Radius * sweep * math.Sin(angle), a.Radius * sweep * math.Cos(angle)}
+}
+
+// Length returns the exact length of the arc
+func (a ArcSegment) Length(tolerance float64) float64 {
+	return a.Radius * math.Abs(a.EndAngle-a.StartAngle)
+}
+
+// QuadraticBezier is a Bézier curve from P0 to P2 with control point P1
+type QuadraticBezier struct {
+	P0, P1, P2 Point
+}
+
+// PointAt returns the point of the curve at parameter t
+func (q QuadraticBezier) PointAt(t float64) Point {
+	u := 1 - t
+	return Point{
+		u*u*q.P0.X + 2*u*t*q.P1.X + t*t*q.P2.X,
+		u*u*q.P0.Y + 2*u*t*q.P1.Y + t*t*q.P2.Y,
+	}
+}
+
+// Derivative returns the velocity of the curve at parameter t
+func (q QuadraticBezier) Derivative(t float64) Point {
+	u := 1 - t
+	return Point{
+		2*u*(q.P1.X-q.P0.X) + 2*t*(q.P2.X-q.P1.X),
+		2*u*(q.P1.Y-q.P0.Y) + 2*t*(q.P2.Y-q.P1.Y),
+	}
+}
+
+// Length returns the arc length of the curve within tolerance
+func (q QuadraticBezier) Length(tolerance float64) float64 {
+	return arcLength(q, tolerance)
+}
+
+// CubicBezier is a Bézier curve from P0 to P3 with control points P1 and P2
+type CubicBezier struct {
+	P0, P1, P2, P3 Point
+}
+
+// PointAt returns the point of the curve at parameter t
+func (c CubicBezier) PointAt(t float64) Point {
+	u := 1 - t
+	b0, b1, b2, b3 := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return Point{
+		b0*c.P0.X + b1*c.P1.X + b2*c.P2.X + b3*c.P3.X,
+		b0*c.P0.Y + b1*c.P1.Y + b2*c.P2.Y + b3*c.P3.Y,
+	}
+}
+
+// Derivative returns the velocity of the curve at parameter t
+func (c CubicBezier) Derivative(t float64) Point {
+	u := 1 - t
+	d0, d1, d2 := 3*u*u, 6*u*t, 3*t*t
+	return Point{
+		d0*(c.P1.X-c.P0.X) + d1*(c.P2.X-c.P1.X) + d2*(c.P3.X-c.P2.X),
+		d0*(c.P1.Y-c.P0.Y) + d1*(c.P2.Y-c.P1.Y) + d2*(c.P3.Y-c.P2.Y),
+	}
+}
+
+// Length returns the arc length of the curve within tolerance
+func (c CubicBezier) Length(tolerance float64) float64 {
+	return arcLength(c, tolerance)
+}
+
+// arcLength integrates the speed of a segment with adaptive Simpson's rule
+func arcLength(s PathSegment, tolerance float64) float64 {
+	speed := func(t float64) float64 {
+		d := s.Derivative(t)
+		return math.Hypot(d.X, d.Y)
+	}
+	// Start from a few panels so curves with an inflection are not mistaken for flat ones
+	const panels = 4
+	var sum float64
+	for i := 0; i < panels; i++ {
+		a, b := float64(i)/panels, float64(i+1)/panels
+		fa, fm, fb := speed(a), speed((a+b)/2), speed(b)
+		sum += adaptiveSimpson(speed, a, b, fa, fm, fb, simpson(a, b, fa, fm, fb), tolerance/panels, 50)
+	}
+	return sum
+}
+
+func simpson(a, b, fa, fm, fb float64) float64 {
+	return (b - a) / 6 * (fa + 4*fm + fb)
+}
+
+func adaptiveSimpson(f func(float64) float64, a, b, fa, fm, fb, whole, tolerance float64, depth int) float64 {
+	m := (a + b) / 2
+	lm, rm := (a+m)/2, (m+b)/2
+	flm, frm := f(lm), f(rm)
+	left, right := simpson(a, m, fa, flm, fm), simpson(m, b, fm, frm, fb)
+	if depth <= 0 || math.Abs(left+right-whole) <= 15*tolerance {
+		return left + right + (left+right-whole)/15
+	}
+	return adaptiveSimpson(f, a, m, fa, flm, fm, left, tolerance/2, depth-1) +
+		adaptiveSimpson(f, m, b, fm, frm, fb, right, tolerance/2, depth-1)
+}
+
+// greenTerm returns the integral of (x dy - y dx) / 2 along the segment, so
+// that the terms of a closed path add up to its signed area (Green's theorem)
+func greenTerm(s PathSegment) float64 {
+	if v, ok := segmentValue(s); ok {
+		s = v
+	}
+	switch seg := s.(type) {
+	case LineSegment:
+		return (seg.From.X*seg.To.Y - seg.To.X*seg.From.Y) / 2
+	case ArcSegment:
+		c, r := seg.Center, seg.Radius
+		return (r*r*(seg.EndAngle-seg.StartAngle) +
+			r*c.X*(math.Sin(seg.EndAngle)-math.Sin(seg.StartAngle)) -
+			r*c.Y*(math.Cos(seg.EndAngle)-math.Cos(seg.StartAngle))) / 2
+	}
+	// Five-point Gauss-Legendre quadrature is exact for polynomials up to
+	// degree 9, which covers x y' - y x' of cubic Bézier curves
+	nodes := []float64{0, -0.5384693101056831, 0.5384693101056831, -0.9061798459386640, 0.9061798459386640}
+	weights := []float64{0.5688888888888889, 0.4786286704993665, 0.4786286704993665, 0.2369268850561891, 0.2369268850561891}
+	var sum float64
+	for i, x := range nodes {
+		t := (x + 1) / 2
+		p, d := s.PointAt(t), s.Derivative(t)
+		sum += weights[i] * (p.X*d.Y - p.Y*d.X)
+	}
+	// The interval [0, 1] is half the length of [-1, 1]
+	return sum / 4
+}
+
+// segmentValue returns the segment a pointer to one of the segment types of
+// this file points to, so that *LineSegment and LineSegment are treated alike.
+// Other segments are returned as they are. ok is false for nil.
+func segmentValue(s PathSegment) (v PathSegment, ok bool) {
+	switch seg := s.(type) {
+	case nil:
+		return nil, false
+	case *LineSegment:
+		if seg == nil {
+			return nil, false
+		}
+		return *seg, true
+	case *ArcSegment:
+		if seg == nil {
+			return nil, false
+		}
+		return *seg, true
+	case *QuadraticBezier:
+		if seg == nil {
+			return nil, false
+		}
+		return *seg, true
+	case *CubicBezier:
+		if seg == nil {
+			return nil, false
+		}
+		return *seg, true
+	}
+	return s, true
+}
+
+// PathShape is a shape bounded by a closed path of lines, arcs and Bézier
+// curves. Its coordinates are its own coordinate system.
+type PathShape struct {
+	Segments  []PathSegment
+	Tolerance float64 // accuracy of Perimeter, and of Contains near curved edges
+
+	area  float64
+	edges []segment // flattened boundary, counter-clockwise
+}
+
+// NewPathShape creates a new PathShape with validation. Each segment must
+// start where the previous one ends, and the last must end where the first
+// starts. The path may run either way round, but must not cross or touch
+// itself, which is checked on the path flattened to within tolerance.
+func NewPathShape(tolerance float64, segments ...PathSegment) (*PathShape, error) {
+	if tolerance <= 0 {
+		return nil, errors.New("tolerance should be a positive number")
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("a path needs at least one segment")
+	}
+	// Keep values rather than pointers, so later changes through a pointer
+	// cannot invalidate the area and edges computed here
+	segments = append([]PathSegment(nil), segments...)
+	for i, s := range segments {
+		v, ok := segmentValue(s)
+		if !ok {
+			return nil, fmt.Errorf("segment %d is nil", i)
+		}
+		segments[i] = v
+	}
+	for i, s := range segments {
+		if arc, ok := s.(ArcSegment); ok && (arc.Radius <= 0 || arc.StartAngle == arc.EndAngle) {
+			return nil, fmt.Errorf("arc %d should have a positive radius and sweep", i)
+		}
+		next := segments[(i+1)%len(segments)]
+		if end, start := s.PointAt(1), next.PointAt(0); distance(end, start) > tolerance {
+			if i == len(segments)-1 {
+				return nil, fmt.Errorf("path is not closed: it ends at %s and starts at %s", end, start)
+			}
+			return nil, fmt.Errorf("segment %d ends at %s but segment %d starts at %s", i, end, i+1, start)
+		}
+	}
+
+	ps := &PathShape{Segments: segments, Tolerance: tolerance}
+	for _, s := range segments {
+		ps.area += greenTerm(s)
+	}
+	// owners holds the index of the segment each flattened edge belongs to
+	var vertices []Point
+	var owners []int
+	for i, s := range segments {
+		for _, v := range flatten(s, tolerance) {
+			// Drop the duplicate left by a segment of zero length, which
+			// would make its neighbours look like they touch
+			if len(vertices) > 0 && vertices[len(vertices)-1] == v {
+				continue
+			}
+			vertices = append(vertices, v)
+			owners = append(owners, i)
+		}
+	}
+	if i, j, ok := selfIntersection(vertices); ok {
+		if owners[i] == owners[j] {
+			return nil, fmt.Errorf("a path should not cross itself, but segment %d does", owners[i])
+		}
+		return nil, fmt.Errorf("a path should not cross itself, but segments %d and %d do", owners[i], owners[j])
+	}
+	if ps.area < 0 {
+		ps.area = -ps.area
+		for i, j := 0, len(vertices)-1; i < j; i, j = i+1, j-1 {
+			vertices[i], vertices[j] = vertices[j], vertices[i]
+		}
+	}
+	if ps.area <= tolerance*tolerance {
+		return nil, errors.New("a path should enclose a positive area")
+	}
+	ps.edges = polygonLoop(vertices)
+	return ps, nil
+}
+
+// flatten returns points along the segment, without its end, such that the
+// chords between them stay within tolerance of the curve
+func flatten(s PathSegment, tolerance float64) []Point {
+	if v, ok := segmentValue(s); ok {
+		s = v
+	}
+	if l, ok := s.(LineSegment); ok {
+		return []Point{l.From}
+	}
+	var points []Point
+	var subdivide func(t0, t1 float64, p0, p1 Point, depth int)
+	subdivide = func(t0, t1 float64, p0, p1 Point, depth int) {
+		tm := (t0 + t1) / 2
+		pm := s.PointAt(tm)
+		// Always split a few times so an S-shaped curve is not taken for a line
+		if depth > 20 || (depth >= 3 && distanceToSegment(pm, segment{p0, p1}) <= tolerance) {
+			points = append(points, p0)
+			return
+		}
+		subdivide(t0, tm, p0, pm, depth+1)
+		subdivide(tm, t1, pm, p1, depth+1)
+	}
+	subdivide(0, 1, s.PointAt(0), s.PointAt(1), 0)
+	return points
+}
+
+// Area returns the area enclosed by the path, computed with Green's theorem
+func (ps *PathShape) Area() float64 {
+	return ps.area
+}
+
+// Perimeter returns the length of the path within Tolerance
+func (ps *PathShape) Perimeter() float64 {
+	var sum float64
+	for _, s := range ps.Segments {
+		sum += s.Length(ps.Tolerance / float64(len(ps.Segments)))
+	}
+	return sum
+}
+
+// String returns a string representation of the path
+func (ps *PathShape) String() string {
+	return fmt.Sprintf("Path(segments=%d, area=%.2f)", len(ps.Segments), ps.area)
+}
+
+// Contains reports whether p lies inside or on the path. Points within
+// Tolerance of a curved edge may be misclassified.
+func (ps *PathShape) Contains(p Point) bool {
+	return winding(ps.edges, p) != 0 || onBoundary(ps.edges, p, tolerance(ps.edges))
+}
+
+func (ps *PathShape) outline() []segment {
+	return ps.edges
+}
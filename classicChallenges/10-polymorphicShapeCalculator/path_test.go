@@ -0,0 +1,214 @@
+package challenge10
+
+import (
+	"math"
+	"testing"
+)
+
+// kappa places cubic Bézier control points so four curves approximate a unit circle
+const kappa = 0.5522847498307936
+
+func bezierCircle(r float64) []PathSegment {
+	k := kappa * r
+	return []PathSegment{
+		CubicBezier{Point{r, 0}, Point{r, k}, Point{k, r}, Point{0, r}},
+		CubicBezier{Point{0, r}, Point{-k, r}, Point{-r, k}, Point{-r, 0}},
+		CubicBezier{Point{-r, 0}, Point{-r, -k}, Point{-k, -r}, Point{0, -r}},
+		CubicBezier{Point{0, -r}, Point{k, -r}, Point{r, -k}, Point{r, 0}},
+	}
+}
+
+func TestPathShape(t *testing.T) {
+	// Length of y = x^2 for x in [-1, 1]
+	parabola := math.Sqrt(5) + math.Asinh(2)/2
+
+	tests := []struct {
+		name              string
+		segments          []PathSegment
+		expectedArea      float64
+		expectedPerimeter float64
+		relTol            float64
+	}{
+		{
+			"Square of lines",
+			[]PathSegment{
+				LineSegment{Point{0, 0}, Point{2, 0}},
+				LineSegment{Point{2, 0}, Point{2, 2}},
+				LineSegment{Point{2, 2}, Point{0, 2}},
+				LineSegment{Point{0, 2}, Point{0, 0}},
+			},
+			4, 8, 1e-12,
+		},
+		{
+			"Full circle arc",
+			[]PathSegment{ArcSegment{Point{1, 1}, 2, 0, 2 * math.Pi}},
+			4 * math.Pi, 4 * math.Pi, 1e-12,
+		},
+		{
+			"Half disc",
+			[]PathSegment{
+				ArcSegment{Point{0, 0}, 1, 0, math.Pi},
+				LineSegment{Point{-1, 0}, Point{1, 0}},
+			},
+			math.Pi / 2, math.Pi + 2, 1e-12,
+		},
+		{
+			"Clockwise half disc",
+			[]PathSegment{
+				LineSegment{Point{1, 0}, Point{-1, 0}},
+				ArcSegment{Point{0, 0}, 1, math.Pi, 0},
+			},
+			math.Pi / 2, math.Pi + 2, 1e-12,
+		},
+		{
+			"Pointer segments",
+			[]PathSegment{
+				&ArcSegment{Point{0, 0}, 1, 0, math.Pi},
+				&LineSegment{Point{-1, 0}, Point{1, 0}},
+			},
+			math.Pi / 2, math.Pi + 2, 1e-12,
+		},
+		{
+			"Parabola cap",
+			[]PathSegment{
+				QuadraticBezier{Point{-1, 1}, Point{0, -1}, Point{1, 1}},
+				LineSegment{Point{1, 1}, Point{-1, 1}},
+			},
+			4.0 / 3, parabola + 2, 1e-9,
+		},
+		{
+			// The Bézier circle is slightly larger than the true circle
+			"Bézier circle",
+			bezierCircle(1),
+			math.Pi, 2 * math.Pi, 5e-4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := NewPathShape(1e-9, tt.segments...)
+			if err != nil {
+				t.Fatalf("Failed to create path: %v", err)
+			}
+			if !approxEquals(ps.Area(), tt.expectedArea, tt.relTol) {
+				t.Errorf("Expected area %v, got %v", tt.expectedArea, ps.Area())
+			}
+			if !approxEquals(ps.Perimeter(), tt.expectedPerimeter, tt.relTol) {
+				t.Errorf("Expected perimeter %v, got %v", tt.expectedPerimeter, ps.Perimeter())
+			}
+		})
+	}
+}
+
+func TestGreenTermPointerSegments(t *testing.T) {
+	arc := ArcSegment{Point{1, 2}, 3, 0.5, 2}
+	line := LineSegment{Point{1, 2}, Point{4, -1}}
+	if greenTerm(&arc) != greenTerm(arc) || greenTerm(&line) != greenTerm(line) {
+		t.Errorf("Expected pointer segments to use the exact terms, got %v and %v for the arc, %v and %v for the line",
+			greenTerm(&arc), greenTerm(arc), greenTerm(&line), greenTerm(line))
+	}
+}
+
+func TestPathShapePerimeterTolerance(t *testing.T) {
+	// An S-shaped cubic closed by lines around its lower half
+	segments := []PathSegment{
+		CubicBezier{Point{0, 0}, Point{1, 2}, Point{2, -2}, Point{3, 0}},
+		LineSegment{Point{3, 0}, Point{3, -2}},
+		LineSegment{Point{3, -2}, Point{0, -2}},
+		LineSegment{Point{0, -2}, Point{0, 0}},
+	}
+	exact, err := NewPathShape(1e-12, segments...)
+	if err != nil {
+		t.Fatalf("Failed to create path: %v", err)
+	}
+	for _, tol := range []float64{1e-2, 1e-4, 1e-6} {
+		ps, err := NewPathShape(tol, segments...)
+		if err != nil {
+			t.Fatalf("Failed to create path: %v", err)
+		}
+		if diff := math.Abs(ps.Perimeter() - exact.Perimeter()); diff > tol {
+			t.Errorf("Expected perimeter within %v of %v, got %v", tol, exact.Perimeter(), ps.Perimeter())
+		}
+	}
+}
+
+func TestPathShapeContains(t *testing.T) {
+	ps, err := NewPathShape(1e-6, bezierCircle(2)...)
+	if err != nil {
+		t.Fatalf("Failed to create path: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		point    Point
+		expected bool
+	}{
+		{"Center", Point{0, 0}, true},
+		{"Near curved edge", Point{1.4, 1.4}, true},
+		{"On vertex", Point{2, 0}, true},
+		{"Outside corner", Point{1.5, 1.5}, false},
+		{"Far outside", Point{3, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ps.Contains(tt.point); got != tt.expected {
+				t.Errorf("Expected Contains(%v) to be %v, got %v", tt.point, tt.expected, got)
+			}
+		})
+	}
+
+	// Paths can be combined with other shapes
+	ring, err := NewCompositeShape(Difference, Place(mustCircle(t, 3), 0, 0), Place(ps, 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to create composite: %v", err)
+	}
+	if !approxEquals(ring.Area(), 9*math.Pi-ps.Area(), 1e-4) {
+		t.Errorf("Expected area %v, got %v", 9*math.Pi-ps.Area(), ring.Area())
+	}
+}
+
+func TestNewPathShapeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance float64
+		segments  []PathSegment
+	}{
+		{"No segments", 1e-9, nil},
+		{"Zero tolerance", 0, []PathSegment{ArcSegment{Point{0, 0}, 1, 0, 2 * math.Pi}}},
+		{"Nil segment", 1e-9, []PathSegment{nil}},
+		{"Zero radius", 1e-9, []PathSegment{ArcSegment{Point{0, 0}, 0, 0, 2 * math.Pi}}},
+		{"Gap between segments", 1e-9, []PathSegment{
+			LineSegment{Point{0, 0}, Point{1, 0}},
+			LineSegment{Point{1, 0.5}, Point{0, 1}},
+			LineSegment{Point{0, 1}, Point{0, 0}},
+		}},
+		{"Not closed", 1e-9, []PathSegment{
+			LineSegment{Point{0, 0}, Point{1, 0}},
+			LineSegment{Point{1, 0}, Point{1, 1}},
+		}},
+		{"No area", 1e-9, []PathSegment{
+			LineSegment{Point{0, 0}, Point{1, 0}},
+			LineSegment{Point{1, 0}, Point{0, 0}},
+		}},
+		{"Crossing segments", 1e-9, []PathSegment{
+			LineSegment{Point{0, 0}, Point{2, 2}},
+			LineSegment{Point{2, 2}, Point{2, 0}},
+			LineSegment{Point{2, 0}, Point{0, 2}},
+			LineSegment{Point{0, 2}, Point{0, 0}},
+		}},
+		{"Curve with a loop", 1e-6, []PathSegment{
+			CubicBezier{Point{0, 0}, Point{4, 3}, Point{-1, 3}, Point{3, 0}},
+			LineSegment{Point{3, 0}, Point{0, 0}},
+		}},
+		{"Nil pointer segment", 1e-9, []PathSegment{(*ArcSegment)(nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ps, err := NewPathShape(tt.tolerance, tt.segments...); err == nil {
+				t.Errorf("Expected an error, but got %s", ps)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Polygon represents a simple polygon: a closed chain of straight edges that
+// does not cross itself. Its coordinates are its own coordinate system.
+type Polygon struct {
+	Vertices []Point // counter-clockwise, without repeating the first vertex
+}
+
+// NewPolygon creates a new Polygon with validation. A closing vertex equal to
+// the first one and repeated consecutive vertices are dropped, and the
+// vertices are reordered counter-clockwise if needed.
+func NewPolygon(vertices []Point) (*Polygon, error) {
+	var cleaned []Point
+	for _, v := range vertices {
+		if math.IsNaN(v.X) || math.IsNaN(v.Y) || math.IsInf(v.X, 0) || math.IsInf(v.Y, 0) {
+			return nil, errors.New("polygon vertices should be finite numbers")
+		}
+		if len(cleaned) == 0 || cleaned[len(cleaned)-1] != v {
+			cleaned = append(cleaned, v)
+		}
+	}
+	if len(cleaned) > 1 && cleaned[0] == cleaned[len(cleaned)-1] {
+		cleaned = cleaned[:len(cleaned)-1]
+	}
+	if len(cleaned) < 3 {
+		return nil, errors.New("a polygon should have at least three distinct vertices")
+	}
+
+	loop := polygonLoop(cleaned)
+	area, perimeter := boundaryArea(loop), boundaryLength(loop)
+	if math.Abs(area) <= 1e-12*perimeter*perimeter {
+		return nil, errors.New("the vertices of a polygon should not all lie on one line")
+	}
+	if area < 0 {
+		for i, j := 0, len(cleaned)-1; i < j; i, j = i+1, j-1 {
+			cleaned[i], cleaned[j] = cleaned[j], cleaned[i]
+		}
+	}
+	if i, j, ok := selfIntersection(cleaned); ok {
+		return nil, fmt.Errorf("the edges of a polygon should not cross, but edges %d and %d do", i, j)
+	}
+	return &Polygon{Vertices: cleaned}, nil
+}
+
+// selfIntersection finds two non-adjacent edges that touch or cross. It
+// sweeps the edges from left to right and only compares edges whose x ranges
+// overlap, so the flattened outlines of curves with many short edges are
+// checked quickly.
+func selfIntersection(vertices []Point) (int, int, bool) {
+	edges := polygonLoop(vertices)
+	n := len(edges)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	minX := func(i int) float64 { return math.Min(edges[i].A.X, edges[i].B.X) }
+	maxX := func(i int) float64 { return math.Max(edges[i].A.X, edges[i].B.X) }
+	sort.Slice(order, func(a, b int) bool { return minX(order[a]) < minX(order[b]) })
+
+	var active []int
+	for _, j := range order {
+		x := minX(j)
+		kept := active[:0]
+		for _, i := range active {
+			if maxX(i) >= x {
+				kept = append(kept, i)
+			}
+		}
+		active = kept
+
+		for _, i := range active {
+			lo, hi := min(i, j), max(i, j)
+			if hi == lo+1 || (lo == 0 && hi == n-1) {
+				continue
+			}
+			if segmentsIntersect(edges[lo], edges[hi]) {
+				return lo, hi, true
+			}
+		}
+		active = append(active, j)
+	}
+	return 0, 0, false
+}
+
+// segmentsIntersect reports whether two segments share at least one point
+func segmentsIntersect(s, o segment) bool {
+	d1, d2 := cross(o.A, o.B, s.A), cross(o.A, o.B, s.B)
+	d3, d4 := cross(s.A, s.B, o.A), cross(s.A, s.B, o.B)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(o, s.A)) || (d2 == 0 && onSegment(o, s.B)) ||
+		(d3 == 0 && onSegment(s, o.A)) || (d4 == 0 && onSegment(s, o.B))
+}
+
+// onSegment reports whether p, known to be collinear with s, lies between its ends
+func onSegment(s segment, p Point) bool {
+	return p.X >= math.Min(s.A.X, s.B.X) && p.X <= math.Max(s.A.X, s.B.X) &&
+		p.Y >= math.Min(s.A.Y, s.B.Y) && p.Y <= math.Max(s.A.Y, s.B.Y)
+}
+
+// Area calculates the area of the polygon using the shoelace formula
+func (p *Polygon) Area() float64 {
+	return boundaryArea(polygonLoop(p.Vertices))
+}
+
+// Perimeter calculates the perimeter of the polygon
+func (p *Polygon) Perimeter() float64 {
+	return boundaryLength(polygonLoop(p.Vertices))
+}
+
+// String returns a string representation of the polygon
+func (p *Polygon) String() string {
+	return fmt.Sprintf("Polygon(vertices=%d, area=%.2f)", len(p.Vertices), p.Area())
+}
+
+// Contains reports whether q lies inside or on the polygon
+func (p *Polygon) Contains(q Point) bool {
+	loop := polygonLoop(p.Vertices)
+	return winding(loop, q) != 0 || onBoundary(loop, q, tolerance(loop))
+}
+
+func (p *Polygon) outline() []segment {
+	return polygonLoop(p.Vertices)
+}
+
+// ToPolygon approximates the circle with a regular polygon of n vertices on
+// the circle, starting at (Radius, 0)
+func (c *Circle) ToPolygon(n int) (*Polygon, error) {
+	if n < 3 {
+		return nil, fmt.Errorf("a circle needs at least 3 vertices, got %d", n)
+	}
+	vertices := make([]Point, n)
+	for i := range vertices {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		vertices[i] = Point{c.Radius * math.Cos(angle), c.Radius * math.Sin(angle)}
+	}
+	return NewPolygon(vertices)
+}
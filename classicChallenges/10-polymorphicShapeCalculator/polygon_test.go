@@ -0,0 +1,116 @@
+package challenge10
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolygonConstructor(t *testing.T) {
+	tests := []struct {
+		name             string
+		vertices         []Point
+		shouldError      bool
+		expectedVertices int
+	}{
+		{"Square", []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}, false, 4},
+		{"Closed ring", []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}, false, 4},
+		{"Repeated vertex", []Point{{0, 0}, {2, 0}, {2, 0}, {1, 3}}, false, 3},
+		{"Concave", []Point{{0, 0}, {4, 0}, {4, 4}, {2, 1}, {0, 4}}, false, 5},
+		{"Too few vertices", []Point{{0, 0}, {1, 1}}, true, 0},
+		{"Collinear", []Point{{0, 0}, {1, 1}, {2, 2}}, true, 0},
+		{"Bow tie", []Point{{0, 0}, {2, 2}, {2, 0}, {0, 2}}, true, 0},
+		{"Touching edges", []Point{{0, 0}, {4, 0}, {4, 4}, {2, 0}, {0, 4}}, true, 0},
+		{"Infinite coordinate", []Point{{0, 0}, {math.Inf(1), 0}, {1, 1}}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolygon(tt.vertices)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected an error for %v, but got %s", tt.vertices, p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error for %v, but got: %v", tt.vertices, err)
+			}
+			if len(p.Vertices) != tt.expectedVertices {
+				t.Errorf("Expected %d vertices, got %d", tt.expectedVertices, len(p.Vertices))
+			}
+		})
+	}
+}
+
+func TestPolygonMeasures(t *testing.T) {
+	// Clockwise input is reordered so the area stays positive
+	p, err := NewPolygon([]Point{{0, 0}, {0, 4}, {2, 1}, {4, 4}, {4, 0}})
+	if err != nil {
+		t.Fatalf("Failed to create polygon: %v", err)
+	}
+	if !floatEquals(p.Area(), 10) {
+		t.Errorf("Expected area 10, got %.6f", p.Area())
+	}
+	expectedPerimeter := 4 + 4 + 4 + 2*math.Sqrt(4+9)
+	if !floatEquals(p.Perimeter(), expectedPerimeter) {
+		t.Errorf("Expected perimeter %.6f, got %.6f", expectedPerimeter, p.Perimeter())
+	}
+	if p.String() != "Polygon(vertices=5, area=10.00)" {
+		t.Errorf("Unexpected string %q", p.String())
+	}
+
+	points := []struct {
+		q        Point
+		expected bool
+	}{
+		{Point{1, 1}, true},
+		{Point{2, 2}, false}, // in the notch
+		{Point{2, 1}, true},  // on a vertex
+		{Point{4, 2}, true},  // on an edge
+		{Point{5, 2}, false},
+	}
+	for _, tp := range points {
+		if got := p.Contains(tp.q); got != tp.expected {
+			t.Errorf("Contains(%s): expected %v, got %v", tp.q, tp.expected, got)
+		}
+	}
+}
+
+func TestCircleToPolygon(t *testing.T) {
+	circle := mustCircle(t, 2)
+
+	square, err := circle.ToPolygon(4)
+	if err != nil {
+		t.Fatalf("Failed to convert circle: %v", err)
+	}
+	if !floatEquals(square.Area(), 8) {
+		t.Errorf("Expected inscribed square area 8, got %.6f", square.Area())
+	}
+
+	fine, err := circle.ToPolygon(360)
+	if err != nil {
+		t.Fatalf("Failed to convert circle: %v", err)
+	}
+	if math.Abs(fine.Area()-circle.Area())/circle.Area() > 1e-4 {
+		t.Errorf("Expected area close to %.6f, got %.6f", circle.Area(), fine.Area())
+	}
+
+	if _, err := circle.ToPolygon(2); err == nil {
+		t.Errorf("Expected an error for 2 vertices, but got none")
+	}
+}
+
+func TestPolygonInComposite(t *testing.T) {
+	notched, err := NewPolygon([]Point{{0, 0}, {4, 0}, {4, 4}, {2, 1}, {0, 4}})
+	if err != nil {
+		t.Fatalf("Failed to create polygon: %v", err)
+	}
+	cs, err := NewCompositeShape(Difference, Place(notched, 0, 0), Place(mustRectangle(t, 4, 1), 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to create composite shape: %v", err)
+	}
+	// Two triangles of base 2 and height 3 remain
+	if !floatEquals(cs.Area(), 6) {
+		t.Errorf("Expected area 6, got %.6f", cs.Area())
+	}
+}
@@ -0,0 +1,144 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ErrAreaMismatch is returned by VerifyArea when an estimate disagrees with Area
+var ErrAreaMismatch = errors.New("area does not match estimate")
+
+// confidenceZ is the number of standard errors in a Monte Carlo confidence
+// interval; four gives a false alarm roughly once in 16000 runs
+const confidenceZ = 4
+
+// AreaEstimate is a numeric estimate of the area of a shape
+type AreaEstimate struct {
+	Area    float64
+	Margin  float64 // the true area lies within Area ± Margin
+	Samples int     // number of points tested with Contains
+}
+
+// Covers reports whether area lies within the margin of the estimate
+func (e AreaEstimate) Covers(area float64) bool {
+	return math.Abs(area-e.Area) <= e.Margin
+}
+
+// String returns a string representation of the estimate
+func (e AreaEstimate) String() string {
+	return fmt.Sprintf("%.4f ± %.4f (%d samples)", e.Area, e.Margin, e.Samples)
+}
+
+// MonteCarloArea estimates the area of a shape from the share of random points
+// in its bounding box that it contains. The same seed gives the same estimate.
+// Margin is a confidence interval of confidenceZ standard errors.
+func MonteCarloArea(s Shape, samples int, seed int64) (AreaEstimate, error) {
+	if samples <= 0 {
+		return AreaEstimate{}, fmt.Errorf("samples should be positive, got %d", samples)
+	}
+	p, err := positioned(s)
+	if err != nil {
+		return AreaEstimate{}, err
+	}
+	lo, hi := bounds(p.boundary())
+	w, h := hi.X-lo.X, hi.Y-lo.Y
+
+	rng := rand.New(rand.NewSource(seed))
+	hits := 0
+	for i := 0; i < samples; i++ {
+		if p.Contains(Point{lo.X + rng.Float64()*w, lo.Y + rng.Float64()*h}) {
+			hits++
+		}
+	}
+	share := float64(hits) / float64(samples)
+	// Never claim certainty: with no hits or no misses the sample variance is zero
+	variance := math.Max(share*(1-share), 1/float64(samples))
+	return AreaEstimate{
+		Area:    w * h * share,
+		Margin:  confidenceZ * w * h * math.Sqrt(variance/float64(samples)),
+		Samples: samples,
+	}, nil
+}
+
+// GridArea estimates the area of a shape by testing the centers of an n by n
+// grid of cells over its bounding box. Only cells crossed by the boundary can
+// be miscounted, so Margin is a bound derived from the shape's perimeter.
+func GridArea(s Shape, n int) (AreaEstimate, error) {
+	if n <= 0 {
+		return AreaEstimate{}, fmt.Errorf("grid size should be positive, got %d", n)
+	}
+	p, err := positioned(s)
+	if err != nil {
+		return AreaEstimate{}, err
+	}
+	lo, hi := bounds(p.boundary())
+	cw, ch := (hi.X-lo.X)/float64(n), (hi.Y-lo.Y)/float64(n)
+
+	hits := 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if p.Contains(Point{lo.X + (float64(i)+0.5)*cw, lo.Y + (float64(j)+0.5)*ch}) {
+				hits++
+			}
+		}
+	}
+	// A curve of length L crosses at most 4 * (L / side + 1) cells
+	crossed := 4 * (s.Perimeter()/math.Min(cw, ch) + 1)
+	return AreaEstimate{
+		Area:    float64(hits) * cw * ch,
+		Margin:  math.Min(crossed, float64(n*n)) * cw * ch,
+		Samples: n * n,
+	}, nil
+}
+
+// VerifyArea checks Area of a shape against a Monte Carlo estimate and a grid
+// estimate using its Contains method. The returned error wraps ErrAreaMismatch
+// when either estimate disagrees.
+func VerifyArea(s Shape, samples int, seed int64) error {
+	mc, err := MonteCarloArea(s, samples, seed)
+	if err != nil {
+		return err
+	}
+	grid, err := GridArea(s, int(math.Sqrt(float64(samples))))
+	if err != nil {
+		return err
+	}
+	area := s.Area()
+	if !mc.Covers(area) {
+		return fmt.Errorf("%s: %w: Area() is %.4f, Monte Carlo estimate is %s", s, ErrAreaMismatch, area, mc)
+	}
+	if !grid.Covers(area) {
+		return fmt.Errorf("%s: %w: Area() is %.4f, grid estimate is %s", s, ErrAreaMismatch, area, grid)
+	}
+	return nil
+}
+
+// positioned returns the shape as a PositionedShape, so that positioned and
+// unpositioned shapes can be tested the same way
+func positioned(s Shape) (PositionedShape, error) {
+	ps, ok := s.(PositionedShape)
+	if !ok {
+		ps = Place(s, 0, 0)
+	}
+	if _, ok := ps.Shape.(placeable); !ok {
+		return PositionedShape{}, fmt.Errorf("%s does not support point tests", s)
+	}
+	return ps, nil
+}
+
+// bounds returns the corners of a box around the boundary. The box is padded
+// because outlines of curved shapes may lie slightly inside the curves.
+func bounds(boundary []segment) (Point, Point) {
+	lo := Point{math.Inf(1), math.Inf(1)}
+	hi := Point{math.Inf(-1), math.Inf(-1)}
+	for _, s := range boundary {
+		for _, q := range []Point{s.A, s.B} {
+			lo = Point{math.Min(lo.X, q.X), math.Min(lo.Y, q.Y)}
+			hi = Point{math.Max(hi.X, q.X), math.Max(hi.Y, q.Y)}
+		}
+	}
+	pad := 0.01 * math.Max(hi.X-lo.X, hi.Y-lo.Y)
+	return Point{lo.X - pad, lo.Y - pad}, Point{hi.X + pad, hi.Y + pad}
+}
@@ -0,0 +1,169 @@
+package challenge10
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// randomShapes returns n shapes built by gen from a seeded generator
+func randomShapes(t *testing.T, n int, gen func(rng *rand.Rand) (Shape, error)) []Shape {
+	t.Helper()
+	rng := rand.New(rand.NewSource(int64(n)))
+	var shapes []Shape
+	for len(shapes) < n {
+		s, err := gen(rng)
+		if err != nil {
+			// Some random inputs are invalid, e.g. an empty intersection
+			continue
+		}
+		shapes = append(shapes, s)
+	}
+	return shapes
+}
+
+func randomBasicShape(rng *rand.Rand) (Shape, error) {
+	switch rng.Intn(3) {
+	case 0:
+		return NewRectangle(0.5+rng.Float64()*5, 0.5+rng.Float64()*5)
+	case 1:
+		return NewCircle(0.5 + rng.Float64()*3)
+	default:
+		a, b := 1+rng.Float64()*4, 1+rng.Float64()*4
+		return NewTriangle(a, b, math.Abs(a-b)+0.1+rng.Float64()*(a+b-math.Abs(a-b)-0.2))
+	}
+}
+
+func TestVerifyAreaProperties(t *testing.T) {
+	generators := []struct {
+		name string
+		gen  func(rng *rand.Rand) (Shape, error)
+	}{
+		{"Rectangle", func(rng *rand.Rand) (Shape, error) {
+			return NewRectangle(0.01+rng.Float64()*10, 0.01+rng.Float64()*10)
+		}},
+		{"Circle", func(rng *rand.Rand) (Shape, error) {
+			return NewCircle(0.01 + rng.Float64()*10)
+		}},
+		{"Triangle", func(rng *rand.Rand) (Shape, error) {
+			return NewTriangle(rng.Float64()*10, rng.Float64()*10, rng.Float64()*10)
+		}},
+		{"Polygon", func(rng *rand.Rand) (Shape, error) {
+			// A star-shaped polygon: vertices sorted by angle around the origin
+			angles := make([]float64, 3+rng.Intn(20))
+			for i := range angles {
+				angles[i] = rng.Float64() * 2 * math.Pi
+			}
+			sort.Float64s(angles)
+			vertices := make([]Point, len(angles))
+			for i, a := range angles {
+				r := 0.5 + rng.Float64()*2
+				vertices[i] = Point{r * math.Cos(a), r * math.Sin(a)}
+			}
+			return NewPolygon(vertices)
+		}},
+		{"Path", func(rng *rand.Rand) (Shape, error) {
+			if rng.Intn(2) == 0 {
+				// A rounded rectangle
+				w, h := 1+rng.Float64()*4, 1+rng.Float64()*4
+				r := rng.Float64() * math.Min(w, h) / 2
+				return NewPathShape(1e-4,
+					LineSegment{Point{r, 0}, Point{w - r, 0}},
+					ArcSegment{Point{w - r, r}, r, -math.Pi / 2, 0},
+					LineSegment{Point{w, r}, Point{w, h - r}},
+					ArcSegment{Point{w - r, h - r}, r, 0, math.Pi / 2},
+					LineSegment{Point{w - r, h}, Point{r, h}},
+					ArcSegment{Point{r, h - r}, r, math.Pi / 2, math.Pi},
+					LineSegment{Point{0, h - r}, Point{0, r}},
+					ArcSegment{Point{r, r}, r, math.Pi, 3 * math.Pi / 2},
+				)
+			}
+			// A blob: a Bézier circle with jittered control points
+			segments := bezierCircle(1 + rng.Float64()*3)
+			for i, s := range segments {
+				c := s.(CubicBezier)
+				c.P1 = c.P1.Add(Point{rng.Float64() - 0.5, rng.Float64() - 0.5})
+				c.P2 = c.P2.Add(Point{rng.Float64() - 0.5, rng.Float64() - 0.5})
+				segments[i] = c
+			}
+			return NewPathShape(1e-4, segments...)
+		}},
+		{"Positioned", func(rng *rand.Rand) (Shape, error) {
+			s, err := randomBasicShape(rng)
+			return Place(s, rng.NormFloat64()*100, rng.NormFloat64()*100), err
+		}},
+		{"Composite", func(rng *rand.Rand) (Shape, error) {
+			a, err := randomBasicShape(rng)
+			if err != nil {
+				return nil, err
+			}
+			b, err := randomBasicShape(rng)
+			if err != nil {
+				return nil, err
+			}
+			op := SetOperation(rng.Intn(3))
+			return NewCompositeShape(op, Place(a, 0, 0), Place(b, rng.Float64()*4-2, rng.Float64()*4-2))
+		}},
+	}
+
+	for _, g := range generators {
+		t.Run(g.name, func(t *testing.T) {
+			for i, s := range randomShapes(t, 8, g.gen) {
+				if err := VerifyArea(s, 10000, int64(i)); err != nil {
+					t.Errorf("Expected %s to pass verification, got %v", s, err)
+				}
+			}
+		})
+	}
+}
+
+// wrongArea reports an area 5% too large
+type wrongArea struct {
+	*Rectangle
+}
+
+func (w wrongArea) Area() float64 {
+	return 1.05 * w.Rectangle.Area()
+}
+
+func TestVerifyAreaDetectsMismatch(t *testing.T) {
+	if err := VerifyArea(wrongArea{mustRectangle(t, 3, 2)}, 40000, 1); !errors.Is(err, ErrAreaMismatch) {
+		t.Errorf("Expected ErrAreaMismatch, got %v", err)
+	}
+	if err := VerifyArea(Place(Place(mustCircle(t, 1), 1, 1), 1, 1), 100, 1); err == nil || errors.Is(err, ErrAreaMismatch) {
+		t.Errorf("Expected an error for a shape without point tests, got %v", err)
+	}
+}
+
+func TestAreaEstimates(t *testing.T) {
+	circle := mustCircle(t, 2)
+
+	mc, err := MonteCarloArea(circle, 50000, 7)
+	if err != nil {
+		t.Fatalf("Failed to estimate area: %v", err)
+	}
+	again, _ := MonteCarloArea(circle, 50000, 7)
+	if mc != again {
+		t.Errorf("Expected the same estimate for the same seed, got %s and %s", mc, again)
+	}
+	if !mc.Covers(circle.Area()) || mc.Margin > 0.2 {
+		t.Errorf("Expected a tight estimate covering %.4f, got %s", circle.Area(), mc)
+	}
+
+	grid, err := GridArea(circle, 300)
+	if err != nil {
+		t.Fatalf("Failed to estimate area: %v", err)
+	}
+	if !grid.Covers(circle.Area()) || math.Abs(grid.Area-circle.Area()) > 0.01 {
+		t.Errorf("Expected an estimate close to %.4f, got %s", circle.Area(), grid)
+	}
+
+	if _, err := MonteCarloArea(circle, 0, 1); err == nil {
+		t.Errorf("Expected an error for zero samples, but got none")
+	}
+	if _, err := GridArea(circle, -1); err == nil {
+		t.Errorf("Expected an error for a negative grid size, but got none")
+	}
+}
@@ -0,0 +1,231 @@
+package challenge10
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrCircleNotSupported is returned when a circle is exported to a format
+// without circles. Convert it with Circle.ToPolygon first.
+var ErrCircleNotSupported = errors.New("circles cannot be exported, convert them with ToPolygon")
+
+// ring returns the vertices of a polygonal shape in the coordinates it is
+// exported with: its own, or the shared ones of a PositionedShape
+func ring(s Shape) ([]Point, error) {
+	var offset Point
+	if ps, ok := s.(PositionedShape); ok {
+		s, offset = ps.Shape, ps.Position
+	}
+
+	var vertices []Point
+	switch shape := s.(type) {
+	case *Rectangle:
+		vertices = shape.vertices()
+	case *Triangle:
+		vertices = shape.vertices()
+	case *Polygon:
+		vertices = append([]Point(nil), shape.Vertices...)
+	case *Circle:
+		return nil, ErrCircleNotSupported
+	default:
+		return nil, fmt.Errorf("%v cannot be exported", s)
+	}
+	for i := range vertices {
+		vertices[i] = vertices[i].Add(offset)
+	}
+	return vertices, nil
+}
+
+// shapeFromRing builds the shape described by a ring of vertices. Axis-aligned
+// rectangles become a Rectangle and triangles whose first edge runs along the
+// x axis become a Triangle, positioned at their origin. Anything else becomes
+// a Polygon at (0, 0). Invalid rings are reported by the shape constructors.
+func shapeFromRing(vertices []Point) (PositionedShape, error) {
+	if len(vertices) > 1 && vertices[0] == vertices[len(vertices)-1] {
+		vertices = vertices[:len(vertices)-1]
+	}
+
+	switch {
+	case len(vertices) == 4 && isAxisAligned(vertices):
+		minX, minY := math.Min(vertices[0].X, vertices[2].X), math.Min(vertices[0].Y, vertices[2].Y)
+		r, err := NewRectangle(math.Abs(vertices[2].X-vertices[0].X), math.Abs(vertices[2].Y-vertices[0].Y))
+		if err != nil {
+			return PositionedShape{}, err
+		}
+		return Place(r, minX, minY), nil
+	case len(vertices) == 3 && vertices[0].Y == vertices[1].Y && vertices[1].X > vertices[0].X && vertices[2].Y > vertices[0].Y:
+		a, b, c := vertices[0], vertices[1], vertices[2]
+		t, err := NewTriangle(distance(b, c), distance(a, c), distance(a, b))
+		if err != nil {
+			return PositionedShape{}, err
+		}
+		return Place(t, a.X, a.Y), nil
+	}
+
+	p, err := NewPolygon(vertices)
+	if err != nil {
+		return PositionedShape{}, err
+	}
+	return Place(p, 0, 0), nil
+}
+
+// isAxisAligned reports whether four vertices go around a rectangle with
+// edges parallel to the axes
+func isAxisAligned(v []Point) bool {
+	for i := range v {
+		next := v[(i+1)%4]
+		if (v[i].X == next.X) == (v[i].Y == next.Y) {
+			return false
+		}
+	}
+	// Edges must alternate between vertical and horizontal
+	return (v[0].X == v[1].X) == (v[2].X == v[3].X) && (v[0].X == v[1].X) != (v[1].X == v[2].X)
+}
+
+//
+// Well-Known Text
+//
+
+// MarshalWKT returns the Well-Known Text of a Rectangle, Triangle or Polygon,
+// or of one of them positioned, as a POLYGON
+func MarshalWKT(s Shape) (string, error) {
+	vertices, err := ring(s)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("POLYGON ((")
+	for i, v := range append(vertices, vertices[0]) {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(formatCoordinate(v.X) + " " + formatCoordinate(v.Y))
+	}
+	sb.WriteString("))")
+	return sb.String(), nil
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ParseWKT reads a POLYGON in Well-Known Text, see shapeFromRing for the shape
+// it is turned into. Polygons with holes are not supported.
+func ParseWKT(text string) (PositionedShape, error) {
+	p := &wktParser{src: text}
+	if !strings.EqualFold(p.word(), "POLYGON") {
+		return PositionedShape{}, p.errorf("expected POLYGON")
+	}
+	if strings.EqualFold(p.peekWord(), "EMPTY") {
+		return PositionedShape{}, p.errorf("empty polygons are not supported")
+	}
+	if err := p.expect('('); err != nil {
+		return PositionedShape{}, err
+	}
+	vertices, err := p.ring()
+	if err != nil {
+		return PositionedShape{}, err
+	}
+	if p.peek() == ',' {
+		return PositionedShape{}, p.errorf("polygons with holes are not supported")
+	}
+	if err := p.expect(')'); err != nil {
+		return PositionedShape{}, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return PositionedShape{}, p.errorf("unexpected text after the polygon")
+	}
+	return shapeFromRing(vertices)
+}
+
+type wktParser struct {
+	src string
+	pos int
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid WKT at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *wktParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// word reads a keyword or number
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && !unicode.IsSpace(rune(p.src[p.pos])) && !strings.ContainsRune("(),", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *wktParser) peekWord() string {
+	pos := p.pos
+	w := p.word()
+	p.pos = pos
+	return w
+}
+
+// ring reads "(x y, x y, ...)" and checks that it is closed
+func (p *wktParser) ring() ([]Point, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var vertices []Point
+	for {
+		x, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		y, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		vertices = append(vertices, Point{x, y})
+		if c := p.peek(); c != ',' && c != ')' {
+			return nil, p.errorf("expected ',' or ')' after a coordinate, only 2D coordinates are supported")
+		}
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+		p.pos++
+	}
+	if len(vertices) < 4 || vertices[0] != vertices[len(vertices)-1] {
+		return nil, p.errorf("a polygon ring should have at least 4 coordinates and end where it starts")
+	}
+	return vertices, nil
+}
+
+func (p *wktParser) number() (float64, error) {
+	w := p.word()
+	v, err := strconv.ParseFloat(w, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, p.errorf("expected a number, got %q", w)
+	}
+	return v, nil
+}
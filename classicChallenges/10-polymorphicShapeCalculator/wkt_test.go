@@ -0,0 +1,139 @@
+package challenge10
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMarshalWKT(t *testing.T) {
+	square, err := NewPolygon([]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}})
+	if err != nil {
+		t.Fatalf("Failed to create polygon: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		shape    Shape
+		expected string
+	}{
+		{"Rectangle", mustRectangle(t, 4, 2.5), "POLYGON ((0 0, 4 0, 4 2.5, 0 2.5, 0 0))"},
+		{"Positioned rectangle", Place(mustRectangle(t, 4, 2), -1, 3), "POLYGON ((-1 3, 3 3, 3 5, -1 5, -1 3))"},
+		{"Triangle", mustTriangle(t, 5, 5, 6), "POLYGON ((0 0, 6 0, 3 4, 0 0))"},
+		{"Polygon", square, "POLYGON ((0 0, 1 0, 1 1, 0 1, 0 0))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wkt, err := MarshalWKT(tt.shape)
+			if err != nil {
+				t.Fatalf("Failed to marshal %s: %v", tt.shape, err)
+			}
+			if wkt != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, wkt)
+			}
+		})
+	}
+
+	if _, err := MarshalWKT(mustCircle(t, 1)); !errors.Is(err, ErrCircleNotSupported) {
+		t.Errorf("Expected ErrCircleNotSupported, got %v", err)
+	}
+}
+
+func TestParseWKT(t *testing.T) {
+	tests := []struct {
+		name             string
+		wkt              string
+		expectedShape    string
+		expectedPosition Point
+	}{
+		{"Rectangle", "POLYGON ((0 0, 4 0, 4 2.5, 0 2.5, 0 0))", "Rectangle(width=4.00, height=2.50)", Point{0, 0}},
+		{"Clockwise rectangle", "polygon((1 1,1 3,5 3,5 1,1 1))", "Rectangle(width=4.00, height=2.00)", Point{1, 1}},
+		{"Triangle", "POLYGON ((2 2, 8 2, 5 6, 2 2))", "Triangle(sides=5.00, 5.00, 6.00)", Point{2, 2}},
+		{"Rotated triangle", "POLYGON ((0 0, 3 4, 0 6, 0 0))", "Polygon(vertices=3, area=9.00)", Point{0, 0}},
+		{"Scientific notation", "POLYGON ((0 0, 1e1 0, 1e1 1e1, 0 0))", "Triangle(sides=10.00, 14.14, 10.00)", Point{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := ParseWKT(tt.wkt)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.wkt, err)
+			}
+			if ps.Shape.String() != tt.expectedShape {
+				t.Errorf("Expected %s, got %s", tt.expectedShape, ps.Shape)
+			}
+			if ps.Position != tt.expectedPosition {
+				t.Errorf("Expected position %s, got %s", tt.expectedPosition, ps.Position)
+			}
+		})
+	}
+}
+
+func TestParseWKTErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		wkt  string
+	}{
+		{"Not a polygon", "POINT (1 2)"},
+		{"Empty polygon", "POLYGON EMPTY"},
+		{"Open ring", "POLYGON ((0 0, 1 0, 1 1, 0 1))"},
+		{"Hole", "POLYGON ((0 0, 4 0, 4 4, 0 0), (1 1, 2 1, 2 2, 1 1))"},
+		{"3D coordinates", "POLYGON ((0 0 1, 1 0 1, 1 1 1, 0 0 1))"},
+		{"Bad number", "POLYGON ((0 0, x 0, 1 1, 0 0))"},
+		{"Missing parenthesis", "POLYGON ((0 0, 1 0, 1 1, 0 0)"},
+		{"Trailing text", "POLYGON ((0 0, 1 0, 1 1, 0 0)) extra"},
+		{"Degenerate triangle", "POLYGON ((0 0, 2 0, 4 0.0000000001, 0 0))"},
+		{"Self-intersecting", "POLYGON ((0 0, 2 2, 2 0, 0 2, 0 0))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ps, err := ParseWKT(tt.wkt); err == nil {
+				t.Errorf("Expected an error for %q, but got %s", tt.wkt, ps)
+			}
+		})
+	}
+}
+
+func TestWKTRoundTrip(t *testing.T) {
+	circle, err := mustCircle(t, 3).ToPolygon(64)
+	if err != nil {
+		t.Fatalf("Failed to convert circle: %v", err)
+	}
+	shapes := []Shape{
+		mustRectangle(t, 0.1, 1e6),
+		Place(mustTriangle(t, 3, 4, 5), 10, -20),
+		mustTriangle(t, 7, 5, 9),
+		circle,
+	}
+
+	for _, s := range shapes {
+		t.Run(s.String(), func(t *testing.T) {
+			wkt, err := MarshalWKT(s)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			parsed, err := ParseWKT(wkt)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", wkt, err)
+			}
+			if !approxEquals(parsed.Area(), s.Area(), 1e-12) || !approxEquals(parsed.Perimeter(), s.Perimeter(), 1e-12) {
+				t.Errorf("Expected area %v and perimeter %v, got %v and %v", s.Area(), s.Perimeter(), parsed.Area(), parsed.Perimeter())
+			}
+			// Triangles are rebuilt from their sides, so vertices may move by rounding errors
+			expected, _ := ring(s)
+			got, err := ring(parsed)
+			if err != nil {
+				t.Fatalf("Failed to get vertices of parsed shape: %v", err)
+			}
+			if len(got) != len(expected) {
+				t.Fatalf("Expected %d vertices, got %d", len(expected), len(got))
+			}
+			for i := range expected {
+				if distance(got[i], expected[i]) > 1e-9 {
+					t.Errorf("Expected vertex %d at %v, got %v", i, expected[i], got[i])
+				}
+			}
+		})
+	}
+}
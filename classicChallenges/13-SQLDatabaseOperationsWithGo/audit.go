@@ -0,0 +1,305 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPermissionDenied is wrapped by the errors returned when an actor's role
+// does not allow an operation
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Role is the set of permissions an actor has
+type Role string
+
+// Roles
+const (
+	RoleViewer         Role = "viewer"
+	RoleEditor         Role = "editor"
+	RoleInventoryClerk Role = "inventory_clerk"
+	RoleAdmin          Role = "admin"
+)
+
+// Permission is a kind of operation on the store
+type Permission string
+
+// Permissions
+const (
+	PermViewProducts   Permission = "view products"
+	PermCreateProducts Permission = "create products"
+	PermEditProducts   Permission = "edit product details" // name, category, codes and translations
+	PermChangePrices   Permission = "change prices"
+	PermAdjustStock    Permission = "adjust stock"
+	PermDeleteProducts Permission = "delete products"
+	PermPurchasing     Permission = "manage purchasing" // suppliers and purchase orders
+	PermReceiveGoods   Permission = "receive goods"
+	PermViewAudit      Permission = "view audit log"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:         {PermViewProducts},
+	RoleEditor:         {PermViewProducts, PermCreateProducts, PermEditProducts, PermChangePrices},
+	RoleInventoryClerk: {PermViewProducts, PermAdjustStock, PermReceiveGoods},
+	RoleAdmin: {PermViewProducts, PermCreateProducts, PermEditProducts, PermChangePrices, PermAdjustStock,
+		PermDeleteProducts, PermPurchasing, PermReceiveGoods, PermViewAudit},
+}
+
+// Can reports whether the role has the permission
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor is the user on whose behalf an operation runs
+type Actor struct {
+	ID   string
+	Role Role
+}
+
+// authorize returns an error unless the actor has all the permissions
+func authorize(actor Actor, perms ...Permission) error {
+	if actor.ID == "" {
+		return fmt.Errorf("actor ID cannot be empty: %w", ErrPermissionDenied)
+	}
+	if _, ok := rolePermissions[actor.Role]; !ok {
+		return fmt.Errorf("actor %s has unknown role %q: %w", actor.ID, actor.Role, ErrPermissionDenied)
+	}
+	for _, perm := range perms {
+		if !actor.Role.Can(perm) {
+			return fmt.Errorf("%s %s may not %s: %w", actor.Role, actor.ID, perm, ErrPermissionDenied)
+		}
+	}
+	return nil
+}
+
+// Audited actions
+const (
+	ActionCreateProduct       = "create_product"
+	ActionUpdateProduct       = "update_product"
+	ActionUpsertProduct       = "upsert_product"
+	ActionDeleteProduct       = "delete_product"
+	ActionAdjustStock         = "adjust_stock"
+	ActionReceiveGoods        = "receive_goods"
+	ActionSetTranslation      = "set_translation"
+	ActionDeleteTranslation   = "delete_translation"
+	ActionCreateSupplier      = "create_supplier"
+	ActionCreatePurchaseOrder = "create_purchase_order"
+)
+
+// AuditEntry records one change to the store.
+// Before and After hold the JSON of the changed record; Before is nil for
+// creations and After is nil for deletions.
+type AuditEntry struct {
+	ID        int64
+	Actor     string
+	Role      Role
+	Action    string
+	ProductID int64 // 0 for changes not about a single product
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// auditTx is a transaction whose changes are recorded as made by actor
+type auditTx struct {
+	*sql.Tx
+	actor Actor
+	now   time.Time
+}
+
+// writeAudited runs fn in a transaction. A change and its audit entry are
+// committed together, so a change is never stored without its entry.
+func (ps *ProductStore) writeAudited(actor Actor, fn func(tx *auditTx) error) (err error) {
+	ps.writeMu.Lock()
+	defer ps.writeMu.Unlock()
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(&auditTx{Tx: tx, actor: actor, now: ps.now().UTC()}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// record writes an audit entry. A nil before or after, including a nil pointer, is stored as NULL.
+func (tx *auditTx) record(action string, productID int64, before, after interface{}) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO audit_log (actor, role, action, product_id, before, after, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		tx.actor.ID, string(tx.actor.Role), action, sql.NullInt64{Int64: productID, Valid: productID != 0},
+		beforeJSON, afterJSON, tx.now,
+	)
+	return err
+}
+
+func auditJSON(v interface{}) (sql.NullString, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: string(data) != "null"}, nil
+}
+
+// AuditedProductStore wraps a ProductStore so reads carry an actor and are
+// checked against the actor's role like writes, and adds the audit log queries
+type AuditedProductStore struct {
+	store *ProductStore
+}
+
+// NewAuditedProductStore wraps store with access control
+func NewAuditedProductStore(store *ProductStore) (*AuditedProductStore, error) {
+	if store == nil {
+		return nil, errors.New("store cannot be nil")
+	}
+	return &AuditedProductStore{store: store}, nil
+}
+
+// GetProduct retrieves a product by ID
+func (s *AuditedProductStore) GetProduct(actor Actor, id int64) (*Product, error) {
+	if err := authorize(actor, PermViewProducts); err != nil {
+		return nil, err
+	}
+	return s.store.GetProduct(id)
+}
+
+// ListProducts returns all products with optional filtering by category
+func (s *AuditedProductStore) ListProducts(actor Actor, category string) ([]*Product, error) {
+	if err := authorize(actor, PermViewProducts); err != nil {
+		return nil, err
+	}
+	return s.store.ListProducts(category)
+}
+
+// CreateProduct adds a new product
+func (s *AuditedProductStore) CreateProduct(actor Actor, product *Product) error {
+	return s.store.CreateProduct(actor, product)
+}
+
+// UpdateProduct updates a product. The actor needs a permission for each kind
+// of field changed: details, price or quantity.
+func (s *AuditedProductStore) UpdateProduct(actor Actor, product *Product) error {
+	return s.store.UpdateProduct(actor, product)
+}
+
+// UpsertProductBySKU creates or updates the product with the same SKU, checking
+// the permissions of a create or an update respectively
+func (s *AuditedProductStore) UpsertProductBySKU(actor Actor, product *Product) error {
+	return s.store.UpsertProductBySKU(actor, product)
+}
+
+// DeleteProduct removes a product
+func (s *AuditedProductStore) DeleteProduct(actor Actor, id int64) error {
+	return s.store.DeleteProduct(actor, id)
+}
+
+// BatchUpdateInventory sets the quantity of several products, recording one entry per product
+func (s *AuditedProductStore) BatchUpdateInventory(actor Actor, updates map[int64]int) error {
+	return s.store.BatchUpdateInventory(actor, updates)
+}
+
+// ReceivePurchaseOrder books received goods, recording the stock change of every product received
+func (s *AuditedProductStore) ReceivePurchaseOrder(actor Actor, orderID int64, received map[int64]int) error {
+	return s.store.ReceivePurchaseOrder(actor, orderID, received)
+}
+
+// SetTranslation stores a translation of a product
+func (s *AuditedProductStore) SetTranslation(actor Actor, tr *Translation) error {
+	return s.store.SetTranslation(actor, tr)
+}
+
+// DeleteTranslation removes a translation of a product
+func (s *AuditedProductStore) DeleteTranslation(actor Actor, productID int64, locale string) error {
+	return s.store.DeleteTranslation(actor, productID, locale)
+}
+
+// CreateSupplier adds a new supplier
+func (s *AuditedProductStore) CreateSupplier(actor Actor, supplier *Supplier) error {
+	return s.store.CreateSupplier(actor, supplier)
+}
+
+// CreatePurchaseOrder creates a purchase order
+func (s *AuditedProductStore) CreatePurchaseOrder(actor Actor, order *PurchaseOrder) error {
+	return s.store.CreatePurchaseOrder(actor, order)
+}
+
+// AuditByProduct returns the audit entries of a product, oldest first
+func (s *AuditedProductStore) AuditByProduct(actor Actor, productID int64) ([]*AuditEntry, error) {
+	if err := authorize(actor, PermViewAudit); err != nil {
+		return nil, err
+	}
+	return s.queryAudit("WHERE product_id = ?", productID)
+}
+
+// AuditByActor returns the audit entries of the changes made by an actor, oldest first
+func (s *AuditedProductStore) AuditByActor(actor Actor, actorID string) ([]*AuditEntry, error) {
+	if err := authorize(actor, PermViewAudit); err != nil {
+		return nil, err
+	}
+	return s.queryAudit("WHERE actor = ?", actorID)
+}
+
+// changePermissions returns the permissions needed to turn before into after
+func changePermissions(before, after *Product) []Permission {
+	var perms []Permission
+	if before.Name != after.Name || before.Category != after.Category || before.SKU != after.SKU || before.GTIN != after.GTIN {
+		perms = append(perms, PermEditProducts)
+	}
+	if before.Price != after.Price {
+		perms = append(perms, PermChangePrices)
+	}
+	if before.Quantity != after.Quantity {
+		perms = append(perms, PermAdjustStock)
+	}
+	return perms
+}
+
+func (s *AuditedProductStore) queryAudit(where string, args ...interface{}) ([]*AuditEntry, error) {
+	rows, err := s.store.db.Query(
+		"SELECT id, actor, role, action, product_id, before, after, created_at FROM audit_log "+where+" ORDER BY id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []*AuditEntry{}
+	for rows.Next() {
+		entry := &AuditEntry{}
+		var productID sql.NullInt64
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Role, &entry.Action, &productID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.ProductID = productID.Int64
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		res = append(res, entry)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testAdmin  = Actor{ID: "alice", Role: RoleAdmin}
+	testEditor = Actor{ID: "bob", Role: RoleEditor}
+	testClerk  = Actor{ID: "carol", Role: RoleInventoryClerk}
+	testViewer = Actor{ID: "dave", Role: RoleViewer}
+)
+
+func setupAuditedStore(t *testing.T) (*AuditedProductStore, *Product) {
+	t.Helper()
+	db := setupTestDB(t)
+	t.Cleanup(func() {
+		db.Close()
+		cleanupTestDB()
+	})
+
+	audited, err := NewAuditedProductStore(NewProductStore(db))
+	if err != nil {
+		t.Fatalf("Failed to create audited store: %v", err)
+	}
+	audited.store.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	product := &Product{Name: "Lamp", Price: 20, Quantity: 5, Category: "Home", SKU: "LMP-1"}
+	if err := audited.CreateProduct(testEditor, product); err != nil {
+		t.Fatalf("Failed to create test product: %v", err)
+	}
+	return audited, product
+}
+
+func TestRolePermissions(t *testing.T) {
+	audited, product := setupAuditedStore(t)
+
+	// change applies f to the current state of the product
+	change := func(f func(p *Product)) *Product {
+		p, err := audited.store.GetProduct(product.ID)
+		if err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		f(p)
+		return p
+	}
+
+	testCases := []struct {
+		name    string
+		actor   Actor
+		op      func(actor Actor) error
+		allowed bool
+	}{
+		{"Viewer can read", testViewer, func(a Actor) error { _, err := audited.GetProduct(a, product.ID); return err }, true},
+		{"Viewer cannot create", testViewer, func(a Actor) error { return audited.CreateProduct(a, &Product{Name: "X"}) }, false},
+		{"Viewer cannot change price", testViewer, func(a Actor) error {
+			return audited.UpdateProduct(a, change(func(p *Product) { p.Price = 1 }))
+		}, false},
+		{"Editor can change price", testEditor, func(a Actor) error {
+			return audited.UpdateProduct(a, change(func(p *Product) { p.Price = 25 }))
+		}, true},
+		{"Editor cannot change quantity", testEditor, func(a Actor) error {
+			return audited.UpdateProduct(a, change(func(p *Product) { p.Quantity = 50 }))
+		}, false},
+		{"Clerk can change quantity", testClerk, func(a Actor) error {
+			return audited.UpdateProduct(a, change(func(p *Product) { p.Quantity = 6 }))
+		}, true},
+		{"Clerk cannot change price and quantity together", testClerk, func(a Actor) error {
+			return audited.UpdateProduct(a, change(func(p *Product) { p.Quantity = 7; p.Price = 1 }))
+		}, false},
+		{"Clerk can batch update", testClerk, func(a Actor) error {
+			return audited.BatchUpdateInventory(a, map[int64]int{product.ID: 8})
+		}, true},
+		{"Editor cannot batch update", testEditor, func(a Actor) error {
+			return audited.BatchUpdateInventory(a, map[int64]int{product.ID: 9})
+		}, false},
+		{"Clerk cannot create suppliers", testClerk, func(a Actor) error {
+			return audited.CreateSupplier(a, &Supplier{Name: "Acme"})
+		}, false},
+		{"Editor cannot delete", testEditor, func(a Actor) error { return audited.DeleteProduct(a, product.ID) }, false},
+		{"Editor cannot read the audit log", testEditor, func(a Actor) error { _, err := audited.AuditByActor(a, "bob"); return err }, false},
+		{"Unknown role", Actor{ID: "eve", Role: "owner"}, func(a Actor) error { _, err := audited.ListProducts(a, ""); return err }, false},
+		{"Missing actor ID", Actor{Role: RoleAdmin}, func(a Actor) error { _, err := audited.ListProducts(a, ""); return err }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.op(tc.actor)
+			if tc.allowed && err != nil {
+				t.Errorf("Expected operation to be allowed, got %v", err)
+			}
+			if !tc.allowed && !errors.Is(err, ErrPermissionDenied) {
+				t.Errorf("Expected ErrPermissionDenied, got %v", err)
+			}
+		})
+	}
+
+	// Denied changes leave the product alone
+	p, err := audited.GetProduct(testViewer, product.ID)
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if p.Price != 25 || p.Quantity != 8 {
+		t.Errorf("Expected price 25 and quantity 8, got %v and %d", p.Price, p.Quantity)
+	}
+}
+
+func TestAuditTrail(t *testing.T) {
+	audited, product := setupAuditedStore(t)
+
+	updated := *product
+	updated.Price = 22.5
+	if err := audited.UpdateProduct(testEditor, &updated); err != nil {
+		t.Fatalf("Failed to update product: %v", err)
+	}
+	// An update that changes nothing is not recorded
+	if err := audited.UpdateProduct(testViewer, &updated); err != nil {
+		t.Fatalf("Expected no-op update to succeed, got %v", err)
+	}
+	if err := audited.BatchUpdateInventory(testClerk, map[int64]int{product.ID: 2}); err != nil {
+		t.Fatalf("Failed to update inventory: %v", err)
+	}
+	if err := audited.SetTranslation(testEditor, &Translation{ProductID: product.ID, Locale: "de", Name: "Lampe"}); err != nil {
+		t.Fatalf("Failed to set translation: %v", err)
+	}
+	if err := audited.DeleteProduct(testAdmin, product.ID); err != nil {
+		t.Fatalf("Failed to delete product: %v", err)
+	}
+
+	entries, err := audited.AuditByProduct(testAdmin, product.ID)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	expectedActions := []struct {
+		action string
+		actor  string
+	}{
+		{ActionCreateProduct, "bob"},
+		{ActionUpdateProduct, "bob"},
+		{ActionAdjustStock, "carol"},
+		{ActionSetTranslation, "bob"},
+		{ActionDeleteProduct, "alice"},
+	}
+	if len(entries) != len(expectedActions) {
+		t.Fatalf("Expected %d audit entries, got %d", len(expectedActions), len(entries))
+	}
+	for i, expected := range expectedActions {
+		if entries[i].Action != expected.action || entries[i].Actor != expected.actor {
+			t.Errorf("Entry %d: expected %s by %s, got %s by %s", i, expected.action, expected.actor, entries[i].Action, entries[i].Actor)
+		}
+	}
+
+	decode := func(raw json.RawMessage) *Product {
+		if raw == nil {
+			return nil
+		}
+		p := &Product{}
+		if err := json.Unmarshal(raw, p); err != nil {
+			t.Fatalf("Failed to decode audit value %s: %v", raw, err)
+		}
+		return p
+	}
+
+	if entries[0].Before != nil || decode(entries[0].After).Name != "Lamp" {
+		t.Errorf("Expected creation to have only an after value, got %s / %s", entries[0].Before, entries[0].After)
+	}
+	if before, after := decode(entries[1].Before), decode(entries[1].After); before.Price != 20 || after.Price != 22.5 {
+		t.Errorf("Expected price change 20 -> 22.5, got %v -> %v", before.Price, after.Price)
+	}
+	if before, after := decode(entries[2].Before), decode(entries[2].After); before.Quantity != 5 || after.Quantity != 2 {
+		t.Errorf("Expected quantity change 5 -> 2, got %d -> %d", before.Quantity, after.Quantity)
+	}
+	if entries[4].After != nil || decode(entries[4].Before).Price != 22.5 {
+		t.Errorf("Expected deletion to have only a before value, got %s / %s", entries[4].Before, entries[4].After)
+	}
+	if entries[0].Role != RoleEditor || !entries[0].CreatedAt.Equal(audited.store.now()) {
+		t.Errorf("Unexpected role or time: %s at %v", entries[0].Role, entries[0].CreatedAt)
+	}
+
+	byActor, err := audited.AuditByActor(testAdmin, "bob")
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(byActor) != 3 {
+		t.Errorf("Expected 3 entries by bob, got %d", len(byActor))
+	}
+}
+
+func TestAuditReceivingAndUpsert(t *testing.T) {
+	audited, product := setupAuditedStore(t)
+
+	supplier := &Supplier{Name: "Acme"}
+	if err := audited.CreateSupplier(testAdmin, supplier); err != nil {
+		t.Fatalf("Failed to create supplier: %v", err)
+	}
+	order := &PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{{ProductID: product.ID, QuantityOrdered: 10, UnitCost: 12}}}
+	if err := audited.CreatePurchaseOrder(testAdmin, order); err != nil {
+		t.Fatalf("Failed to create purchase order: %v", err)
+	}
+	if err := audited.ReceivePurchaseOrder(testClerk, order.ID, map[int64]int{order.Lines[0].ID: 4}); err != nil {
+		t.Fatalf("Failed to receive purchase order: %v", err)
+	}
+
+	entries, err := audited.AuditByActor(testAdmin, "carol")
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != ActionReceiveGoods || entries[0].ProductID != product.ID {
+		t.Fatalf("Expected one receive entry for the product, got %+v", entries)
+	}
+	var after Product
+	if err := json.Unmarshal(entries[0].After, &after); err != nil {
+		t.Fatalf("Failed to decode audit value: %v", err)
+	}
+	if after.Quantity != 9 {
+		t.Errorf("Expected quantity 9 after receiving, got %d", after.Quantity)
+	}
+
+	// Receipts and orders are stamped by the store's clock, like their audit entries
+	receipts, err := audited.store.ListReceipts(product.ID)
+	if err != nil {
+		t.Fatalf("Failed to list receipts: %v", err)
+	}
+	if len(receipts) != 1 || !receipts[0].ReceivedAt.Equal(entries[0].CreatedAt) {
+		t.Errorf("Expected one receipt at %v, got %+v", entries[0].CreatedAt, receipts)
+	}
+	if !order.CreatedAt.Equal(entries[0].CreatedAt) {
+		t.Errorf("Expected the order to be created at %v, got %v", entries[0].CreatedAt, order.CreatedAt)
+	}
+
+	// Upserting an existing SKU needs the permissions of the fields it changes
+	if err := audited.UpsertProductBySKU(testClerk, &Product{Name: "Lamp", Price: 1, Quantity: 9, Category: "Home", SKU: "LMP-1"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected clerk price change by upsert to be denied, got %v", err)
+	}
+	if err := audited.UpsertProductBySKU(testEditor, &Product{Name: "Desk lamp", Price: 20, Quantity: 9, Category: "Home", SKU: "LMP-1"}); err != nil {
+		t.Errorf("Expected editor rename by upsert to be allowed, got %v", err)
+	}
+	if err := audited.UpsertProductBySKU(testClerk, &Product{Name: "Chair", SKU: "CHR-1"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected clerk create by upsert to be denied, got %v", err)
+	}
+
+	entries, err = audited.AuditByProduct(testAdmin, product.ID)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	last := entries[len(entries)-1]
+	if last.Action != ActionUpsertProduct || last.Before == nil || last.After == nil {
+		t.Errorf("Expected an upsert entry with before and after values, got %+v", last)
+	}
+}
+
+func TestDirectWritesAreChecked(t *testing.T) {
+	audited, product := setupAuditedStore(t)
+	cached, err := NewCachedProductStore(audited.store, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// Writes on the store and on its decorators are checked like audited ones
+	updated := *product
+	updated.Price = 1
+	if err := audited.store.UpdateProduct(testViewer, &updated); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected a viewer's direct price change to be denied, got %v", err)
+	}
+	if err := cached.UpdateProduct(testClerk, &updated); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected a clerk's price change through the cache to be denied, got %v", err)
+	}
+	if err := audited.store.DeleteProduct(testEditor, product.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected an editor's direct delete to be denied, got %v", err)
+	}
+
+	updated.Price = 18
+	if err := audited.store.UpdateProduct(testEditor, &updated); err != nil {
+		t.Fatalf("Failed to update product: %v", err)
+	}
+	updated.Price = 15
+	if err := cached.UpdateProduct(testEditor, &updated); err != nil {
+		t.Fatalf("Failed to update product through the cache: %v", err)
+	}
+
+	entries, err := audited.AuditByProduct(testAdmin, product.ID)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected the creation and 2 price changes, got %d entries", len(entries))
+	}
+	for i, expected := range []float64{18, 15} {
+		entry := entries[i+1]
+		var after Product
+		if err := json.Unmarshal(entry.After, &after); err != nil {
+			t.Fatalf("Failed to decode audit value: %v", err)
+		}
+		if entry.Action != ActionUpdateProduct || entry.Actor != testEditor.ID || after.Price != expected {
+			t.Errorf("Entry %d: expected a price change to %v by %s, got %s by %s to %v",
+				i+1, expected, testEditor.ID, entry.Action, entry.Actor, after.Price)
+		}
+	}
+}
+
+func TestChangesRollBackWhenAuditFails(t *testing.T) {
+	audited, product := setupAuditedStore(t)
+	if _, err := audited.store.db.Exec(`CREATE TRIGGER audit_log_fail BEFORE INSERT ON audit_log BEGIN
+		SELECT RAISE(ABORT, 'audit log unavailable');
+	END`); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+
+	updated := *product
+	updated.Price = 99
+	if err := audited.UpdateProduct(testEditor, &updated); err == nil {
+		t.Errorf("Expected the audited update to fail")
+	}
+	if err := audited.store.UpdateProduct(testAdmin, &updated); err == nil {
+		t.Errorf("Expected the direct update to fail")
+	}
+	if err := audited.store.BatchUpdateInventory(testAdmin, map[int64]int{product.ID: 0}); err == nil {
+		t.Errorf("Expected the inventory update to fail")
+	}
+	if err := audited.store.CreateProduct(testAdmin, &Product{Name: "Rug", SKU: "RUG-1"}); err == nil {
+		t.Errorf("Expected the creation to fail")
+	}
+
+	p, err := audited.store.GetProduct(product.ID)
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if p.Price != 20 || p.Quantity != 5 {
+		t.Errorf("Expected the product to be unchanged, got price %v and quantity %d", p.Price, p.Quantity)
+	}
+	if _, err := audited.store.GetProductBySKU("RUG-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the product not to be created, got %v", err)
+	}
+}
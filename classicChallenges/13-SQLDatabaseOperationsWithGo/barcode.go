@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// ErrDuplicateProduct is returned when a SKU or GTIN is already used by another product
+var ErrDuplicateProduct = errors.New("duplicate product")
+
+// NormalizeGTIN validates an EAN-8, EAN-13 or UPC-A barcode and returns it in
+// canonical form. Spaces and hyphens are ignored, and UPC-A codes are turned
+// into the equivalent EAN-13 by adding a leading zero, so the same item can't
+// be stored twice under both forms.
+func NormalizeGTIN(code string) (string, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(code)
+	switch len(digits) {
+	case 8, 12, 13:
+	default:
+		return "", fmt.Errorf("GTIN %q must have 8, 12 or 13 digits, got %d", code, len(digits))
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("GTIN %q must contain only digits", code)
+		}
+	}
+
+	last := len(digits) - 1
+	if want := gtinCheckDigit(digits[:last]); digits[last] != want {
+		return "", fmt.Errorf("GTIN %q has an invalid check digit, expected %c", code, want)
+	}
+
+	if len(digits) == 12 {
+		digits = "0" + digits
+	}
+	return digits, nil
+}
+
+// gtinCheckDigit computes the GS1 check digit of the data digits.
+// Weights alternate 3 and 1, starting with 3 at the rightmost data digit.
+func gtinCheckDigit(data string) byte {
+	sum := 0
+	weight := 3
+	for i := len(data) - 1; i >= 0; i-- {
+		sum += int(data[i]-'0') * weight
+		weight = 4 - weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// normalizeCodes trims the SKU and validates and normalizes the GTIN in place
+func (p *Product) normalizeCodes() error {
+	p.SKU = strings.TrimSpace(p.SKU)
+	if p.GTIN == "" {
+		return nil
+	}
+	gtin, err := NormalizeGTIN(p.GTIN)
+	if err != nil {
+		return err
+	}
+	p.GTIN = gtin
+	return nil
+}
+
+// duplicateError turns a unique constraint violation into ErrDuplicateProduct
+func duplicateError(p *Product, err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%w: SKU %q or GTIN %q is already in use", ErrDuplicateProduct, p.SKU, p.GTIN)
+	}
+	return err
+}
+
+// GetProductBySKU retrieves a product by its SKU
+func (ps *ProductStore) GetProductBySKU(sku string) (*Product, error) {
+	return findProductBySKU(ps.db, sku)
+}
+
+func findProductBySKU(db queryRower, sku string) (*Product, error) {
+	row := db.QueryRow("SELECT "+productColumns+" FROM products WHERE sku = ?", strings.TrimSpace(sku))
+
+	p, err := scanProduct(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product with SKU %q %w", sku, ErrNotFound)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetProductByGTIN retrieves a product by its barcode.
+// The code is normalized first, so a UPC-A finds the matching EAN-13.
+func (ps *ProductStore) GetProductByGTIN(code string) (*Product, error) {
+	gtin, err := NormalizeGTIN(code)
+	if err != nil {
+		return nil, err
+	}
+	row := ps.db.QueryRow("SELECT "+productColumns+" FROM products WHERE gtin = ?", gtin)
+
+	p, err := scanProduct(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product with GTIN %q %w", code, ErrNotFound)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpsertProductBySKU creates the product, or updates the existing product with
+// the same SKU. The product.ID is set to the ID of the stored row either way.
+// The actor needs the permissions of a create or an update respectively.
+// Updates that change nothing are neither made nor recorded.
+func (ps *ProductStore) UpsertProductBySKU(actor Actor, product *Product) error {
+	if err := authorize(actor, PermViewProducts); err != nil {
+		return err
+	}
+	if err := product.normalizeCodes(); err != nil {
+		return err
+	}
+	if product.SKU == "" {
+		return errors.New("SKU is required for an upsert")
+	}
+
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		before, err := findProductBySKU(tx, product.SKU)
+		perms := []Permission{PermCreateProducts}
+		if err == nil {
+			product.ID = before.ID
+			perms = changePermissions(before, product)
+			if len(perms) == 0 {
+				return nil
+			}
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := authorize(actor, perms...); err != nil {
+			return err
+		}
+
+		var id int64
+		err = tx.QueryRow(
+			`INSERT INTO products (name, price, quantity, category, sku, gtin) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(sku) DO UPDATE SET
+				name = excluded.name,
+				price = excluded.price,
+				quantity = excluded.quantity,
+				category = excluded.category,
+				gtin = excluded.gtin
+			RETURNING id`,
+			product.Name, product.Price, product.Quantity, product.Category, nullString(product.SKU), nullString(product.GTIN),
+		).Scan(&id)
+		if err != nil {
+			return duplicateError(product, err)
+		}
+		product.ID = id
+		return tx.record(ActionUpsertProduct, id, before, product)
+	})
+}
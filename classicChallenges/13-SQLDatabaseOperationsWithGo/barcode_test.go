@@ -0,0 +1,231 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestNormalizeGTIN(t *testing.T) {
+	testCases := []struct {
+		name        string
+		code        string
+		expected    string
+		expectError bool
+	}{
+		{name: "Valid EAN-13", code: "4006381333931", expected: "4006381333931"},
+		{name: "Valid EAN-8", code: "96385074", expected: "96385074"},
+		{name: "UPC-A becomes EAN-13", code: "036000291452", expected: "0036000291452"},
+		{name: "Separators are ignored", code: "400-6381 333931", expected: "4006381333931"},
+		{name: "Invalid EAN-13 check digit", code: "4006381333932", expectError: true},
+		{name: "Invalid EAN-8 check digit", code: "96385075", expectError: true},
+		{name: "Invalid UPC-A check digit", code: "036000291453", expectError: true},
+		{name: "Wrong length", code: "12345", expectError: true},
+		{name: "Letters", code: "40063813339AB", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gtin, err := NormalizeGTIN(tc.code)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error for GTIN %q, got %q", tc.code, gtin)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Did not expect error for GTIN %q, got %v", tc.code, err)
+			}
+			if gtin != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, gtin)
+			}
+		})
+	}
+}
+
+func TestProductCodesUniqueness(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+
+	product := &Product{Name: "Scanner", Price: 99, Quantity: 1, Category: "Tools", SKU: "SCN-1", GTIN: "4006381333931"}
+	if err := store.CreateProduct(testAdmin, product); err != nil {
+		t.Fatalf("Failed to create test product: %v", err)
+	}
+
+	// Products without codes don't collide with each other
+	for i := 0; i < 2; i++ {
+		if err := store.CreateProduct(testAdmin, &Product{Name: "Plain", Price: 1, Quantity: 1, Category: "Tools"}); err != nil {
+			t.Fatalf("Failed to create product without codes: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		product Product
+	}{
+		{"Duplicate SKU", Product{Name: "Copy", SKU: "SCN-1"}},
+		{"Duplicate GTIN", Product{Name: "Copy", SKU: "SCN-2", GTIN: "4006381333931"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.product
+			err := store.CreateProduct(testAdmin, &p)
+			if !errors.Is(err, ErrDuplicateProduct) {
+				t.Errorf("Expected ErrDuplicateProduct, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Invalid GTIN", func(t *testing.T) {
+		err := store.CreateProduct(testAdmin, &Product{Name: "Bad", GTIN: "4006381333932"})
+		if err == nil {
+			t.Errorf("Expected error for invalid GTIN, got nil")
+		}
+	})
+
+	t.Run("Update to a used SKU", func(t *testing.T) {
+		other := &Product{Name: "Other", SKU: "OTH-1"}
+		if err := store.CreateProduct(testAdmin, other); err != nil {
+			t.Fatalf("Failed to create test product: %v", err)
+		}
+		other.SKU = "SCN-1"
+		if err := store.UpdateProduct(testAdmin, other); !errors.Is(err, ErrDuplicateProduct) {
+			t.Errorf("Expected ErrDuplicateProduct, got %v", err)
+		}
+	})
+}
+
+func TestLookupByCode(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+
+	product := &Product{Name: "Cereal", Price: 3.5, Quantity: 40, Category: "Food", SKU: "CER-1", GTIN: "036000291452"}
+	if err := store.CreateProduct(testAdmin, product); err != nil {
+		t.Fatalf("Failed to create test product: %v", err)
+	}
+	if product.GTIN != "0036000291452" {
+		t.Errorf("Expected GTIN to be normalized to EAN-13, got %s", product.GTIN)
+	}
+
+	for _, code := range []string{"036000291452", "0036000291452"} {
+		p, err := store.GetProductByGTIN(code)
+		if err != nil {
+			t.Fatalf("Failed to retrieve product by GTIN %s: %v", code, err)
+		}
+		if p.ID != product.ID {
+			t.Errorf("Expected ID %d, got %d", product.ID, p.ID)
+		}
+	}
+
+	p, err := store.GetProductBySKU("CER-1")
+	if err != nil {
+		t.Fatalf("Failed to retrieve product by SKU: %v", err)
+	}
+	if p.Name != "Cereal" || p.SKU != "CER-1" {
+		t.Errorf("Unexpected product %+v", p)
+	}
+
+	if _, err := store.GetProductBySKU("NOPE"); err == nil {
+		t.Errorf("Expected error for unknown SKU, got nil")
+	}
+	if _, err := store.GetProductByGTIN("96385074"); err == nil {
+		t.Errorf("Expected error for unknown GTIN, got nil")
+	}
+}
+
+func TestUpsertProductBySKU(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+
+	first := &Product{Name: "Mug", Price: 5, Quantity: 10, Category: "Kitchen", SKU: "MUG-1"}
+	if err := store.UpsertProductBySKU(testAdmin, first); err != nil {
+		t.Fatalf("Failed to upsert new product: %v", err)
+	}
+	if first.ID <= 0 {
+		t.Fatalf("Expected product ID to be set after upsert, got %d", first.ID)
+	}
+
+	second := &Product{Name: "Big Mug", Price: 7, Quantity: 3, Category: "Kitchen", SKU: "MUG-1", GTIN: "96385074"}
+	if err := store.UpsertProductBySKU(testAdmin, second); err != nil {
+		t.Fatalf("Failed to upsert existing product: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected upsert to keep ID %d, got %d", first.ID, second.ID)
+	}
+
+	products, err := store.ListProducts("")
+	if err != nil {
+		t.Fatalf("Failed to list products: %v", err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("Expected 1 product after two upserts, got %d", len(products))
+	}
+	if products[0].Name != "Big Mug" || products[0].Price != 7 || products[0].GTIN != "96385074" {
+		t.Errorf("Expected product to be updated, got %+v", products[0])
+	}
+
+	if err := store.UpsertProductBySKU(testAdmin, &Product{Name: "No SKU"}); err == nil {
+		t.Errorf("Expected error upserting without SKU, got nil")
+	}
+}
+
+func TestInitDBUpgradesOldSchema(t *testing.T) {
+	os.Remove(testDBPath)
+	defer cleanupTestDB()
+
+	// A database created before products had codes
+	old, err := sql.Open("sqlite3", testDBPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price REAL, quantity INTEGER, category TEXT)",
+		"INSERT INTO products (name, price, quantity, category) VALUES ('Old Widget', 5, 3, 'Tools')",
+	} {
+		if _, err := old.Exec(stmt); err != nil {
+			t.Fatalf("Failed to create old schema: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := InitDB(testDBPath)
+	if err != nil {
+		t.Fatalf("Failed to initialize old database: %v", err)
+	}
+	defer db.Close()
+
+	store := NewProductStore(db)
+	existing, err := store.GetProduct(1)
+	if err != nil {
+		t.Fatalf("Failed to get existing product: %v", err)
+	}
+	if existing.Name != "Old Widget" || existing.SKU != "" || existing.GTIN != "" {
+		t.Errorf("Expected the old product without codes, got %+v", existing)
+	}
+
+	p := &Product{Name: "New Widget", Price: 6, Quantity: 1, Category: "Tools", SKU: "W-2", GTIN: "4006381333931"}
+	if err := store.CreateProduct(testAdmin, p); err != nil {
+		t.Fatalf("Failed to create product with codes: %v", err)
+	}
+	if err := store.CreateProduct(testAdmin, &Product{Name: "Copy", SKU: "W-2"}); err == nil {
+		t.Error("Expected the unique SKU index to reject a duplicate")
+	}
+
+	// Opening again must not try to add the columns twice
+	db2, err := InitDB(testDBPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen upgraded database: %v", err)
+	}
+	db2.Close()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProductRepository is the set of product operations shared by ProductStore
+// and its decorators
+type ProductRepository interface {
+	CreateProduct(actor Actor, product *Product) error
+	GetProduct(id int64) (*Product, error)
+	UpdateProduct(actor Actor, product *Product) error
+	DeleteProduct(actor Actor, id int64) error
+	ListProducts(category string) ([]*Product, error)
+	BatchUpdateInventory(actor Actor, updates map[int64]int) error
+}
+
+var (
+	_ ProductRepository = (*ProductStore)(nil)
+	_ ProductRepository = (*CachedProductStore)(nil)
+)
+
+const listKeyPrefix = "list:"
+
+// CacheStats holds the counters of a CachedProductStore
+type CacheStats struct {
+	Hits        uint64 // reads served from the cache
+	Misses      uint64 // reads that went to the database
+	Shared      uint64 // reads that waited for a concurrent miss instead of querying
+	Evictions   uint64 // entries dropped because the cache was full
+	Expirations uint64 // entries dropped because their TTL passed
+	Size        int    // entries currently cached
+}
+
+// HitRatio returns the share of reads that did not query the database
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses + s.Shared
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Shared) / float64(total)
+}
+
+// CachedProductStore is a read-through cache in front of a ProductStore.
+// GetProduct and ListProducts results are kept in a bounded LRU with a TTL,
+// concurrent misses for the same key share one query, and every write
+// invalidates the entries it may have changed.
+type CachedProductStore struct {
+	store    *ProductStore
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	lru        *list.List // front is most recently used
+	items      map[string]*list.Element
+	flights    map[string]*flight
+	generation uint64 // bumped on every invalidation
+	stats      CacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// flight is a database read in progress that other readers can wait for
+type flight struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// NewCachedProductStore wraps store with a cache holding at most capacity entries.
+// A ttl of zero or less keeps entries until they are evicted or invalidated.
+func NewCachedProductStore(store *ProductStore, capacity int, ttl time.Duration) (*CachedProductStore, error) {
+	if store == nil {
+		return nil, errors.New("store cannot be nil")
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("cache capacity must be positive, got %d", capacity)
+	}
+	return &CachedProductStore{
+		store:    store,
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		flights:  make(map[string]*flight),
+	}, nil
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *CachedProductStore) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// CreateProduct adds a new product and invalidates the cached lists
+func (c *CachedProductStore) CreateProduct(actor Actor, product *Product) error {
+	err := c.store.CreateProduct(actor, product)
+	c.invalidate()
+	return err
+}
+
+// GetProduct retrieves a product by ID, from the cache if possible
+func (c *CachedProductStore) GetProduct(id int64) (*Product, error) {
+	value, err := c.load(productKey(id), func() (interface{}, error) {
+		return c.store.GetProduct(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneProduct(value.(*Product)), nil
+}
+
+// UpdateProduct updates a product and invalidates its cache entry and the cached lists
+func (c *CachedProductStore) UpdateProduct(actor Actor, product *Product) error {
+	err := c.store.UpdateProduct(actor, product)
+	c.invalidate(product.ID)
+	return err
+}
+
+// DeleteProduct removes a product and invalidates its cache entry and the cached lists
+func (c *CachedProductStore) DeleteProduct(actor Actor, id int64) error {
+	err := c.store.DeleteProduct(actor, id)
+	c.invalidate(id)
+	return err
+}
+
+// ListProducts returns all products with optional filtering by category, from the cache if possible
+func (c *CachedProductStore) ListProducts(category string) ([]*Product, error) {
+	value, err := c.load(listKeyPrefix+category, func() (interface{}, error) {
+		return c.store.ListProducts(category)
+	})
+	if err != nil {
+		return nil, err
+	}
+	products := value.([]*Product)
+	res := make([]*Product, len(products))
+	for i, p := range products {
+		res[i] = cloneProduct(p)
+	}
+	return res, nil
+}
+
+// BatchUpdateInventory updates several quantities and invalidates every affected entry
+func (c *CachedProductStore) BatchUpdateInventory(actor Actor, updates map[int64]int) error {
+	err := c.store.BatchUpdateInventory(actor, updates)
+	ids := make([]int64, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+	c.invalidate(ids...)
+	return err
+}
+
+// UpsertProductBySKU creates or updates a product by SKU and invalidates its cache entry and the cached lists
+func (c *CachedProductStore) UpsertProductBySKU(actor Actor, product *Product) error {
+	err := c.store.UpsertProductBySKU(actor, product)
+	c.invalidate(product.ID)
+	return err
+}
+
+// GetProductBySKU retrieves a product by its SKU. Lookups by code are not cached.
+func (c *CachedProductStore) GetProductBySKU(sku string) (*Product, error) {
+	return c.store.GetProductBySKU(sku)
+}
+
+// GetProductByGTIN retrieves a product by its barcode. Lookups by code are not cached.
+func (c *CachedProductStore) GetProductByGTIN(code string) (*Product, error) {
+	return c.store.GetProductByGTIN(code)
+}
+
+// ReceivePurchaseOrder books received goods and clears the cache, since any
+// product on the order may have changed
+func (c *CachedProductStore) ReceivePurchaseOrder(actor Actor, orderID int64, received map[int64]int) error {
+	err := c.store.ReceivePurchaseOrder(actor, orderID, received)
+	c.Purge()
+	return err
+}
+
+// Purge drops every cached entry
+func (c *CachedProductStore) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.lru.Init()
+	c.items = make(map[string]*list.Element)
+	c.flights = make(map[string]*flight)
+}
+
+// load returns the cached value for key or calls fetch to read it.
+// Only one fetch per key runs at a time; other readers wait for its result.
+func (c *CachedProductStore) load(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.ttl <= 0 || c.now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return entry.value, nil
+		}
+		c.removeElement(elem)
+		c.stats.Expirations++
+	}
+
+	if f, ok := c.flights[key]; ok {
+		c.stats.Shared++
+		c.mu.Unlock()
+		f.wg.Wait()
+		return f.value, f.err
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	c.flights[key] = f
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	f.value, f.err = fetch()
+
+	c.mu.Lock()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	// A write since the fetch started may have made the value stale
+	if f.err == nil && c.generation == generation {
+		c.add(key, f.value)
+	}
+	c.mu.Unlock()
+	f.wg.Done()
+
+	return f.value, f.err
+}
+
+// add stores a value, evicting the least recently used entry if the cache is full.
+// The caller must hold c.mu.
+func (c *CachedProductStore) add(key string, value interface{}) {
+	entry := &cacheEntry{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// invalidate drops the entries of the given products and all cached lists,
+// and detaches in-flight reads so later readers query again
+func (c *CachedProductStore) invalidate(ids ...int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, id := range ids {
+		key := productKey(id)
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+		delete(c.flights, key)
+	}
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, listKeyPrefix) {
+			c.removeElement(elem)
+		}
+	}
+	for key := range c.flights {
+		if strings.HasPrefix(key, listKeyPrefix) {
+			delete(c.flights, key)
+		}
+	}
+}
+
+// removeElement removes an entry from the LRU. The caller must hold c.mu.
+func (c *CachedProductStore) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.items, entry.key)
+}
+
+func productKey(id int64) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+// cloneProduct copies a product so callers cannot modify cached values
+func cloneProduct(p *Product) *Product {
+	clone := *p
+	return &clone
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupCachedStore(t *testing.T, capacity int, ttl time.Duration) (*CachedProductStore, []*Product) {
+	t.Helper()
+	db := setupTestDB(t)
+	t.Cleanup(func() {
+		db.Close()
+		cleanupTestDB()
+	})
+
+	store := NewProductStore(db)
+	products := []*Product{
+		{Name: "Product 1", Price: 9.99, Quantity: 10, Category: "Electronics"},
+		{Name: "Product 2", Price: 19.99, Quantity: 20, Category: "Books"},
+		{Name: "Product 3", Price: 29.99, Quantity: 30, Category: "Books"},
+	}
+	for _, p := range products {
+		if err := store.CreateProduct(testAdmin, p); err != nil {
+			t.Fatalf("Failed to create test product: %v", err)
+		}
+	}
+
+	cached, err := NewCachedProductStore(store, capacity, ttl)
+	if err != nil {
+		t.Fatalf("Failed to create cached store: %v", err)
+	}
+	return cached, products
+}
+
+func TestNewCachedProductStore(t *testing.T) {
+	if _, err := NewCachedProductStore(nil, 10, 0); err == nil {
+		t.Errorf("Expected error for nil store, got nil")
+	}
+	if _, err := NewCachedProductStore(&ProductStore{}, 0, 0); err == nil {
+		t.Errorf("Expected error for zero capacity, got nil")
+	}
+}
+
+func TestCachedGetProduct(t *testing.T) {
+	cached, products := setupCachedStore(t, 10, 0)
+	id := products[0].ID
+
+	for i := 0; i < 3; i++ {
+		p, err := cached.GetProduct(id)
+		if err != nil {
+			t.Fatalf("Failed to retrieve product: %v", err)
+		}
+		if p.Name != "Product 1" {
+			t.Errorf("Expected name Product 1, got %s", p.Name)
+		}
+		// Modifying the returned product must not change the cache
+		p.Name = "Changed"
+	}
+
+	stats := cached.Stats()
+	if stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("Expected 1 miss and 2 hits, got %+v", stats)
+	}
+
+	if _, err := cached.GetProduct(id + 1000); err == nil {
+		t.Errorf("Expected error retrieving non-existent product, got nil")
+	}
+	if cached.Stats().Size != 1 {
+		t.Errorf("Expected errors not to be cached, got size %d", cached.Stats().Size)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	cached, products := setupCachedStore(t, 10, 0)
+
+	// Warm the cache
+	if _, err := cached.GetProduct(products[0].ID); err != nil {
+		t.Fatalf("Failed to retrieve product: %v", err)
+	}
+	books, err := cached.ListProducts("Books")
+	if err != nil || len(books) != 2 {
+		t.Fatalf("Expected 2 books, got %d (err %v)", len(books), err)
+	}
+
+	t.Run("UpdateProduct", func(t *testing.T) {
+		update := *products[0]
+		update.Price = 99.99
+		update.Category = "Books"
+		if err := cached.UpdateProduct(testAdmin, &update); err != nil {
+			t.Fatalf("Failed to update product: %v", err)
+		}
+		p, _ := cached.GetProduct(products[0].ID)
+		if p.Price != 99.99 {
+			t.Errorf("Expected updated price 99.99, got %f", p.Price)
+		}
+		books, _ := cached.ListProducts("Books")
+		if len(books) != 3 {
+			t.Errorf("Expected 3 books after update, got %d", len(books))
+		}
+	})
+
+	t.Run("BatchUpdateInventory", func(t *testing.T) {
+		if err := cached.BatchUpdateInventory(testAdmin, map[int64]int{products[0].ID: 1, products[1].ID: 2}); err != nil {
+			t.Fatalf("Failed to perform batch update: %v", err)
+		}
+		p, _ := cached.GetProduct(products[0].ID)
+		if p.Quantity != 1 {
+			t.Errorf("Expected quantity 1 after batch update, got %d", p.Quantity)
+		}
+		books, _ := cached.ListProducts("Books")
+		for _, b := range books {
+			if b.ID == products[1].ID && b.Quantity != 2 {
+				t.Errorf("Expected quantity 2 in cached list, got %d", b.Quantity)
+			}
+		}
+	})
+
+	t.Run("CreateProduct", func(t *testing.T) {
+		if err := cached.CreateProduct(testAdmin, &Product{Name: "Product 4", Price: 5, Quantity: 1, Category: "Books"}); err != nil {
+			t.Fatalf("Failed to create product: %v", err)
+		}
+		books, _ := cached.ListProducts("Books")
+		if len(books) != 4 {
+			t.Errorf("Expected 4 books after create, got %d", len(books))
+		}
+	})
+
+	t.Run("DeleteProduct", func(t *testing.T) {
+		if err := cached.DeleteProduct(testAdmin, products[0].ID); err != nil {
+			t.Fatalf("Failed to delete product: %v", err)
+		}
+		if _, err := cached.GetProduct(products[0].ID); err == nil {
+			t.Errorf("Expected error retrieving deleted product, got nil")
+		}
+	})
+}
+
+func TestCacheTTL(t *testing.T) {
+	cached, products := setupCachedStore(t, 10, time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	cached.GetProduct(products[0].ID)
+	now = now.Add(30 * time.Second)
+	cached.GetProduct(products[0].ID)
+	now = now.Add(time.Minute)
+	cached.GetProduct(products[0].ID)
+
+	stats := cached.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Expirations != 1 {
+		t.Errorf("Expected 1 hit, 2 misses and 1 expiration, got %+v", stats)
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	cached, products := setupCachedStore(t, 2, 0)
+
+	cached.GetProduct(products[0].ID)
+	cached.GetProduct(products[1].ID)
+	cached.GetProduct(products[0].ID) // product 2 is now least recently used
+	cached.GetProduct(products[2].ID) // evicts product 2
+
+	stats := cached.Stats()
+	if stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected size 2 and 1 eviction, got %+v", stats)
+	}
+
+	cached.GetProduct(products[0].ID)
+	if cached.Stats().Hits != 2 {
+		t.Errorf("Expected product 1 to still be cached, got %+v", cached.Stats())
+	}
+	cached.GetProduct(products[1].ID)
+	if cached.Stats().Misses != 4 {
+		t.Errorf("Expected product 2 to have been evicted, got %+v", cached.Stats())
+	}
+}
+
+func TestCacheCollapsesConcurrentMisses(t *testing.T) {
+	cached, _ := setupCachedStore(t, 10, 0)
+
+	const readers = 20
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func() (interface{}, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return &Product{ID: 1, Name: "Slow"}, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer wg.Done()
+			value, err := cached.load("product:1", fetch)
+			if err != nil || value.(*Product).Name != "Slow" {
+				t.Errorf("Unexpected result %v, %v", value, err)
+			}
+		}()
+	}
+
+	// Wait until every reader is either fetching or waiting
+	for {
+		stats := cached.Stats()
+		if stats.Misses+stats.Shared == readers {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("Expected 1 fetch for concurrent misses, got %d", fetches)
+	}
+	if stats := cached.Stats(); stats.Shared != readers-1 {
+		t.Errorf("Expected %d shared reads, got %+v", readers-1, stats)
+	}
+}
+
+func TestCacheDropsStaleFetch(t *testing.T) {
+	cached, _ := setupCachedStore(t, 10, 0)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		cached.load("product:1", func() (interface{}, error) {
+			close(started)
+			<-release
+			return &Product{ID: 1, Name: "Stale"}, nil
+		})
+		close(done)
+	}()
+
+	<-started
+	cached.invalidate(1)
+	close(release)
+	<-done
+
+	if size := cached.Stats().Size; size != 0 {
+		t.Errorf("Expected a fetch overtaken by a write not to be cached, got size %d", size)
+	}
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DefaultLocale is the language of Product.Name. Every fallback chain ends here.
+const DefaultLocale = "en"
+
+// Translation holds the name and description of a product in one locale
+type Translation struct {
+	ProductID   int64
+	Locale      string
+	Name        string
+	Description string
+}
+
+// LocalizedProduct is a product with its name and description resolved for a locale
+type LocalizedProduct struct {
+	Product
+	Description string
+	Locale      string // locale the name was taken from
+}
+
+// NormalizeLocale validates a BCP 47 style tag such as "zh-Hant-TW" or "pt_br"
+// and returns it in canonical case: language lower, script title, region upper.
+func NormalizeLocale(tag string) (string, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	if len(parts) > 3 {
+		return "", fmt.Errorf("locale %q has too many subtags", tag)
+	}
+	for i, part := range parts {
+		if !isAlnum(part) {
+			return "", fmt.Errorf("locale %q has an invalid subtag %q", tag, part)
+		}
+		switch {
+		case i == 0:
+			if len(part) < 2 || len(part) > 3 || !isLetters(part) {
+				return "", fmt.Errorf("locale %q must start with a 2 or 3 letter language code", tag)
+			}
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4 && isLetters(part) && i == 1:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		case (len(part) == 2 && isLetters(part)) || (len(part) == 3 && !isLetters(part)):
+			parts[i] = strings.ToUpper(part)
+		default:
+			return "", fmt.Errorf("locale %q has an invalid subtag %q", tag, part)
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+func isAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// FallbackChain returns the locales to try for a normalized locale, most
+// specific first, e.g. zh-Hant-TW, zh-Hant, zh, en.
+func FallbackChain(locale string) []string {
+	var chain []string
+	for locale != "" {
+		chain = append(chain, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	if len(chain) == 0 || chain[len(chain)-1] != DefaultLocale {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+// SetTranslation stores the name and description of a product in a locale,
+// replacing an existing translation
+func (ps *ProductStore) SetTranslation(actor Actor, tr *Translation) error {
+	if err := authorize(actor, PermEditProducts); err != nil {
+		return err
+	}
+	locale, err := NormalizeLocale(tr.Locale)
+	if err != nil {
+		return err
+	}
+	if tr.Name == "" {
+		return errors.New("translated name cannot be empty")
+	}
+
+	stored := *tr
+	stored.Locale = locale
+	err = ps.writeAudited(actor, func(tx *auditTx) error {
+		before, err := findTranslation(tx, tr.ProductID, locale)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(
+			`INSERT INTO product_translations (product_id, locale, name, description)
+			SELECT id, ?, ?, ? FROM products WHERE id = ?
+			ON CONFLICT(product_id, locale) DO UPDATE SET name = excluded.name, description = excluded.description`,
+			locale, tr.Name, tr.Description, tr.ProductID,
+		)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("product with ID %d %w", tr.ProductID, ErrNotFound)
+		}
+		return tx.record(ActionSetTranslation, tr.ProductID, before, &stored)
+	})
+	if err != nil {
+		return err
+	}
+	tr.Locale = locale
+	return nil
+}
+
+// DeleteTranslation removes the translation of a product in a locale
+func (ps *ProductStore) DeleteTranslation(actor Actor, productID int64, locale string) error {
+	if err := authorize(actor, PermEditProducts); err != nil {
+		return err
+	}
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		before, err := findTranslation(tx, productID, locale)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("translation %s of product %d %w", locale, productID, ErrNotFound)
+		}
+		if _, err := tx.Exec("DELETE FROM product_translations WHERE product_id = ? AND locale = ?", productID, locale); err != nil {
+			return err
+		}
+		return tx.record(ActionDeleteTranslation, productID, before, nil)
+	})
+}
+
+// findTranslation reads the translation of a product in a normalized locale,
+// returning nil if there is none
+func findTranslation(db queryRower, productID int64, locale string) (*Translation, error) {
+	tr := &Translation{ProductID: productID, Locale: locale}
+	err := db.QueryRow(
+		"SELECT name, description FROM product_translations WHERE product_id = ? AND locale = ?",
+		productID, locale,
+	).Scan(&tr.Name, &tr.Description)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// GetProductLocalized retrieves a product with its name and description in the
+// given locale, falling back along FallbackChain for missing translations
+func (ps *ProductStore) GetProductLocalized(id int64, locale string) (*LocalizedProduct, error) {
+	chain, err := localeChain(locale)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ps.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := ps.loadTranslations([]*Product{p}, chain)
+	if err != nil {
+		return nil, err
+	}
+	return localize(p, translations[p.ID], chain), nil
+}
+
+// ListProductsLocalized returns the products of a category (all if empty) in the
+// given locale, sorted by their localized name using the locale's collation
+func (ps *ProductStore) ListProductsLocalized(category, locale string) ([]*LocalizedProduct, error) {
+	chain, err := localeChain(locale)
+	if err != nil {
+		return nil, err
+	}
+	products, err := ps.ListProducts(category)
+	if err != nil {
+		return nil, err
+	}
+	return ps.localizeAll(products, chain)
+}
+
+// SearchProducts returns the products whose localized name or description
+// contains query, ignoring case and accents, sorted like ListProductsLocalized.
+// The database narrows the products down to those with a matching name or
+// translation before they are localized.
+func (ps *ProductStore) SearchProducts(query, locale string) ([]*LocalizedProduct, error) {
+	chain, err := localeChain(locale)
+	if err != nil {
+		return nil, err
+	}
+	products, err := ps.searchCandidates(searchFold(query), chain)
+	if err != nil {
+		return nil, err
+	}
+	localized, err := ps.localizeAll(products, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// A candidate may only match in a translation its localized name doesn't
+	// come from, so the match is checked again. Search ignores accents even in
+	// languages that sort accented letters separately.
+	matcher := NewCollator(DefaultLocale)
+	res := []*LocalizedProduct{}
+	for _, lp := range localized {
+		if matcher.Contains(lp.Name, query) || matcher.Contains(lp.Description, query) {
+			res = append(res, lp)
+		}
+	}
+	return res, nil
+}
+
+// searchCandidates returns the products whose name, or whose name or
+// description in one of the chain's locales, contains the folded needle
+func (ps *ProductStore) searchCandidates(needle string, chain []string) ([]*Product, error) {
+	ctx := context.Background()
+	conn, err := ps.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := registerSearchFold(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{needle}
+	for _, locale := range chain {
+		args = append(args, locale)
+	}
+	args = append(args, needle, needle)
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+productColumns+" FROM products WHERE instr(search_fold(name), ?) > 0 OR id IN ("+
+			"SELECT product_id FROM product_translations WHERE locale IN ("+placeholders(len(chain))+") "+
+			"AND (instr(search_fold(name), ?) > 0 OR instr(search_fold(description), ?) > 0))",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []*Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// registerSearchFold makes searchFold available to SQL on conn as search_fold.
+// Functions are registered per connection, so this is done once per connection.
+func registerSearchFold(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, "SELECT search_fold('')"); err == nil {
+		return nil
+	}
+	return conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("search needs a SQLite connection, got %T", driverConn)
+		}
+		return sqliteConn.RegisterFunc("search_fold", searchFold, true)
+	})
+}
+
+// searchFold lowercases s and replaces accented letters with their base
+// letters. One string contains another by the default locale's
+// Collator.Contains exactly when its folded form contains the other's.
+func searchFold(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		if base, ok := foldings[lower]; ok {
+			b.WriteString(base)
+		} else {
+			b.WriteRune(lower)
+		}
+	}
+	return b.String()
+}
+
+func localeChain(locale string) ([]string, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	normalized, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	return FallbackChain(normalized), nil
+}
+
+// localizeAll resolves the translations of all products in bulk and sorts them by name
+func (ps *ProductStore) localizeAll(products []*Product, chain []string) ([]*LocalizedProduct, error) {
+	translations, err := ps.loadTranslations(products, chain)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*LocalizedProduct, len(products))
+	for i, p := range products {
+		res[i] = localize(p, translations[p.ID], chain)
+	}
+
+	collator := NewCollator(chain[0])
+	sort.SliceStable(res, func(i, j int) bool {
+		return collator.Compare(res[i].Name, res[j].Name) < 0
+	})
+	return res, nil
+}
+
+// loadTranslations reads the translations in the chain's locales, keyed by product and locale
+func (ps *ProductStore) loadTranslations(products []*Product, chain []string) (map[int64]map[string]*Translation, error) {
+	res := make(map[int64]map[string]*Translation)
+	locales := make([]interface{}, len(chain))
+	for i, locale := range chain {
+		locales[i] = locale
+	}
+	_, err := queryIn(ps.db,
+		"SELECT product_id, locale, name, description FROM product_translations WHERE product_id IN (%s) AND locale IN ("+
+			placeholders(len(chain))+")",
+		productIDs(products), locales,
+		func(rows *sql.Rows) error {
+			tr := &Translation{}
+			if err := rows.Scan(&tr.ProductID, &tr.Locale, &tr.Name, &tr.Description); err != nil {
+				return err
+			}
+			if res[tr.ProductID] == nil {
+				res[tr.ProductID] = make(map[string]*Translation)
+			}
+			res[tr.ProductID][tr.Locale] = tr
+			return nil
+		})
+	return res, err
+}
+
+// localize picks the name and description from the first locale in the chain
+// that has them. Product.Name counts as the DefaultLocale name.
+func localize(p *Product, translations map[string]*Translation, chain []string) *LocalizedProduct {
+	lp := &LocalizedProduct{Product: *p, Locale: DefaultLocale}
+	nameFound, descriptionFound := false, false
+	for _, locale := range chain {
+		tr, ok := translations[locale]
+		if !ok {
+			continue
+		}
+		if !nameFound && tr.Name != "" {
+			lp.Name = tr.Name
+			lp.Locale = locale
+			nameFound = true
+		}
+		if !descriptionFound && tr.Description != "" {
+			lp.Description = tr.Description
+			descriptionFound = true
+		}
+	}
+	return lp
+}
+
+//
+// Collation
+//
+
+// Collator compares strings the way a reader of a language expects: accents
+// and case only matter when the letters are otherwise equal, and some
+// languages sort extra letters after z (Swedish å ä ö) or in between (Spanish ñ).
+// It covers Latin scripts; other characters are compared by code point.
+type Collator struct {
+	tailoring map[rune]int
+}
+
+// collation weights, spaced so tailored letters fit between base letters
+const weightStep = 8
+
+// foldings maps accented Latin letters to their base letters
+var foldings = buildFoldings(map[string]string{
+	"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđ", "e": "èéêëēĕėęě", "g": "ĝğġģ",
+	"h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ", "l": "ĺļľŀł", "n": "ñńņňŉ",
+	"o": "òóôõöøōŏő", "r": "ŕŗř", "s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűų",
+	"w": "ŵ", "y": "ýÿŷ", "z": "źżž", "ss": "ß", "ae": "æ", "oe": "œ", "th": "þ",
+})
+
+func buildFoldings(groups map[string]string) map[rune]string {
+	m := make(map[rune]string)
+	for base, letters := range groups {
+		for _, r := range letters {
+			m[r] = base
+		}
+	}
+	return m
+}
+
+// tailorings lists letters that sort as separate letters, after the anchor letter
+var tailorings = map[string][]struct {
+	letter rune
+	anchor rune
+}{
+	"sv": {{'å', 'z'}, {'ä', 'z'}, {'ö', 'z'}},
+	"fi": {{'å', 'z'}, {'ä', 'z'}, {'ö', 'z'}},
+	"da": {{'æ', 'z'}, {'ø', 'z'}, {'å', 'z'}},
+	"nb": {{'æ', 'z'}, {'ø', 'z'}, {'å', 'z'}},
+	"es": {{'ñ', 'n'}},
+}
+
+// NewCollator creates a collator for a locale. Only the language subtag matters.
+func NewCollator(locale string) *Collator {
+	lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	c := &Collator{tailoring: make(map[rune]int)}
+	offsets := make(map[rune]int)
+	for _, t := range tailorings[lang] {
+		offsets[t.anchor]++
+		c.tailoring[t.letter] = int(t.anchor)*weightStep + offsets[t.anchor]
+	}
+	return c
+}
+
+// collationElement holds the weights of one character at the three levels
+type collationElement struct {
+	primary   int // base letter
+	secondary int // accent
+	tertiary  int // case
+}
+
+func (c *Collator) elements(s string) []collationElement {
+	var res []collationElement
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		tertiary := 0
+		if lower != r {
+			tertiary = 1
+		}
+		if w, ok := c.tailoring[lower]; ok {
+			res = append(res, collationElement{primary: w, tertiary: tertiary})
+			continue
+		}
+		if base, ok := foldings[lower]; ok {
+			for _, b := range base {
+				res = append(res, collationElement{primary: int(b) * weightStep, secondary: int(lower), tertiary: tertiary})
+			}
+			continue
+		}
+		res = append(res, collationElement{primary: int(lower) * weightStep, tertiary: tertiary})
+	}
+	return res
+}
+
+// Compare returns -1, 0 or 1. Base letters are compared first, then accents,
+// then case, and finally the raw strings so the order is total.
+func (c *Collator) Compare(a, b string) int {
+	ea, eb := c.elements(a), c.elements(b)
+	levels := []func(collationElement) int{
+		func(e collationElement) int { return e.primary },
+		func(e collationElement) int { return e.secondary },
+		func(e collationElement) int { return e.tertiary },
+	}
+	for _, weight := range levels {
+		for i := 0; i < len(ea) && i < len(eb); i++ {
+			if wa, wb := weight(ea[i]), weight(eb[i]); wa != wb {
+				if wa < wb {
+					return -1
+				}
+				return 1
+			}
+		}
+		if len(ea) != len(eb) {
+			if len(ea) < len(eb) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// Contains reports whether substr occurs in s, comparing base letters only
+func (c *Collator) Contains(s, substr string) bool {
+	hay, needle := c.elements(s), c.elements(substr)
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		match := true
+		for j := range needle {
+			if hay[i+j].primary != needle[j].primary {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tag         string
+		expected    string
+		expectError bool
+	}{
+		{name: "Language only", tag: "EN", expected: "en"},
+		{name: "Language and region", tag: "zh-tw", expected: "zh-TW"},
+		{name: "Underscore separator", tag: "pt_br", expected: "pt-BR"},
+		{name: "Script and region", tag: "zh-hant-tw", expected: "zh-Hant-TW"},
+		{name: "Numeric region", tag: "es-419", expected: "es-419"},
+		{name: "Empty", tag: "", expectError: true},
+		{name: "Long language", tag: "english", expectError: true},
+		{name: "Bad subtag", tag: "en-U$", expectError: true},
+		{name: "Too many subtags", tag: "zh-Hant-TW-x", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locale, err := NormalizeLocale(tc.tag)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tc.tag, locale)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Did not expect error for %q, got %v", tc.tag, err)
+			}
+			if locale != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, locale)
+			}
+		})
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	testCases := []struct {
+		locale   string
+		expected []string
+	}{
+		{"zh-TW", []string{"zh-TW", "zh", "en"}},
+		{"zh-Hant-TW", []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{"en-GB", []string{"en-GB", "en"}},
+		{"en", []string{"en"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			chain := FallbackChain(tc.locale)
+			if !reflect.DeepEqual(chain, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, chain)
+			}
+		})
+	}
+}
+
+func TestCollatorCompare(t *testing.T) {
+	testCases := []struct {
+		name     string
+		locale   string
+		input    []string
+		expected []string
+	}{
+		{"Accents sort with their base letter", "en", []string{"zebra", "église", "eagle"}, []string{"eagle", "église", "zebra"}},
+		{"Case only breaks ties", "en", []string{"b", "B", "a"}, []string{"a", "b", "B"}},
+		{"Sharp s expands", "de", []string{"Strasse", "Straße", "Strand"}, []string{"Strand", "Strasse", "Straße"}},
+		{"Swedish letters after z", "sv-SE", []string{"öl", "zon", "äpple", "apa"}, []string{"apa", "zon", "äpple", "öl"}},
+		{"German umlaut with its base letter", "de", []string{"öl", "zon", "äpple", "apa"}, []string{"apa", "äpple", "öl", "zon"}},
+		{"Spanish ñ after n", "es", []string{"ñu", "oso", "nube"}, []string{"nube", "ñu", "oso"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			collator := NewCollator(tc.locale)
+			sorted := append([]string(nil), tc.input...)
+			for i := 1; i < len(sorted); i++ {
+				for j := i; j > 0 && collator.Compare(sorted[j-1], sorted[j]) > 0; j-- {
+					sorted[j-1], sorted[j] = sorted[j], sorted[j-1]
+				}
+			}
+			if !reflect.DeepEqual(sorted, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, sorted)
+			}
+		})
+	}
+
+	if NewCollator("en").Compare("Café", "Café") != 0 {
+		t.Errorf("Expected equal strings to compare as 0")
+	}
+}
+
+func setupTranslatedStore(t *testing.T) (*ProductStore, map[string]*Product) {
+	t.Helper()
+	db := setupTestDB(t)
+	t.Cleanup(func() {
+		db.Close()
+		cleanupTestDB()
+	})
+
+	store := NewProductStore(db)
+	products := map[string]*Product{}
+	for _, name := range []string{"Tea", "Apple", "Orange juice"} {
+		p := &Product{Name: name, Price: 1, Quantity: 1, Category: "Food"}
+		if err := store.CreateProduct(testAdmin, p); err != nil {
+			t.Fatalf("Failed to create test product: %v", err)
+		}
+		products[name] = p
+	}
+
+	translations := []*Translation{
+		{ProductID: products["Tea"].ID, Locale: "zh", Name: "茶", Description: "绿茶"},
+		{ProductID: products["Tea"].ID, Locale: "zh-TW", Name: "茶葉"},
+		{ProductID: products["Tea"].ID, Locale: "sv", Name: "Te", Description: "Grönt te"},
+		{ProductID: products["Apple"].ID, Locale: "sv", Name: "Äpple", Description: "Rött äpple"},
+		{ProductID: products["Orange juice"].ID, Locale: "sv", Name: "Apelsinjuice"},
+	}
+	for _, tr := range translations {
+		if err := store.SetTranslation(testAdmin, tr); err != nil {
+			t.Fatalf("Failed to set translation: %v", err)
+		}
+	}
+	return store, products
+}
+
+func TestGetProductLocalized(t *testing.T) {
+	store, products := setupTranslatedStore(t)
+	tea := products["Tea"].ID
+
+	testCases := []struct {
+		name                string
+		locale              string
+		expectedName        string
+		expectedLocale      string
+		expectedDescription string
+	}{
+		{"Exact locale", "zh", "茶", "zh", "绿茶"},
+		{"Region falls back per field", "zh-TW", "茶葉", "zh-TW", "绿茶"},
+		{"Script falls back to language", "zh_Hant_TW", "茶", "zh", "绿茶"},
+		{"Unknown locale falls back to default", "fr", "Tea", "en", ""},
+		{"Empty locale is the default", "", "Tea", "en", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lp, err := store.GetProductLocalized(tea, tc.locale)
+			if err != nil {
+				t.Fatalf("Failed to get localized product: %v", err)
+			}
+			if lp.Name != tc.expectedName || lp.Locale != tc.expectedLocale || lp.Description != tc.expectedDescription {
+				t.Errorf("Expected %s (%s) %q, got %s (%s) %q", tc.expectedName, tc.expectedLocale, tc.expectedDescription, lp.Name, lp.Locale, lp.Description)
+			}
+			if lp.ID != tea || lp.Price != 1 {
+				t.Errorf("Expected product fields to be kept, got %+v", lp.Product)
+			}
+		})
+	}
+
+	if _, err := store.GetProductLocalized(999, "zh"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.GetProductLocalized(tea, "not a locale"); err == nil {
+		t.Errorf("Expected error for invalid locale, got nil")
+	}
+
+	// The base product is unchanged by translations
+	p, err := store.GetProduct(tea)
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if p.Name != "Tea" {
+		t.Errorf("Expected base name Tea, got %s", p.Name)
+	}
+}
+
+func TestListProductsLocalized(t *testing.T) {
+	store, _ := setupTranslatedStore(t)
+
+	testCases := []struct {
+		locale   string
+		expected []string
+	}{
+		{"en", []string{"Apple", "Orange juice", "Tea"}},
+		{"sv-SE", []string{"Apelsinjuice", "Te", "Äpple"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			products, err := store.ListProductsLocalized("Food", tc.locale)
+			if err != nil {
+				t.Fatalf("Failed to list localized products: %v", err)
+			}
+			var names []string
+			for _, p := range products {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	store, _ := setupTranslatedStore(t)
+
+	testCases := []struct {
+		name     string
+		query    string
+		locale   string
+		expected []string
+	}{
+		{"Ignores case and accents", "APPLE", "sv", []string{"Äpple"}},
+		{"Matches description", "gront", "sv", []string{"Te"}},
+		{"Base names in default locale", "juice", "en", []string{"Orange juice"}},
+		{"Accented query", "ÄPPLE", "en", []string{"Apple"}},
+		{"Localized name in the fallback chain", "茶", "zh-TW", []string{"茶葉"}},
+		{"Match in another locale only", "apelsin", "en", nil},
+		{"No match", "coffee", "en", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			products, err := store.SearchProducts(tc.query, tc.locale)
+			if err != nil {
+				t.Fatalf("Failed to search products: %v", err)
+			}
+			var names []string
+			for _, p := range products {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestTranslationMaintenance(t *testing.T) {
+	store, products := setupTranslatedStore(t)
+	tea := products["Tea"].ID
+
+	if err := store.SetTranslation(testAdmin, &Translation{ProductID: tea, Locale: "zh", Name: "红茶"}); err != nil {
+		t.Fatalf("Failed to replace translation: %v", err)
+	}
+	lp, err := store.GetProductLocalized(tea, "zh")
+	if err != nil {
+		t.Fatalf("Failed to get localized product: %v", err)
+	}
+	if lp.Name != "红茶" || lp.Description != "" {
+		t.Errorf("Expected replaced translation, got %s %q", lp.Name, lp.Description)
+	}
+
+	if err := store.SetTranslation(testAdmin, &Translation{ProductID: 999, Locale: "zh", Name: "无"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown product, got %v", err)
+	}
+	if err := store.SetTranslation(testAdmin, &Translation{ProductID: tea, Locale: "zh"}); err == nil {
+		t.Errorf("Expected error for empty name, got nil")
+	}
+
+	if err := store.DeleteTranslation(testAdmin, tea, "ZH"); err != nil {
+		t.Fatalf("Failed to delete translation: %v", err)
+	}
+	if err := store.DeleteTranslation(testAdmin, tea, "zh"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted translation, got %v", err)
+	}
+
+	// Deleting a product removes its translations
+	if err := store.DeleteProduct(testAdmin, tea); err != nil {
+		t.Fatalf("Failed to delete product: %v", err)
+	}
+	var count int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM product_translations WHERE product_id = ?", tea).Scan(&count); err != nil {
+		t.Fatalf("Failed to count translations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected translations to be deleted with the product, got %d", count)
+	}
+}
@@ -2,11 +2,19 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrNotFound is wrapped by the errors returned for missing rows,
+// e.g. "product with ID 7 not found"
+var ErrNotFound = errors.New("not found")
+
 // Product represents a product in the inventory system
 type Product struct {
 	ID       int64
@@ -14,19 +22,107 @@ type Product struct {
 	Price    float64
 	Quantity int
 	Category string
+	SKU      string // optional, unique stock keeping unit
+	GTIN     string // optional, unique EAN-8, EAN-13 or UPC-A barcode, see NormalizeGTIN
+}
+
+// productColumns lists the products columns in the order scanProduct expects
+const productColumns = "id, name, price, quantity, category, sku, gtin"
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanProduct reads a product selected with productColumns
+func scanProduct(row rowScanner) (*Product, error) {
+	p := &Product{}
+	var sku, gtin sql.NullString
+	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Quantity, &p.Category, &sku, &gtin)
+	if err != nil {
+		return nil, err
+	}
+	p.SKU = sku.String
+	p.GTIN = gtin.String
+	return p, nil
 }
 
-// ProductStore manages product operations
+// nullString stores empty strings as NULL so they don't collide in unique indexes
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// ProductStore manages product operations. Every change is made on behalf of
+// an actor, checked against the actor's role and recorded in the audit log.
 type ProductStore struct {
-	db *sql.DB
+	db  *sql.DB
+	now func() time.Time
+
+	// writeMu serializes writes, so the permission checks of concurrent
+	// changes cannot interleave
+	writeMu sync.Mutex
 }
 
 // NewProductStore creates a new ProductStore with the given database connection
 func NewProductStore(db *sql.DB) *ProductStore {
-	return &ProductStore{db: db}
+	return &ProductStore{db: db, now: time.Now}
 }
 
-// InitDB sets up a new SQLite database and creates the products table
+// schema holds the statements InitDB runs, in order
+var schema = []string{
+	// The products table has columns: id, name, price, quantity, category, sku, gtin
+	"CREATE TABLE IF NOT EXISTS products (id INTEGER PRIMARY KEY, name TEXT, price REAL, quantity INTEGER, category TEXT, sku TEXT, gtin TEXT)",
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products(sku)",
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_products_gtin ON products(gtin)",
+
+	// Price history, filled by triggers so every way of changing a price is recorded
+	"CREATE TABLE IF NOT EXISTS price_history (id INTEGER PRIMARY KEY, product_id INTEGER NOT NULL, price REAL NOT NULL, changed_at TIMESTAMP NOT NULL)",
+	"CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history(product_id)",
+	`CREATE TRIGGER IF NOT EXISTS products_price_insert AFTER INSERT ON products BEGIN
+		INSERT INTO price_history (product_id, price, changed_at) VALUES (NEW.id, NEW.price, CURRENT_TIMESTAMP);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_price_update AFTER UPDATE OF price ON products WHEN OLD.price IS NOT NEW.price BEGIN
+		INSERT INTO price_history (product_id, price, changed_at) VALUES (NEW.id, NEW.price, CURRENT_TIMESTAMP);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_price_delete AFTER DELETE ON products BEGIN
+		DELETE FROM price_history WHERE product_id = OLD.id;
+	END`,
+
+	// Translations, see i18n.go. The base name in products is the DefaultLocale name.
+	"CREATE TABLE IF NOT EXISTS product_translations (product_id INTEGER NOT NULL REFERENCES products(id), locale TEXT NOT NULL, name TEXT NOT NULL, description TEXT NOT NULL DEFAULT '', PRIMARY KEY (product_id, locale))",
+	`CREATE TRIGGER IF NOT EXISTS products_translations_delete AFTER DELETE ON products BEGIN
+		DELETE FROM product_translations WHERE product_id = OLD.id;
+	END`,
+
+	// Purchasing, see purchasing.go
+	"CREATE TABLE IF NOT EXISTS suppliers (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, contact TEXT)",
+	"CREATE TABLE IF NOT EXISTS purchase_orders (id INTEGER PRIMARY KEY, supplier_id INTEGER NOT NULL REFERENCES suppliers(id), status TEXT NOT NULL, created_at TIMESTAMP NOT NULL)",
+	"CREATE TABLE IF NOT EXISTS purchase_order_lines (id INTEGER PRIMARY KEY, order_id INTEGER NOT NULL REFERENCES purchase_orders(id), product_id INTEGER NOT NULL REFERENCES products(id), quantity_ordered INTEGER NOT NULL, quantity_received INTEGER NOT NULL DEFAULT 0, unit_cost REAL NOT NULL)",
+	"CREATE TABLE IF NOT EXISTS receipts (id INTEGER PRIMARY KEY, line_id INTEGER NOT NULL REFERENCES purchase_order_lines(id), product_id INTEGER NOT NULL REFERENCES products(id), quantity INTEGER NOT NULL, unit_cost REAL NOT NULL, received_at TIMESTAMP NOT NULL)",
+	"CREATE INDEX IF NOT EXISTS idx_receipts_product ON receipts(product_id)",
+
+	// Audit log of all changes, see audit.go
+	"CREATE TABLE IF NOT EXISTS audit_log (id INTEGER PRIMARY KEY, actor TEXT NOT NULL, role TEXT NOT NULL, action TEXT NOT NULL, product_id INTEGER, before TEXT, after TEXT, created_at TIMESTAMP NOT NULL)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_log_product ON audit_log(product_id)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor)",
+}
+
+// addedColumns lists the columns added to tables after their CREATE TABLE
+// statement was first released. CREATE TABLE IF NOT EXISTS leaves existing
+// tables alone, so InitDB adds these to older databases itself.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"products", "sku", "TEXT"},
+	{"products", "gtin", "TEXT"},
+}
+
+// InitDB sets up a new SQLite database and creates the tables
 func InitDB(dbPath string) (*sql.DB, error) {
 	// Open a SQLite database connection
 	db, err := sql.Open("sqlite3", dbPath)
@@ -34,99 +130,173 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	// Create the products table if it doesn't exist
-	// The table should have columns: id, name, price, quantity, category
-	_, err = db.Exec("CREATE TABLE IF NOT EXISTS products (id INTEGER PRIMARY KEY, name TEXT, price REAL, quantity INTEGER, category TEXT)")
-	if err != nil {
+	// Bring tables created by older versions up to date before the indexes
+	// on the new columns are created
+	if err := addMissingColumns(db); err != nil {
 		db.Close()
 		return nil, err
 	}
+
+	// Create the tables if they don't exist
+	for _, stmt := range schema {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
 	return db, nil
 }
 
-// CreateProduct adds a new product to the database
-func (ps *ProductStore) CreateProduct(product *Product) error {
-	// Insert the product into the database
-	result, err := ps.db.Exec(
-		"INSERT INTO products (name, price, quantity, category) VALUES (?, ?, ?, ?)",
-		product.Name, product.Price, product.Quantity, product.Category)
-	if err != nil {
-		return err
+// addMissingColumns adds the columns of addedColumns that existing tables
+// lack. Tables that don't exist yet are created complete by schema.
+func addMissingColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		columns, err := tableColumns(db, c.table)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 || columns[c.column] {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("adding column %s.%s: %w", c.table, c.column, err)
+		}
 	}
+	return nil
+}
 
-	// Update the product.ID with the database-generated ID
-	id, err := result.LastInsertId()
+// tableColumns returns the column names of a table, or none if it doesn't exist
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// CreateProduct adds a new product to the database
+func (ps *ProductStore) CreateProduct(actor Actor, product *Product) error {
+	if err := authorize(actor, PermCreateProducts); err != nil {
 		return err
 	}
-	product.ID = id
-	return nil
+	if err := product.normalizeCodes(); err != nil {
+		return err
+	}
+
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		// Insert the product into the database
+		result, err := tx.Exec(
+			"INSERT INTO products (name, price, quantity, category, sku, gtin) VALUES (?, ?, ?, ?, ?, ?)",
+			product.Name, product.Price, product.Quantity, product.Category, nullString(product.SKU), nullString(product.GTIN))
+		if err != nil {
+			return duplicateError(product, err)
+		}
+
+		// Update the product.ID with the database-generated ID
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		product.ID = id
+		return tx.record(ActionCreateProduct, id, nil, product)
+	})
 }
 
 // GetProduct retrieves a product by ID
 func (ps *ProductStore) GetProduct(id int64) (*Product, error) {
+	return findProduct(ps.db, id)
+}
+
+func findProduct(db queryRower, id int64) (*Product, error) {
 	// Query the database for a product with the given ID
-	row := ps.db.QueryRow("SELECT id, name, price, quantity, category FROM products WHERE id = ?", id)
+	row := db.QueryRow("SELECT "+productColumns+" FROM products WHERE id = ?", id)
 
-	p := &Product{}
-	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Quantity, &p.Category)
+	p, err := scanProduct(row)
 	// Return a Product struct populated with the data or an error if not found
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product with ID %d not found", id)
+			return nil, fmt.Errorf("product with ID %d %w", id, ErrNotFound)
 		}
 		return nil, err
 	}
 	return p, nil
 }
 
-// UpdateProduct updates an existing product
-func (ps *ProductStore) UpdateProduct(product *Product) error {
-	// Update the product in the database
-	result, err := ps.db.Exec(
-		"UPDATE products SET name = ?, price = ?, quantity = ?, category = ? WHERE id = ?",
-		product.Name,
-		product.Price,
-		product.Quantity,
-		product.Category,
-		product.ID,
-	)
-	// Return an error if the product doesn't exist
-	if err != nil {
+// UpdateProduct updates an existing product. The actor needs a permission for
+// each kind of field changed: details, price or quantity. Updates that change
+// nothing are neither made nor recorded.
+func (ps *ProductStore) UpdateProduct(actor Actor, product *Product) error {
+	if err := authorize(actor, PermViewProducts); err != nil {
 		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := product.normalizeCodes(); err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		// 找不到 row 时不会报错，需要创建一个新的自定义错误
-		return fmt.Errorf("product with ID %d not found", product.ID)
-	}
-	return nil
+
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		// Return an error if the product doesn't exist
+		before, err := findProduct(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		perms := changePermissions(before, product)
+		if len(perms) == 0 {
+			return nil
+		}
+		if err := authorize(actor, perms...); err != nil {
+			return err
+		}
+
+		// Update the product in the database
+		_, err = tx.Exec(
+			"UPDATE products SET name = ?, price = ?, quantity = ?, category = ?, sku = ?, gtin = ? WHERE id = ?",
+			product.Name,
+			product.Price,
+			product.Quantity,
+			product.Category,
+			nullString(product.SKU),
+			nullString(product.GTIN),
+			product.ID,
+		)
+		if err != nil {
+			return duplicateError(product, err)
+		}
+		return tx.record(ActionUpdateProduct, product.ID, before, product)
+	})
 }
 
 // DeleteProduct removes a product by ID
-func (ps *ProductStore) DeleteProduct(id int64) error {
-	// Delete the product from the database
-	result, err := ps.db.Exec(
-		"DELETE FROM products WHERE id = ?",
-		id,
-	)
-	// Return an error if the product doesn't exist
-	if err != nil {
+func (ps *ProductStore) DeleteProduct(actor Actor, id int64) error {
+	if err := authorize(actor, PermDeleteProducts); err != nil {
 		return err
 	}
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		// Return an error if the product doesn't exist
+		before, err := findProduct(tx, id)
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("product with ID %d not found", id)
-	}
-	return nil
+		// Delete the product from the database
+		if _, err := tx.Exec("DELETE FROM products WHERE id = ?", id); err != nil {
+			return err
+		}
+		return tx.record(ActionDeleteProduct, id, before, nil)
+	})
 }
 
 // ListProducts returns all products with optional filtering by category
@@ -137,7 +307,7 @@ func (ps *ProductStore) ListProducts(category string) ([]*Product, error) {
 	var err error
 	if category != "" {
 		rows, err = ps.db.Query(
-			"SELECT id, name, price, quantity, category FROM products WHERE category = ?",
+			"SELECT "+productColumns+" FROM products WHERE category = ?",
 			category,
 		)
 		if err != nil {
@@ -146,7 +316,7 @@ func (ps *ProductStore) ListProducts(category string) ([]*Product, error) {
 	} else if category == "" {
 		// If category is empty, return all products
 		rows, err = ps.db.Query(
-			"SELECT id, name, price, quantity, category FROM products",
+			"SELECT " + productColumns + " FROM products",
 		)
 		if err != nil {
 			return nil, err
@@ -155,8 +325,7 @@ func (ps *ProductStore) ListProducts(category string) ([]*Product, error) {
 	defer rows.Close()
 	res := []*Product{}
 	for rows.Next() {
-		p := &Product{}
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Quantity, &p.Category)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -170,44 +339,45 @@ func (ps *ProductStore) ListProducts(category string) ([]*Product, error) {
 	return res, nil
 }
 
-// BatchUpdateInventory updates the quantity of multiple products in a single transaction
-func (ps *ProductStore) BatchUpdateInventory(updates map[int64]int) error {
-	// Start a transaction
-	tx, err := ps.db.Begin()
-	if err != nil {
+// BatchUpdateInventory updates the quantity of multiple products in a single
+// transaction, recording one audit entry per product
+func (ps *ProductStore) BatchUpdateInventory(actor Actor, updates map[int64]int) error {
+	if err := authorize(actor, PermAdjustStock); err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-	// For each product ID in the updates map, update its quantity
-	stmt, err := tx.Prepare(
-		"UPDATE products SET quantity = ? WHERE id = ?",
-	)
-	if err != nil {
-		return err
+	// Update in ID order so the audit entries are in a stable order too
+	ids := make([]int64, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
 	}
-	defer stmt.Close()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-	for id, quantity := range updates {
-		result, err := stmt.Exec(quantity, id)
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		stmt, err := tx.Prepare(
+			"UPDATE products SET quantity = ? WHERE id = ?",
+		)
 		if err != nil {
 			return err
 		}
+		defer stmt.Close()
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			return err
-		}
-		// If any update fails, roll back the transaction
-		if rowsAffected == 0 {
-			return fmt.Errorf("product with ID %d not found", id)
+		for _, id := range ids {
+			// If any product doesn't exist, the whole transaction is rolled back
+			before, err := findProduct(tx, id)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.Exec(updates[id], id); err != nil {
+				return err
+			}
+			after := *before
+			after.Quantity = updates[id]
+			if err := tx.record(ActionAdjustStock, id, before, &after); err != nil {
+				return err
+			}
 		}
-	}
-	// Otherwise, commit the transaction
-	return tx.Commit()
+		return nil
+	})
 }
 
 func main() {
@@ -60,7 +60,7 @@ func TestCreateProduct(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			product := tc.product
 
-			err := store.CreateProduct(&product)
+			err := store.CreateProduct(testAdmin, &product)
 			if err != nil {
 				t.Fatalf("Failed to create product: %v", err)
 			}
@@ -100,7 +100,7 @@ func TestGetProduct(t *testing.T) {
 		Quantity: 100,
 		Category: "Test",
 	}
-	err := store.CreateProduct(product)
+	err := store.CreateProduct(testAdmin, product)
 	if err != nil {
 		t.Fatalf("Failed to create test product: %v", err)
 	}
@@ -161,7 +161,7 @@ func TestUpdateProduct(t *testing.T) {
 		Quantity: 100,
 		Category: "Test",
 	}
-	err := store.CreateProduct(product)
+	err := store.CreateProduct(testAdmin, product)
 	if err != nil {
 		t.Fatalf("Failed to create test product: %v", err)
 	}
@@ -171,7 +171,7 @@ func TestUpdateProduct(t *testing.T) {
 	product.Price = 19.99
 	product.Quantity = 50
 
-	err = store.UpdateProduct(product)
+	err = store.UpdateProduct(testAdmin, product)
 	if err != nil {
 		t.Fatalf("Failed to update product: %v", err)
 	}
@@ -209,13 +209,13 @@ func TestDeleteProduct(t *testing.T) {
 		Quantity: 100,
 		Category: "Test",
 	}
-	err := store.CreateProduct(product)
+	err := store.CreateProduct(testAdmin, product)
 	if err != nil {
 		t.Fatalf("Failed to create test product: %v", err)
 	}
 
 	// Delete the product
-	err = store.DeleteProduct(product.ID)
+	err = store.DeleteProduct(testAdmin, product.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete product: %v", err)
 	}
@@ -243,7 +243,7 @@ func TestListProducts(t *testing.T) {
 	}
 
 	for i := range productsToCreate {
-		err := store.CreateProduct(&productsToCreate[i])
+		err := store.CreateProduct(testAdmin, &productsToCreate[i])
 		if err != nil {
 			t.Fatalf("Failed to create test product: %v", err)
 		}
@@ -312,7 +312,7 @@ func TestBatchUpdateInventory(t *testing.T) {
 	}
 
 	for i := range products {
-		err := store.CreateProduct(&products[i])
+		err := store.CreateProduct(testAdmin, &products[i])
 		if err != nil {
 			t.Fatalf("Failed to create test product: %v", err)
 		}
@@ -325,7 +325,7 @@ func TestBatchUpdateInventory(t *testing.T) {
 	}
 
 	// Perform batch update
-	err := store.BatchUpdateInventory(updates)
+	err := store.BatchUpdateInventory(testAdmin, updates)
 	if err != nil {
 		t.Fatalf("Failed to perform batch update: %v", err)
 	}
@@ -362,7 +362,7 @@ func TestBatchUpdateInventoryRollback(t *testing.T) {
 		Quantity: 10,
 		Category: "Test",
 	}
-	err := store.CreateProduct(product)
+	err := store.CreateProduct(testAdmin, product)
 	if err != nil {
 		t.Fatalf("Failed to create test product: %v", err)
 	}
@@ -374,7 +374,7 @@ func TestBatchUpdateInventoryRollback(t *testing.T) {
 	}
 
 	// This should fail and roll back
-	err = store.BatchUpdateInventory(updates)
+	err = store.BatchUpdateInventory(testAdmin, updates)
 	if err == nil {
 		t.Fatalf("Expected error for non-existent product, got nil")
 	}
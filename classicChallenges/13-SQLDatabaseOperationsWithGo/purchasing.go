@@ -0,0 +1,388 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Purchase order statuses
+const (
+	OrderStatusOpen              = "open"
+	OrderStatusPartiallyReceived = "partially_received"
+	OrderStatusReceived          = "received"
+)
+
+// Supplier represents a company products are purchased from
+type Supplier struct {
+	ID      int64
+	Name    string
+	Contact string
+}
+
+// PurchaseOrder represents an order of products from a supplier
+type PurchaseOrder struct {
+	ID         int64
+	SupplierID int64
+	Status     string
+	CreatedAt  time.Time
+	Lines      []*PurchaseOrderLine
+}
+
+// PurchaseOrderLine is a single product on a purchase order
+type PurchaseOrderLine struct {
+	ID               int64
+	OrderID          int64
+	ProductID        int64
+	QuantityOrdered  int
+	QuantityReceived int
+	UnitCost         float64
+}
+
+// Receipt records goods received against a purchase order line
+type Receipt struct {
+	ID         int64
+	LineID     int64
+	ProductID  int64
+	Quantity   int
+	UnitCost   float64
+	ReceivedAt time.Time
+}
+
+// CreateSupplier adds a new supplier to the database
+func (ps *ProductStore) CreateSupplier(actor Actor, supplier *Supplier) error {
+	if err := authorize(actor, PermPurchasing); err != nil {
+		return err
+	}
+	if supplier.Name == "" {
+		return errors.New("supplier name cannot be empty")
+	}
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		result, err := tx.Exec(
+			"INSERT INTO suppliers (name, contact) VALUES (?, ?)",
+			supplier.Name, supplier.Contact)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		supplier.ID = id
+		return tx.record(ActionCreateSupplier, 0, nil, supplier)
+	})
+}
+
+// GetSupplier retrieves a supplier by ID
+func (ps *ProductStore) GetSupplier(id int64) (*Supplier, error) {
+	row := ps.db.QueryRow("SELECT id, name, contact FROM suppliers WHERE id = ?", id)
+
+	s := &Supplier{}
+	err := row.Scan(&s.ID, &s.Name, &s.Contact)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("supplier with ID %d %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// CreatePurchaseOrder stores an order and its lines in a single transaction.
+// The order starts in the open status and nothing is received yet.
+func (ps *ProductStore) CreatePurchaseOrder(actor Actor, order *PurchaseOrder) error {
+	if err := authorize(actor, PermPurchasing); err != nil {
+		return err
+	}
+	if len(order.Lines) == 0 {
+		return errors.New("purchase order must have at least one line")
+	}
+	for _, line := range order.Lines {
+		if line.QuantityOrdered <= 0 {
+			return fmt.Errorf("ordered quantity for product %d must be positive", line.ProductID)
+		}
+		if line.UnitCost < 0 {
+			return fmt.Errorf("unit cost for product %d cannot be negative", line.ProductID)
+		}
+	}
+
+	// stored is the order as written, so the caller's struct is only updated
+	// once everything is committed
+	stored := &PurchaseOrder{SupplierID: order.SupplierID, Status: OrderStatusOpen}
+	err := ps.writeAudited(actor, func(tx *auditTx) error {
+		stored.CreatedAt = tx.now
+		exists, err := rowExists(tx, "SELECT 1 FROM suppliers WHERE id = ?", order.SupplierID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("supplier with ID %d %w", order.SupplierID, ErrNotFound)
+		}
+
+		result, err := tx.Exec(
+			"INSERT INTO purchase_orders (supplier_id, status, created_at) VALUES (?, ?, ?)",
+			stored.SupplierID, stored.Status, stored.CreatedAt)
+		if err != nil {
+			return err
+		}
+		stored.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		stmt, err := tx.Prepare(
+			"INSERT INTO purchase_order_lines (order_id, product_id, quantity_ordered, quantity_received, unit_cost) VALUES (?, ?, ?, 0, ?)",
+		)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, line := range order.Lines {
+			exists, err := rowExists(tx, "SELECT 1 FROM products WHERE id = ?", line.ProductID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("product with ID %d %w", line.ProductID, ErrNotFound)
+			}
+			result, err := stmt.Exec(stored.ID, line.ProductID, line.QuantityOrdered, line.UnitCost)
+			if err != nil {
+				return err
+			}
+			lineID, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			stored.Lines = append(stored.Lines, &PurchaseOrderLine{
+				ID: lineID, OrderID: stored.ID, ProductID: line.ProductID,
+				QuantityOrdered: line.QuantityOrdered, UnitCost: line.UnitCost,
+			})
+		}
+		return tx.record(ActionCreatePurchaseOrder, 0, nil, stored)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.ID = stored.ID
+	order.Status = stored.Status
+	order.CreatedAt = stored.CreatedAt
+	for i, line := range order.Lines {
+		line.ID = stored.Lines[i].ID
+		line.OrderID = stored.ID
+		line.QuantityReceived = 0
+	}
+	return nil
+}
+
+// GetPurchaseOrder retrieves a purchase order with its lines
+func (ps *ProductStore) GetPurchaseOrder(id int64) (*PurchaseOrder, error) {
+	row := ps.db.QueryRow("SELECT id, supplier_id, status, created_at FROM purchase_orders WHERE id = ?", id)
+
+	order := &PurchaseOrder{}
+	err := row.Scan(&order.ID, &order.SupplierID, &order.Status, &order.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("purchase order with ID %d %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+
+	rows, err := ps.db.Query(
+		"SELECT id, order_id, product_id, quantity_ordered, quantity_received, unit_cost FROM purchase_order_lines WHERE order_id = ? ORDER BY id",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l := &PurchaseOrderLine{}
+		err := rows.Scan(&l.ID, &l.OrderID, &l.ProductID, &l.QuantityOrdered, &l.QuantityReceived, &l.UnitCost)
+		if err != nil {
+			return nil, err
+		}
+		order.Lines = append(order.Lines, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ReceivePurchaseOrder books received goods against the lines of an order.
+// received maps a line ID to the quantity that arrived. Partial deliveries are
+// allowed, receiving more than was ordered is not. Product quantities, line
+// progress, receipts and the order status are all updated in one transaction,
+// recording the stock change of every product received.
+func (ps *ProductStore) ReceivePurchaseOrder(actor Actor, orderID int64, received map[int64]int) error {
+	if err := authorize(actor, PermReceiveGoods); err != nil {
+		return err
+	}
+	if len(received) == 0 {
+		return errors.New("nothing to receive")
+	}
+
+	return ps.writeAudited(actor, func(tx *auditTx) error {
+		var status string
+		err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = ?", orderID).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("purchase order with ID %d %w", orderID, ErrNotFound)
+			}
+			return err
+		}
+		if status == OrderStatusReceived {
+			return fmt.Errorf("purchase order with ID %d is already fully received", orderID)
+		}
+
+		// Process lines in a stable order so failures are reproducible
+		lineIDs := make([]int64, 0, len(received))
+		for lineID := range received {
+			lineIDs = append(lineIDs, lineID)
+		}
+		sort.Slice(lineIDs, func(i, j int) bool { return lineIDs[i] < lineIDs[j] })
+
+		// before holds the products as they were before the first line received for them
+		before := make(map[int64]*Product)
+		var productIDs []int64
+		receivedAt := tx.now
+		for _, lineID := range lineIDs {
+			quantity := received[lineID]
+			if quantity <= 0 {
+				return fmt.Errorf("received quantity for line %d must be positive", lineID)
+			}
+
+			var productID int64
+			var ordered, alreadyReceived int
+			var unitCost float64
+			err = tx.QueryRow(
+				"SELECT product_id, quantity_ordered, quantity_received, unit_cost FROM purchase_order_lines WHERE id = ? AND order_id = ?",
+				lineID, orderID,
+			).Scan(&productID, &ordered, &alreadyReceived, &unitCost)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return fmt.Errorf("line %d %w on purchase order %d", lineID, ErrNotFound, orderID)
+				}
+				return err
+			}
+			if alreadyReceived+quantity > ordered {
+				return fmt.Errorf("line %d: receiving %d would exceed the ordered quantity %d (already received %d)",
+					lineID, quantity, ordered, alreadyReceived)
+			}
+
+			if before[productID] == nil {
+				p, err := findProduct(tx, productID)
+				if err != nil {
+					return err
+				}
+				before[productID] = p
+				productIDs = append(productIDs, productID)
+			}
+
+			if _, err = tx.Exec(
+				"UPDATE purchase_order_lines SET quantity_received = quantity_received + ? WHERE id = ?",
+				quantity, lineID,
+			); err != nil {
+				return err
+			}
+			if _, err = tx.Exec("UPDATE products SET quantity = quantity + ? WHERE id = ?", quantity, productID); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(
+				"INSERT INTO receipts (line_id, product_id, quantity, unit_cost, received_at) VALUES (?, ?, ?, ?, ?)",
+				lineID, productID, quantity, unitCost, receivedAt,
+			); err != nil {
+				return err
+			}
+		}
+
+		var totalOrdered, totalReceived int
+		err = tx.QueryRow(
+			"SELECT SUM(quantity_ordered), SUM(quantity_received) FROM purchase_order_lines WHERE order_id = ?",
+			orderID,
+		).Scan(&totalOrdered, &totalReceived)
+		if err != nil {
+			return err
+		}
+		status = OrderStatusPartiallyReceived
+		if totalReceived >= totalOrdered {
+			status = OrderStatusReceived
+		}
+		if _, err = tx.Exec("UPDATE purchase_orders SET status = ? WHERE id = ?", status, orderID); err != nil {
+			return err
+		}
+
+		sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+		for _, id := range productIDs {
+			after, err := findProduct(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := tx.record(ActionReceiveGoods, id, before[id], after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListReceipts returns all receipts of a product, oldest first
+func (ps *ProductStore) ListReceipts(productID int64) ([]*Receipt, error) {
+	rows, err := ps.db.Query(
+		"SELECT id, line_id, product_id, quantity, unit_cost, received_at FROM receipts WHERE product_id = ? ORDER BY id",
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []*Receipt{}
+	for rows.Next() {
+		r := &Receipt{}
+		err := rows.Scan(&r.ID, &r.LineID, &r.ProductID, &r.Quantity, &r.UnitCost, &r.ReceivedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// WeightedAverageCost returns the average unit cost of a product over all its
+// receipts, weighted by the received quantity
+func (ps *ProductStore) WeightedAverageCost(productID int64) (float64, error) {
+	var totalCost float64
+	var totalQuantity int
+	err := ps.db.QueryRow(
+		"SELECT COALESCE(SUM(quantity * unit_cost), 0), COALESCE(SUM(quantity), 0) FROM receipts WHERE product_id = ?",
+		productID,
+	).Scan(&totalCost, &totalQuantity)
+	if err != nil {
+		return 0, err
+	}
+	if totalQuantity == 0 {
+		return 0, fmt.Errorf("product with ID %d has no receipts", productID)
+	}
+	return totalCost / float64(totalQuantity), nil
+}
+
+// rowExists reports whether the query yields at least one row
+func rowExists(db queryRower, query string, args ...interface{}) (bool, error) {
+	var one int
+	err := db.QueryRow(query, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
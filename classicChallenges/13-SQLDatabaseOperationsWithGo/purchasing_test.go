@@ -0,0 +1,221 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func setupPurchasing(t *testing.T, store *ProductStore) (*Supplier, *Product, *Product) {
+	t.Helper()
+
+	supplier := &Supplier{Name: "Acme", Contact: "sales@acme.test"}
+	if err := store.CreateSupplier(testAdmin, supplier); err != nil {
+		t.Fatalf("Failed to create supplier: %v", err)
+	}
+
+	p1 := &Product{Name: "Widget", Price: 10, Quantity: 5, Category: "Parts"}
+	p2 := &Product{Name: "Gadget", Price: 20, Quantity: 0, Category: "Parts"}
+	for _, p := range []*Product{p1, p2} {
+		if err := store.CreateProduct(testAdmin, p); err != nil {
+			t.Fatalf("Failed to create test product: %v", err)
+		}
+	}
+	return supplier, p1, p2
+}
+
+func TestCreatePurchaseOrder(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+	supplier, p1, p2 := setupPurchasing(t, store)
+
+	testCases := []struct {
+		name        string
+		order       PurchaseOrder
+		expectError bool
+	}{
+		{
+			name: "Valid order",
+			order: PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{
+				{ProductID: p1.ID, QuantityOrdered: 10, UnitCost: 4},
+				{ProductID: p2.ID, QuantityOrdered: 3, UnitCost: 12.5},
+			}},
+		},
+		{
+			name:        "No lines",
+			order:       PurchaseOrder{SupplierID: supplier.ID},
+			expectError: true,
+		},
+		{
+			name: "Unknown supplier",
+			order: PurchaseOrder{SupplierID: supplier.ID + 100, Lines: []*PurchaseOrderLine{
+				{ProductID: p1.ID, QuantityOrdered: 10, UnitCost: 4},
+			}},
+			expectError: true,
+		},
+		{
+			name: "Unknown product",
+			order: PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{
+				{ProductID: p1.ID, QuantityOrdered: 10, UnitCost: 4},
+				{ProductID: p2.ID + 100, QuantityOrdered: 1, UnitCost: 4},
+			}},
+			expectError: true,
+		},
+		{
+			name: "Zero quantity",
+			order: PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{
+				{ProductID: p1.ID, QuantityOrdered: 0, UnitCost: 4},
+			}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			order := tc.order
+			err := store.CreatePurchaseOrder(testAdmin, &order)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error creating purchase order, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create purchase order: %v", err)
+			}
+
+			retrieved, err := store.GetPurchaseOrder(order.ID)
+			if err != nil {
+				t.Fatalf("Failed to retrieve purchase order: %v", err)
+			}
+			if retrieved.Status != OrderStatusOpen {
+				t.Errorf("Expected status %s, got %s", OrderStatusOpen, retrieved.Status)
+			}
+			if len(retrieved.Lines) != len(order.Lines) {
+				t.Fatalf("Expected %d lines, got %d", len(order.Lines), len(retrieved.Lines))
+			}
+			for i, line := range retrieved.Lines {
+				if line.ID != order.Lines[i].ID || line.ProductID != order.Lines[i].ProductID {
+					t.Errorf("Line %d does not match: expected %+v, got %+v", i, order.Lines[i], line)
+				}
+			}
+		})
+	}
+
+	// Failed orders must not leave rows behind
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM purchase_orders").Scan(&count); err != nil {
+		t.Fatalf("Failed to count purchase orders: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 purchase order after rollbacks, got %d", count)
+	}
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+	supplier, p1, p2 := setupPurchasing(t, store)
+
+	order := &PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{
+		{ProductID: p1.ID, QuantityOrdered: 10, UnitCost: 4},
+		{ProductID: p2.ID, QuantityOrdered: 3, UnitCost: 12.5},
+	}}
+	if err := store.CreatePurchaseOrder(testAdmin, order); err != nil {
+		t.Fatalf("Failed to create purchase order: %v", err)
+	}
+	line1, line2 := order.Lines[0].ID, order.Lines[1].ID
+
+	// Partial receipt
+	if err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{line1: 4}); err != nil {
+		t.Fatalf("Failed to receive purchase order: %v", err)
+	}
+	retrieved, _ := store.GetPurchaseOrder(order.ID)
+	if retrieved.Status != OrderStatusPartiallyReceived {
+		t.Errorf("Expected status %s, got %s", OrderStatusPartiallyReceived, retrieved.Status)
+	}
+	product, _ := store.GetProduct(p1.ID)
+	if product.Quantity != 9 {
+		t.Errorf("Expected quantity 9 after partial receipt, got %d", product.Quantity)
+	}
+
+	// Over-receiving rolls back the whole receipt
+	err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{line1: 1, line2: 4})
+	if err == nil {
+		t.Fatalf("Expected error when receiving more than ordered, got nil")
+	}
+	product, _ = store.GetProduct(p1.ID)
+	if product.Quantity != 9 {
+		t.Errorf("Expected quantity to remain 9 after rollback, got %d", product.Quantity)
+	}
+
+	// Receive the rest
+	if err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{line1: 6, line2: 3}); err != nil {
+		t.Fatalf("Failed to receive purchase order: %v", err)
+	}
+	retrieved, _ = store.GetPurchaseOrder(order.ID)
+	if retrieved.Status != OrderStatusReceived {
+		t.Errorf("Expected status %s, got %s", OrderStatusReceived, retrieved.Status)
+	}
+	product, _ = store.GetProduct(p2.ID)
+	if product.Quantity != 3 {
+		t.Errorf("Expected quantity 3 for product 2, got %d", product.Quantity)
+	}
+
+	if err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{line1: 1}); err == nil {
+		t.Errorf("Expected error receiving a fully received order, got nil")
+	}
+
+	receipts, err := store.ListReceipts(p1.ID)
+	if err != nil {
+		t.Fatalf("Failed to list receipts: %v", err)
+	}
+	if len(receipts) != 2 {
+		t.Errorf("Expected 2 receipts for product 1, got %d", len(receipts))
+	}
+}
+
+func TestWeightedAverageCost(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB()
+
+	store := NewProductStore(db)
+	supplier, p1, p2 := setupPurchasing(t, store)
+
+	if _, err := store.WeightedAverageCost(p1.ID); err == nil {
+		t.Errorf("Expected error for product without receipts, got nil")
+	}
+
+	orders := []*PurchaseOrder{
+		{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{{ProductID: p1.ID, QuantityOrdered: 10, UnitCost: 4}}},
+		{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{{ProductID: p1.ID, QuantityOrdered: 30, UnitCost: 6}}},
+	}
+	for _, order := range orders {
+		if err := store.CreatePurchaseOrder(testAdmin, order); err != nil {
+			t.Fatalf("Failed to create purchase order: %v", err)
+		}
+		if err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{order.Lines[0].ID: order.Lines[0].QuantityOrdered}); err != nil {
+			t.Fatalf("Failed to receive purchase order: %v", err)
+		}
+	}
+
+	cost, err := store.WeightedAverageCost(p1.ID)
+	if err != nil {
+		t.Fatalf("Failed to compute weighted average cost: %v", err)
+	}
+	// (10*4 + 30*6) / 40 = 5.5
+	if math.Abs(cost-5.5) > 1e-9 {
+		t.Errorf("Expected weighted average cost 5.5, got %f", cost)
+	}
+
+	if _, err := store.WeightedAverageCost(p2.ID); err == nil {
+		t.Errorf("Expected error for product 2 without receipts, got nil")
+	}
+}
@@ -0,0 +1,855 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file implements a small GraphQL-like query language for products.
+//
+//	{
+//	  products(category: "Books") {
+//	    id name price
+//	    category { name productCount }
+//	    stock { quantity inStock onOrder }
+//	    priceHistory(limit: 3) { price changedAt }
+//	  }
+//	  product(sku: "MUG-1") { id name }
+//	}
+//
+// Only the selected fields are returned, in the order they were selected.
+// Relations are loaded with one query per relation for all products at once,
+// so a list of N products never costs N extra queries. Very long lists are
+// split into chunks of maxInValues products per query.
+
+// QueryError is returned for queries that are malformed or don't match the schema
+type QueryError struct {
+	Pos     int // byte offset in the query
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query error at offset %d: %s", e.Pos, e.Message)
+}
+
+//
+// Parsing
+//
+
+// selection is one field of a query with its arguments and sub-fields
+type selection struct {
+	name       string
+	args       map[string]interface{}
+	selections []*selection
+	pos        int
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenString
+	tokenNumber
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+type queryParser struct {
+	src string
+	pos int
+	tok token
+}
+
+// parseQuery parses a query document. The outer braces are optional.
+func parseQuery(src string) ([]*selection, error) {
+	p := &queryParser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var selections []*selection
+	var err error
+	if p.isPunct("{") {
+		selections, err = p.parseSelectionSet()
+	} else {
+		selections, err = p.parseSelections()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q after the query", p.tok.text)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("query selects no fields")
+	}
+	return selections, nil
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return &QueryError{Pos: p.tok.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) isPunct(s string) bool {
+	return p.tok.kind == tokenPunct && p.tok.text == s
+}
+
+func (p *queryParser) expect(s string) error {
+	if !p.isPunct(s) {
+		return p.errorf("expected %q, got %q", s, p.tok.text)
+	}
+	return p.next()
+}
+
+// parseSelectionSet parses '{' selections '}'
+func (p *queryParser) parseSelectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelections()
+	if err != nil {
+		return nil, err
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return selections, nil
+}
+
+// parseSelections parses fields until something that can't start a field
+func (p *queryParser) parseSelections() ([]*selection, error) {
+	var selections []*selection
+	for p.tok.kind == tokenName {
+		sel := &selection{name: p.tok.text, pos: p.tok.pos}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.isPunct("(") {
+			args, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			sel.args = args
+		}
+		if p.isPunct("{") {
+			subs, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			sel.selections = subs
+		}
+		selections = append(selections, sel)
+		if p.isPunct(",") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return selections, nil
+}
+
+// parseArguments parses '(' name ':' value (',' name ':' value)* ')'
+func (p *queryParser) parseArguments() (map[string]interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := make(map[string]interface{})
+	for !p.isPunct(")") {
+		if p.tok.kind != tokenName {
+			return nil, p.errorf("expected argument name, got %q", p.tok.text)
+		}
+		name := p.tok.text
+		if _, ok := args[name]; ok {
+			return nil, p.errorf("argument %q given twice", name)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		switch p.tok.kind {
+		case tokenString, tokenNumber:
+			args[name] = p.tok.value
+		case tokenName:
+			switch p.tok.text {
+			case "true":
+				args[name] = true
+			case "false":
+				args[name] = false
+			case "null":
+				args[name] = nil
+			default:
+				return nil, p.errorf("unexpected value %q", p.tok.text)
+			}
+		default:
+			return nil, p.errorf("expected a value, got %q", p.tok.text)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.isPunct(",") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return args, p.next()
+}
+
+// next reads the next token into p.tok
+func (p *queryParser) next() error {
+	// Skip whitespace and comments
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokenEOF, text: "end of query", pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.IndexByte("{}():,", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, text: string(c), pos: start}
+	case isNameStart(c):
+		for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, text: p.src[start:p.pos], pos: start}
+	case c == '"':
+		var sb strings.Builder
+		p.pos++
+		for {
+			if p.pos >= len(p.src) {
+				return &QueryError{Pos: start, Message: "unterminated string"}
+			}
+			c := p.src[p.pos]
+			if c == '"' {
+				p.pos++
+				break
+			}
+			if c == '\\' && p.pos+1 < len(p.src) {
+				p.pos++
+				switch p.src[p.pos] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(p.src[p.pos])
+				}
+				p.pos++
+				continue
+			}
+			sb.WriteByte(c)
+			p.pos++
+		}
+		p.tok = token{kind: tokenString, text: p.src[start:p.pos], value: sb.String(), pos: start}
+	case c == '-' || isDigit(c):
+		p.pos++
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		text := p.src[start:p.pos]
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return &QueryError{Pos: start, Message: fmt.Sprintf("invalid number %q", text)}
+		}
+		p.tok = token{kind: tokenNumber, text: text, value: f, pos: start}
+	default:
+		return &QueryError{Pos: start, Message: fmt.Sprintf("unexpected character %q", c)}
+	}
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+//
+// Schema
+//
+
+type argKind int
+
+const (
+	argString argKind = iota
+	argInt
+	argCount // an integer of zero or more
+)
+
+// fieldType describes a field of the schema. Fields without sub-fields are scalars.
+type fieldType struct {
+	args   map[string]argKind
+	fields map[string]*fieldType
+}
+
+var (
+	scalarField = &fieldType{}
+
+	categoryType = map[string]*fieldType{
+		"name":         scalarField,
+		"productCount": scalarField,
+	}
+	stockType = map[string]*fieldType{
+		"quantity": scalarField,
+		"inStock":  scalarField,
+		"onOrder":  scalarField,
+	}
+	priceChangeType = map[string]*fieldType{
+		"price":     scalarField,
+		"changedAt": scalarField,
+	}
+	productType = map[string]*fieldType{
+		"id":           scalarField,
+		"name":         scalarField,
+		"price":        scalarField,
+		"quantity":     scalarField,
+		"sku":          scalarField,
+		"gtin":         scalarField,
+		"category":     {fields: categoryType},
+		"stock":        {fields: stockType},
+		"priceHistory": {fields: priceChangeType, args: map[string]argKind{"limit": argCount}},
+	}
+	queryType = map[string]*fieldType{
+		"product":  {fields: productType, args: map[string]argKind{"id": argInt, "sku": argString, "gtin": argString}},
+		"products": {fields: productType, args: map[string]argKind{"category": argString}},
+	}
+)
+
+// validate checks selections against the schema
+func validate(selections []*selection, fields map[string]*fieldType) error {
+	seen := make(map[string]bool)
+	for _, sel := range selections {
+		ft, ok := fields[sel.name]
+		if !ok {
+			return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("unknown field %q", sel.name)}
+		}
+		if seen[sel.name] {
+			return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("field %q selected twice", sel.name)}
+		}
+		seen[sel.name] = true
+
+		for name, value := range sel.args {
+			kind, ok := ft.args[name]
+			if !ok {
+				return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("unknown argument %q on field %q", name, sel.name)}
+			}
+			switch kind {
+			case argString:
+				if _, ok := value.(string); !ok {
+					return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("argument %q must be a string", name)}
+				}
+			case argInt, argCount:
+				f, ok := value.(float64)
+				if !ok || f != float64(int64(f)) {
+					return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("argument %q must be an integer", name)}
+				}
+				if kind == argCount && f < 0 {
+					return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("argument %q must not be negative", name)}
+				}
+			}
+		}
+
+		if ft.fields == nil && sel.selections != nil {
+			return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("scalar field %q cannot have sub-fields", sel.name)}
+		}
+		if ft.fields != nil {
+			if sel.selections == nil {
+				return &QueryError{Pos: sel.pos, Message: fmt.Sprintf("field %q needs a selection of sub-fields", sel.name)}
+			}
+			if err := validate(sel.selections, ft.fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//
+// Execution
+//
+
+// queryObject is a JSON object that keeps its fields in selection order
+type queryObject []queryField
+
+type queryField struct {
+	name  string
+	value interface{}
+}
+
+// MarshalJSON writes the fields in order
+func (o queryObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// priceChange is an entry of a product's price history
+type priceChange struct {
+	price     float64
+	changedAt time.Time
+}
+
+// queryExecutor resolves one query. queries counts the database round trips.
+type queryExecutor struct {
+	store   *ProductStore
+	queries int
+}
+
+// ExecuteQuery runs a query against the store and returns the selected data as JSON.
+// Malformed queries fail with a *QueryError.
+func (ps *ProductStore) ExecuteQuery(src string) (json.RawMessage, error) {
+	data, _, err := ps.executeQuery(src)
+	return data, err
+}
+
+// executeQuery also returns the number of database queries it needed
+func (ps *ProductStore) executeQuery(src string) (json.RawMessage, int, error) {
+	selections, err := parseQuery(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := validate(selections, queryType); err != nil {
+		return nil, 0, err
+	}
+
+	e := &queryExecutor{store: ps}
+	result := queryObject{}
+	for _, sel := range selections {
+		var value interface{}
+		switch sel.name {
+		case "product":
+			value, err = e.resolveProduct(sel)
+		case "products":
+			value, err = e.resolveProductList(sel)
+		}
+		if err != nil {
+			return nil, e.queries, err
+		}
+		result = append(result, queryField{name: sel.name, value: value})
+	}
+
+	data, err := json.Marshal(result)
+	return data, e.queries, err
+}
+
+// resolveProduct looks a single product up by id, sku or gtin. A product that
+// doesn't exist resolves to null.
+func (e *queryExecutor) resolveProduct(sel *selection) (interface{}, error) {
+	if len(sel.args) != 1 {
+		return nil, &QueryError{Pos: sel.pos, Message: "product needs exactly one of the arguments id, sku or gtin"}
+	}
+
+	var product *Product
+	var err error
+	e.queries++
+	if id, ok := sel.args["id"].(float64); ok {
+		product, err = e.store.GetProduct(int64(id))
+	} else if sku, ok := sel.args["sku"].(string); ok {
+		product, err = e.store.GetProductBySKU(sku)
+	} else if gtin, ok := sel.args["gtin"].(string); ok {
+		if _, normErr := NormalizeGTIN(gtin); normErr != nil {
+			return nil, &QueryError{Pos: sel.pos, Message: normErr.Error()}
+		}
+		product, err = e.store.GetProductByGTIN(gtin)
+	}
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	objects, err := e.resolveProducts([]*Product{product}, sel.selections)
+	if err != nil {
+		return nil, err
+	}
+	return objects[0], nil
+}
+
+// resolveProductList lists products, optionally filtered by category
+func (e *queryExecutor) resolveProductList(sel *selection) (interface{}, error) {
+	category, _ := sel.args["category"].(string)
+	e.queries++
+	products, err := e.store.ListProducts(category)
+	if err != nil {
+		return nil, err
+	}
+	return e.resolveProducts(products, sel.selections)
+}
+
+// resolveProducts builds the selected fields of every product. Each selected
+// relation is loaded once for the whole slice.
+func (e *queryExecutor) resolveProducts(products []*Product, selections []*selection) ([]queryObject, error) {
+	var categoryCounts map[string]int
+	var onOrder map[int64]int
+	var history map[int64][]priceChange
+	var err error
+
+	for _, sel := range selections {
+		switch sel.name {
+		case "category":
+			if hasSelection(sel, "productCount") {
+				if categoryCounts, err = e.loadCategoryCounts(products); err != nil {
+					return nil, err
+				}
+			}
+		case "stock":
+			if hasSelection(sel, "onOrder") {
+				if onOrder, err = e.loadOnOrder(products); err != nil {
+					return nil, err
+				}
+			}
+		case "priceHistory":
+			if history, err = e.loadPriceHistory(products); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	objects := make([]queryObject, 0, len(products))
+	for _, p := range products {
+		obj := queryObject{}
+		for _, sel := range selections {
+			var value interface{}
+			switch sel.name {
+			case "id":
+				value = p.ID
+			case "name":
+				value = p.Name
+			case "price":
+				value = p.Price
+			case "quantity":
+				value = p.Quantity
+			case "sku":
+				value = p.SKU
+			case "gtin":
+				value = p.GTIN
+			case "category":
+				value = resolveCategory(p, sel.selections, categoryCounts)
+			case "stock":
+				value = resolveStock(p, sel.selections, onOrder)
+			case "priceHistory":
+				value = resolvePriceHistory(history[p.ID], sel)
+			}
+			obj = append(obj, queryField{name: sel.name, value: value})
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func resolveCategory(p *Product, selections []*selection, counts map[string]int) queryObject {
+	obj := queryObject{}
+	for _, sel := range selections {
+		switch sel.name {
+		case "name":
+			obj = append(obj, queryField{name: sel.name, value: p.Category})
+		case "productCount":
+			obj = append(obj, queryField{name: sel.name, value: counts[p.Category]})
+		}
+	}
+	return obj
+}
+
+func resolveStock(p *Product, selections []*selection, onOrder map[int64]int) queryObject {
+	obj := queryObject{}
+	for _, sel := range selections {
+		switch sel.name {
+		case "quantity":
+			obj = append(obj, queryField{name: sel.name, value: p.Quantity})
+		case "inStock":
+			obj = append(obj, queryField{name: sel.name, value: p.Quantity > 0})
+		case "onOrder":
+			obj = append(obj, queryField{name: sel.name, value: onOrder[p.ID]})
+		}
+	}
+	return obj
+}
+
+// resolvePriceHistory returns the newest changes first, limited by the limit argument
+func resolvePriceHistory(changes []priceChange, sel *selection) []queryObject {
+	if limit, ok := sel.args["limit"].(float64); ok && int(limit) < len(changes) {
+		changes = changes[:int(limit)]
+	}
+	res := make([]queryObject, 0, len(changes))
+	for _, c := range changes {
+		obj := queryObject{}
+		for _, sub := range sel.selections {
+			switch sub.name {
+			case "price":
+				obj = append(obj, queryField{name: sub.name, value: c.price})
+			case "changedAt":
+				obj = append(obj, queryField{name: sub.name, value: c.changedAt.UTC().Format(time.RFC3339)})
+			}
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+func hasSelection(sel *selection, name string) bool {
+	for _, sub := range sel.selections {
+		if sub.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCategoryCounts counts the products of every category in the slice
+func (e *queryExecutor) loadCategoryCounts(products []*Product) (map[string]int, error) {
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	var categories []interface{}
+	for _, p := range products {
+		if !seen[p.Category] {
+			seen[p.Category] = true
+			categories = append(categories, p.Category)
+		}
+	}
+
+	n, err := queryIn(e.store.db,
+		"SELECT category, COUNT(*) FROM products WHERE category IN (%s) GROUP BY category",
+		categories, nil,
+		func(rows *sql.Rows) error {
+			var category string
+			var count int
+			if err := rows.Scan(&category, &count); err != nil {
+				return err
+			}
+			counts[category] = count
+			return nil
+		})
+	e.queries += n
+	return counts, err
+}
+
+// loadOnOrder sums the ordered but not yet received quantities
+func (e *queryExecutor) loadOnOrder(products []*Product) (map[int64]int, error) {
+	onOrder := make(map[int64]int)
+	n, err := queryIn(e.store.db,
+		"SELECT product_id, SUM(quantity_ordered - quantity_received) FROM purchase_order_lines WHERE product_id IN (%s) GROUP BY product_id",
+		productIDs(products), nil,
+		func(rows *sql.Rows) error {
+			var id int64
+			var quantity int
+			if err := rows.Scan(&id, &quantity); err != nil {
+				return err
+			}
+			onOrder[id] = quantity
+			return nil
+		})
+	e.queries += n
+	return onOrder, err
+}
+
+// loadPriceHistory reads the price changes of all products, newest first
+func (e *queryExecutor) loadPriceHistory(products []*Product) (map[int64][]priceChange, error) {
+	history := make(map[int64][]priceChange)
+	n, err := queryIn(e.store.db,
+		"SELECT product_id, price, changed_at FROM price_history WHERE product_id IN (%s) ORDER BY product_id, id DESC",
+		productIDs(products), nil,
+		func(rows *sql.Rows) error {
+			var id int64
+			var c priceChange
+			if err := rows.Scan(&id, &c.price, &c.changedAt); err != nil {
+				return err
+			}
+			history[id] = append(history[id], c)
+			return nil
+		})
+	e.queries += n
+	return history, err
+}
+
+// maxInValues is the most values bound to one IN list. SQLite limits the
+// number of parameters in a statement (to 999 before version 3.32), so
+// longer lists are split across several queries.
+const maxInValues = 500
+
+// queryIn runs query, whose IN list is written as %s, once for every chunk of
+// at most maxInValues values and passes each row to scan. extra is bound after
+// the values. It returns the number of queries run, which is 0 for no values.
+func queryIn(db *sql.DB, query string, values, extra []interface{}, scan func(*sql.Rows) error) (int, error) {
+	queries := 0
+	for len(values) > 0 {
+		chunk := values[:min(len(values), maxInValues)]
+		values = values[len(chunk):]
+
+		args := append(chunk[:len(chunk):len(chunk)], extra...)
+		queries++
+		rows, err := db.Query(fmt.Sprintf(query, placeholders(len(chunk))), args...)
+		if err != nil {
+			return queries, err
+		}
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return queries, err
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return queries, err
+		}
+	}
+	return queries, nil
+}
+
+// placeholders returns "?, ?, ..." with n question marks
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func productIDs(products []*Product) []interface{} {
+	ids := make([]interface{}, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+//
+// HTTP
+//
+
+// maxQuerySize limits the size of a query request body
+const maxQuerySize = 64 << 10
+
+// QueryHandler serves product queries over HTTP. It accepts GET requests with
+// a query parameter and POST requests with a JSON body {"query": "..."}.
+// Errors in the query are returned to the client; other errors are logged
+// and reported as an internal error.
+type QueryHandler struct {
+	store *ProductStore
+
+	// ErrorLog logs errors that are not the client's fault. If nil, the log
+	// package's standard logger is used.
+	ErrorLog *log.Logger
+}
+
+// NewQueryHandler creates a QueryHandler for the given store
+func NewQueryHandler(store *ProductStore) *QueryHandler {
+	return &QueryHandler{store: store}
+}
+
+type queryRequest struct {
+	Query string `json:"query"`
+}
+
+type queryResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []queryMessage  `json:"errors,omitempty"`
+}
+
+type queryMessage struct {
+	Message string `json:"message"`
+}
+
+// ServeHTTP runs the query and writes the result as JSON
+func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var query string
+	switch r.Method {
+	case http.MethodGet:
+		query = r.URL.Query().Get("query")
+	case http.MethodPost:
+		var req queryRequest
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxQuerySize))
+		if err == nil {
+			err = json.Unmarshal(body, &req)
+		}
+		if err != nil {
+			writeQueryResponse(w, http.StatusBadRequest, queryResponse{Errors: []queryMessage{{Message: "invalid request body: " + err.Error()}}})
+			return
+		}
+		query = req.Query
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeQueryResponse(w, http.StatusMethodNotAllowed, queryResponse{Errors: []queryMessage{{Message: "method not allowed"}}})
+		return
+	}
+
+	data, err := h.store.ExecuteQuery(query)
+	if err != nil {
+		var queryErr *QueryError
+		if errors.As(err, &queryErr) {
+			writeQueryResponse(w, http.StatusBadRequest, queryResponse{Errors: []queryMessage{{Message: err.Error()}}})
+			return
+		}
+		h.logf("query %q failed: %v", query, err)
+		writeQueryResponse(w, http.StatusInternalServerError, queryResponse{Errors: []queryMessage{{Message: "internal error"}}})
+		return
+	}
+	writeQueryResponse(w, http.StatusOK, queryResponse{Data: data})
+}
+
+func (h *QueryHandler) logf(format string, args ...interface{}) {
+	if h.ErrorLog != nil {
+		h.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func writeQueryResponse(w http.ResponseWriter, status int, resp queryResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
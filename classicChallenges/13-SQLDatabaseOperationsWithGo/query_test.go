@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expectError bool
+	}{
+		{"Outer braces", `{ products { id name } }`, false},
+		{"No outer braces", `products { id }`, false},
+		{"Arguments and commas", `{ product(id: 1, ) { id, name } products(category: "A \"b\"") { id } }`, false},
+		{"Comments", "{ # all products\n products { id } }", false},
+		{"Empty query", ``, true},
+		{"Empty selection set", `{ products { } }`, true},
+		{"Unclosed brace", `{ products { id }`, true},
+		{"Unterminated string", `{ products(category: "Books) { id } }`, true},
+		{"Missing colon", `{ product(id 1) { id } }`, true},
+		{"Unexpected character", `{ products { id; name } }`, true},
+		{"Trailing tokens", `{ products { id } } }`, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseQuery(tc.query)
+			if tc.expectError {
+				var queryErr *QueryError
+				if !errors.As(err, &queryErr) {
+					t.Errorf("Expected QueryError, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Did not expect error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{"Unknown root field", `{ orders { id } }`},
+		{"Unknown product field", `{ products { id color } }`},
+		{"Unknown argument", `{ products(color: "red") { id } }`},
+		{"Wrong argument type", `{ product(id: "1") { id } }`},
+		{"Fractional id", `{ product(id: 1.5) { id } }`},
+		{"Negative limit", `{ products { priceHistory(limit: -1) { price } } }`},
+		{"Sub-fields on scalar", `{ products { name { first } } }`},
+		{"Object without sub-fields", `{ products { category } }`},
+		{"Duplicate field", `{ products { id id } }`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selections, err := parseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("Failed to parse query: %v", err)
+			}
+			if err := validate(selections, queryType); err == nil {
+				t.Errorf("Expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func setupQueryStore(t *testing.T) (*ProductStore, []*Product) {
+	t.Helper()
+	db := setupTestDB(t)
+	t.Cleanup(func() {
+		db.Close()
+		cleanupTestDB()
+	})
+
+	store := NewProductStore(db)
+	var products []*Product
+	for i := 1; i <= 10; i++ {
+		category := "Books"
+		if i%2 == 0 {
+			category = "Electronics"
+		}
+		p := &Product{Name: fmt.Sprintf("Product %d", i), Price: float64(i), Quantity: i % 3, Category: category, SKU: fmt.Sprintf("SKU-%d", i)}
+		if err := store.CreateProduct(testAdmin, p); err != nil {
+			t.Fatalf("Failed to create test product: %v", err)
+		}
+		products = append(products, p)
+	}
+
+	// Give the first product a price change and an open purchase order
+	products[0].Price = 1.5
+	if err := store.UpdateProduct(testAdmin, products[0]); err != nil {
+		t.Fatalf("Failed to update product: %v", err)
+	}
+	supplier := &Supplier{Name: "Acme"}
+	if err := store.CreateSupplier(testAdmin, supplier); err != nil {
+		t.Fatalf("Failed to create supplier: %v", err)
+	}
+	order := &PurchaseOrder{SupplierID: supplier.ID, Lines: []*PurchaseOrderLine{{ProductID: products[0].ID, QuantityOrdered: 7, UnitCost: 1}}}
+	if err := store.CreatePurchaseOrder(testAdmin, order); err != nil {
+		t.Fatalf("Failed to create purchase order: %v", err)
+	}
+	if err := store.ReceivePurchaseOrder(testAdmin, order.ID, map[int64]int{order.Lines[0].ID: 2}); err != nil {
+		t.Fatalf("Failed to receive purchase order: %v", err)
+	}
+	return store, products
+}
+
+type queriedProduct struct {
+	ID       *int64   `json:"id"`
+	Name     *string  `json:"name"`
+	Price    *float64 `json:"price"`
+	Category *struct {
+		Name         string `json:"name"`
+		ProductCount int    `json:"productCount"`
+	} `json:"category"`
+	Stock *struct {
+		Quantity int  `json:"quantity"`
+		InStock  bool `json:"inStock"`
+		OnOrder  int  `json:"onOrder"`
+	} `json:"stock"`
+	PriceHistory []struct {
+		Price     float64 `json:"price"`
+		ChangedAt string  `json:"changedAt"`
+	} `json:"priceHistory"`
+}
+
+func TestExecuteQuery(t *testing.T) {
+	store, products := setupQueryStore(t)
+
+	query := `{
+		products(category: "Books") {
+			id
+			name
+			category { name productCount }
+			stock { quantity inStock onOrder }
+			priceHistory(limit: 1) { price changedAt }
+		}
+	}`
+	data, queries, err := store.executeQuery(query)
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+
+	// One query for the list and one per relation, independent of the number of products
+	if queries != 4 {
+		t.Errorf("Expected 4 database queries, got %d", queries)
+	}
+
+	// Fields come back in selection order
+	if !strings.HasPrefix(string(data), `{"products":[{"id":`) {
+		t.Errorf("Expected fields in selection order, got %s", data)
+	}
+
+	var result struct {
+		Products []queriedProduct `json:"products"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Products) != 5 {
+		t.Fatalf("Expected 5 books, got %d", len(result.Products))
+	}
+
+	first := result.Products[0]
+	if *first.ID != products[0].ID || *first.Name != "Product 1" {
+		t.Errorf("Unexpected first product %+v", first)
+	}
+	if first.Price != nil {
+		t.Errorf("Expected unselected price to be omitted")
+	}
+	if first.Category.Name != "Books" || first.Category.ProductCount != 5 {
+		t.Errorf("Unexpected category %+v", *first.Category)
+	}
+	if first.Stock.Quantity != 3 || !first.Stock.InStock || first.Stock.OnOrder != 5 {
+		t.Errorf("Unexpected stock %+v", *first.Stock)
+	}
+	if len(first.PriceHistory) != 1 || first.PriceHistory[0].Price != 1.5 {
+		t.Errorf("Expected latest price change 1.5, got %+v", first.PriceHistory)
+	}
+
+	third := result.Products[1]
+	if third.Stock.InStock || third.Stock.OnOrder != 0 {
+		t.Errorf("Expected product 3 to be out of stock with nothing on order, got %+v", *third.Stock)
+	}
+}
+
+func TestExecuteQueryManyProducts(t *testing.T) {
+	store, _ := setupQueryStore(t)
+
+	// More products than fit in one IN list, and than older SQLite builds allow parameters
+	const bulk = 1200
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	for i := 0; i < bulk; i++ {
+		if _, err := tx.Exec("INSERT INTO products (name, price, quantity, category) VALUES (?, ?, ?, ?)",
+			fmt.Sprintf("Bulk %d", i), 1.0, 1, "Bulk"); err != nil {
+			t.Fatalf("Failed to insert product: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	data, queries, err := store.executeQuery(`{ products { id category { productCount } stock { onOrder } priceHistory { price } } }`)
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+
+	// The list, one category query and three chunks each for stock and price history
+	if queries != 8 {
+		t.Errorf("Expected 8 database queries, got %d", queries)
+	}
+
+	var result struct {
+		Products []queriedProduct `json:"products"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Products) != bulk+10 {
+		t.Fatalf("Expected %d products, got %d", bulk+10, len(result.Products))
+	}
+	for _, p := range result.Products {
+		if len(p.PriceHistory) == 0 {
+			t.Fatalf("Expected price history for product %d", *p.ID)
+		}
+	}
+	if last := result.Products[len(result.Products)-1]; last.Category.ProductCount != bulk {
+		t.Errorf("Expected %d products in the last category, got %d", bulk, last.Category.ProductCount)
+	}
+}
+
+func TestExecuteQuerySingleProduct(t *testing.T) {
+	store, products := setupQueryStore(t)
+
+	data, err := store.ExecuteQuery(fmt.Sprintf(`{
+		byID: product(id: %d) { name priceHistory { price } }
+	}`, products[0].ID))
+	if err == nil {
+		t.Errorf("Expected error for alias syntax, got %s", data)
+	}
+
+	data, err = store.ExecuteQuery(fmt.Sprintf(`{ product(id: %d) { name priceHistory { price } } }`, products[0].ID))
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+	var result struct {
+		Product queriedProduct `json:"product"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Product.PriceHistory) != 2 {
+		t.Errorf("Expected 2 price history entries, got %d", len(result.Product.PriceHistory))
+	}
+
+	data, err = store.ExecuteQuery(`{ product(sku: "SKU-4") { name } }`)
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+	if string(data) != `{"product":{"name":"Product 4"}}` {
+		t.Errorf("Unexpected result %s", data)
+	}
+
+	data, err = store.ExecuteQuery(`{ product(id: 999) { name } }`)
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+	if string(data) != `{"product":null}` {
+		t.Errorf("Expected null for a missing product, got %s", data)
+	}
+
+	if _, err := store.ExecuteQuery(`{ product(id: 1, sku: "SKU-1") { name } }`); err == nil {
+		t.Errorf("Expected error for ambiguous product arguments, got nil")
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	store, _ := setupQueryStore(t)
+	server := httptest.NewServer(NewQueryHandler(store))
+	defer server.Close()
+
+	t.Run("POST", func(t *testing.T) {
+		body := `{"query": "{ products(category: \"Electronics\") { name } }"}`
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var result struct {
+			Data struct {
+				Products []queriedProduct `json:"products"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(result.Data.Products) != 5 {
+			t.Errorf("Expected 5 products, got %d", len(result.Data.Products))
+		}
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "?query=" + url.QueryEscape(`products { id }`))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"query": "{ products { color } }"}`))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+		var result queryResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "color") {
+			t.Errorf("Expected an error about the unknown field, got %+v", result.Errors)
+		}
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		db, err := InitDB(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to initialize database: %v", err)
+		}
+		db.Close()
+		var logged strings.Builder
+		handler := NewQueryHandler(NewProductStore(db))
+		handler.ErrorLog = log.New(&logged, "", 0)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?query="+url.QueryEscape(`products { id }`), nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", rec.Code)
+		}
+		var result queryResponse
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Message != "internal error" {
+			t.Errorf("Expected a generic internal error, got %+v", result.Errors)
+		}
+		if !strings.Contains(logged.String(), "database is closed") {
+			t.Errorf("Expected the cause to be logged, got %q", logged.String())
+		}
+	})
+
+	t.Run("Wrong method", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", resp.StatusCode)
+		}
+	})
+}
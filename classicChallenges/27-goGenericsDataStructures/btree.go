@@ -0,0 +1,366 @@
+package generics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// ErrKeyNotFound is returned when a key is not present in a map
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrInvalidDegree is returned when a B-tree is created with a degree below 2
+var ErrInvalidDegree = errors.New("degree must be at least 2")
+
+// BTree is an ordered map stored in a B-tree. Keys of a node are kept in one
+// slice, so a lookup touches few cache lines. Every node except the root holds
+// between degree-1 and 2*degree-1 keys. A BTree is not safe for concurrent use.
+type BTree[K cmp.Ordered, V any] struct {
+	degree int
+	root   *btreeNode[K, V]
+	size   int
+}
+
+type btreeNode[K cmp.Ordered, V any] struct {
+	keys     []K
+	values   []V
+	children []*btreeNode[K, V] // empty for leaves, otherwise len(keys)+1
+}
+
+// NewBTree creates a new empty B-tree with the given minimum degree
+func NewBTree[K cmp.Ordered, V any](degree int) (*BTree[K, V], error) {
+	if degree < 2 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidDegree, degree)
+	}
+	return &BTree[K, V]{degree: degree, root: &btreeNode[K, V]{}}, nil
+}
+
+// BulkLoad builds a B-tree from keys in strictly increasing order and their
+// values, filling nodes bottom-up in O(n) instead of inserting one by one
+func BulkLoad[K cmp.Ordered, V any](degree int, keys []K, values []V) (*BTree[K, V], error) {
+	t, err := NewBTree[K, V](degree)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("got %d keys but %d values", len(keys), len(values))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, fmt.Errorf("keys must be strictly increasing, %v is followed by %v", keys[i-1], keys[i])
+		}
+	}
+	if len(keys) == 0 {
+		return t, nil
+	}
+	t.size = len(keys)
+
+	// Each pass packs a level into nodes; the keys between nodes become the next level up
+	var children []*btreeNode[K, V]
+	for {
+		nodes, sepKeys, sepValues := t.packLevel(keys, values, children)
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			break
+		}
+		keys, values, children = sepKeys, sepValues, nodes
+	}
+	return t, nil
+}
+
+// packLevel splits keys into as few nodes as possible, spreading keys evenly.
+// Nodes take children in order when children is not nil.
+func (t *BTree[K, V]) packLevel(keys []K, values []V, children []*btreeNode[K, V]) (nodes []*btreeNode[K, V], sepKeys []K, sepValues []V) {
+	maxKeys := 2*t.degree - 1
+	// A full node and the separator after it take maxKeys+1 keys
+	count := (len(keys) + 1 + maxKeys) / (maxKeys + 1)
+	inNodes := len(keys) - (count - 1)
+	pos, child := 0, 0
+	for i := 0; i < count; i++ {
+		n := inNodes / count
+		if i < inNodes%count {
+			n++
+		}
+		node := &btreeNode[K, V]{
+			keys:   append(make([]K, 0, maxKeys), keys[pos:pos+n]...),
+			values: append(make([]V, 0, maxKeys), values[pos:pos+n]...),
+		}
+		if children != nil {
+			node.children = append(make([]*btreeNode[K, V], 0, maxKeys+1), children[child:child+n+1]...)
+			child += n + 1
+		}
+		nodes = append(nodes, node)
+		pos += n
+		if i < count-1 {
+			sepKeys = append(sepKeys, keys[pos])
+			sepValues = append(sepValues, values[pos])
+			pos++
+		}
+	}
+	return nodes, sepKeys, sepValues
+}
+
+func (n *btreeNode[K, V]) leaf() bool {
+	return len(n.children) == 0
+}
+
+// Len returns the number of keys in the tree
+func (t *BTree[K, V]) Len() int {
+	return t.size
+}
+
+// Get returns the value stored for key
+// Returns ErrKeyNotFound if the key is not present
+func (t *BTree[K, V]) Get(key K) (V, error) {
+	n := t.root
+	for {
+		i, found := slices.BinarySearch(n.keys, key)
+		if found {
+			return n.values[i], nil
+		}
+		if n.leaf() {
+			var zero V
+			return zero, ErrKeyNotFound
+		}
+		n = n.children[i]
+	}
+}
+
+// Put stores value for key, replacing any previous value
+func (t *BTree[K, V]) Put(key K, value V) {
+	if len(t.root.keys) == 2*t.degree-1 {
+		old := t.root
+		t.root = &btreeNode[K, V]{children: []*btreeNode[K, V]{old}}
+		t.splitChild(t.root, 0)
+	}
+	n := t.root
+	for {
+		i, found := slices.BinarySearch(n.keys, key)
+		if found {
+			n.values[i] = value
+			return
+		}
+		if n.leaf() {
+			n.keys = slices.Insert(n.keys, i, key)
+			n.values = slices.Insert(n.values, i, value)
+			t.size++
+			return
+		}
+		// Split full children on the way down, so there is always room for a key from below
+		if len(n.children[i].keys) == 2*t.degree-1 {
+			t.splitChild(n, i)
+			switch {
+			case key == n.keys[i]:
+				n.values[i] = value
+				return
+			case key > n.keys[i]:
+				i++
+			}
+		}
+		n = n.children[i]
+	}
+}
+
+// splitChild splits the full child i of n around its middle key, which moves up into n
+func (t *BTree[K, V]) splitChild(n *btreeNode[K, V], i int) {
+	child := n.children[i]
+	mid := t.degree - 1
+	right := &btreeNode[K, V]{
+		keys:   append(make([]K, 0, 2*t.degree-1), child.keys[mid+1:]...),
+		values: append(make([]V, 0, 2*t.degree-1), child.values[mid+1:]...),
+	}
+	if !child.leaf() {
+		right.children = append(make([]*btreeNode[K, V], 0, 2*t.degree), child.children[mid+1:]...)
+		clear(child.children[mid+1:])
+		child.children = child.children[:mid+1]
+	}
+	n.keys = slices.Insert(n.keys, i, child.keys[mid])
+	n.values = slices.Insert(n.values, i, child.values[mid])
+	n.children = slices.Insert(n.children, i+1, right)
+
+	// Zero moved values so the tree does not keep them alive
+	clear(child.values[mid:])
+	child.keys = child.keys[:mid]
+	child.values = child.values[:mid]
+}
+
+// Delete removes key from the tree
+// Returns ErrKeyNotFound if the key is not present
+func (t *BTree[K, V]) Delete(key K) error {
+	found := t.delete(t.root, key)
+	if len(t.root.keys) == 0 && !t.root.leaf() {
+		t.root = t.root.children[0]
+	}
+	if !found {
+		return ErrKeyNotFound
+	}
+	t.size--
+	return nil
+}
+
+// delete removes key from the subtree of n, which has at least degree keys
+// unless it is the root
+func (t *BTree[K, V]) delete(n *btreeNode[K, V], key K) bool {
+	i, found := slices.BinarySearch(n.keys, key)
+	switch {
+	case found && n.leaf():
+		n.keys = slices.Delete(n.keys, i, i+1)
+		n.values = slices.Delete(n.values, i, i+1)
+		return true
+	case found:
+		// Replace the key with its predecessor or successor, taken from a child that can spare one
+		if len(n.children[i].keys) >= t.degree {
+			k, v := n.children[i].max()
+			n.keys[i], n.values[i] = k, v
+			return t.delete(n.children[i], k)
+		}
+		if len(n.children[i+1].keys) >= t.degree {
+			k, v := n.children[i+1].min()
+			n.keys[i], n.values[i] = k, v
+			return t.delete(n.children[i+1], k)
+		}
+		t.merge(n, i)
+		return t.delete(n.children[i], key)
+	case n.leaf():
+		return false
+	}
+
+	if len(n.children[i].keys) < t.degree {
+		i = t.fill(n, i)
+	}
+	return t.delete(n.children[i], key)
+}
+
+// fill gives child i of n at least degree keys, borrowing from a sibling or
+// merging with one, and returns the index of the child that now holds its keys
+func (t *BTree[K, V]) fill(n *btreeNode[K, V], i int) int {
+	child := n.children[i]
+	switch {
+	case i > 0 && len(n.children[i-1].keys) >= t.degree:
+		// Rotate the last key of the left sibling through n
+		left := n.children[i-1]
+		last := len(left.keys) - 1
+		child.keys = slices.Insert(child.keys, 0, n.keys[i-1])
+		child.values = slices.Insert(child.values, 0, n.values[i-1])
+		n.keys[i-1], n.values[i-1] = left.keys[last], left.values[last]
+		var zero V
+		left.values[last] = zero
+		left.keys, left.values = left.keys[:last], left.values[:last]
+		if !left.leaf() {
+			child.children = slices.Insert(child.children, 0, left.children[last+1])
+			left.children[last+1] = nil
+			left.children = left.children[:last+1]
+		}
+		return i
+	case i < len(n.children)-1 && len(n.children[i+1].keys) >= t.degree:
+		// Rotate the first key of the right sibling through n
+		right := n.children[i+1]
+		child.keys = append(child.keys, n.keys[i])
+		child.values = append(child.values, n.values[i])
+		n.keys[i], n.values[i] = right.keys[0], right.values[0]
+		right.keys = slices.Delete(right.keys, 0, 1)
+		right.values = slices.Delete(right.values, 0, 1)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = slices.Delete(right.children, 0, 1)
+		}
+		return i
+	case i < len(n.children)-1:
+		t.merge(n, i)
+		return i
+	default:
+		t.merge(n, i-1)
+		return i - 1
+	}
+}
+
+// merge joins child i+1 of n and the key between them into child i
+func (t *BTree[K, V]) merge(n *btreeNode[K, V], i int) {
+	left, right := n.children[i], n.children[i+1]
+	left.keys = append(append(left.keys, n.keys[i]), right.keys...)
+	left.values = append(append(left.values, n.values[i]), right.values...)
+	left.children = append(left.children, right.children...)
+	n.keys = slices.Delete(n.keys, i, i+1)
+	n.values = slices.Delete(n.values, i, i+1)
+	n.children = slices.Delete(n.children, i+1, i+2)
+}
+
+func (n *btreeNode[K, V]) min() (K, V) {
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n.keys[0], n.values[0]
+}
+
+func (n *btreeNode[K, V]) max() (K, V) {
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	last := len(n.keys) - 1
+	return n.keys[last], n.values[last]
+}
+
+// Min returns the smallest key and its value
+// Returns an error if the tree is empty
+func (t *BTree[K, V]) Min() (K, V, error) {
+	if t.size == 0 {
+		var k K
+		var v V
+		return k, v, ErrEmptyCollection
+	}
+	k, v := t.root.min()
+	return k, v, nil
+}
+
+// Max returns the largest key and its value
+// Returns an error if the tree is empty
+func (t *BTree[K, V]) Max() (K, V, error) {
+	if t.size == 0 {
+		var k K
+		var v V
+		return k, v, ErrEmptyCollection
+	}
+	k, v := t.root.max()
+	return k, v, nil
+}
+
+// All returns an iterator over all keys and values in increasing key order
+func (t *BTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.root.ascend(nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the keys from from (inclusive) to to
+// (exclusive) and their values, in increasing key order
+func (t *BTree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.root.ascend(&from, &to, yield)
+	}
+}
+
+// ascend yields the keys of the subtree in [from, to), where nil means
+// unbounded, and reports whether iteration should continue
+func (n *btreeNode[K, V]) ascend(from, to *K, yield func(K, V) bool) bool {
+	start := 0
+	if from != nil {
+		start, _ = slices.BinarySearch(n.keys, *from)
+	}
+	for i := start; i <= len(n.keys); i++ {
+		if !n.leaf() && !n.children[i].ascend(from, to, yield) {
+			return false
+		}
+		if i == len(n.keys) {
+			break
+		}
+		if to != nil && n.keys[i] >= *to {
+			return false
+		}
+		if !yield(n.keys[i], n.values[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package generics
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// checkBTree verifies the B-tree invariants and returns the keys in order
+func checkBTree[K interface{ ~int | ~string }, V any](t *testing.T, tree *BTree[K, V]) []K {
+	t.Helper()
+	var keys []K
+	leafDepth := -1
+	var walk func(n *btreeNode[K, V], depth int, root bool)
+	walk = func(n *btreeNode[K, V], depth int, root bool) {
+		if !root && (len(n.keys) < tree.degree-1 || len(n.keys) > 2*tree.degree-1) {
+			t.Fatalf("Expected between %d and %d keys in a node, got %d", tree.degree-1, 2*tree.degree-1, len(n.keys))
+		}
+		if len(n.values) != len(n.keys) {
+			t.Fatalf("Expected %d values, got %d", len(n.keys), len(n.values))
+		}
+		if n.leaf() {
+			if leafDepth >= 0 && depth != leafDepth {
+				t.Fatalf("Expected all leaves at depth %d, got one at %d", leafDepth, depth)
+			}
+			leafDepth = depth
+			keys = append(keys, n.keys...)
+			return
+		}
+		if len(n.children) != len(n.keys)+1 {
+			t.Fatalf("Expected %d children, got %d", len(n.keys)+1, len(n.children))
+		}
+		for i, c := range n.children {
+			walk(c, depth+1, false)
+			if i < len(n.keys) {
+				keys = append(keys, n.keys[i])
+			}
+		}
+	}
+	walk(tree.root, 0, true)
+	if !slices.IsSorted(keys) || len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		t.Fatalf("Expected strictly increasing keys, got %v", keys)
+	}
+	if len(keys) != tree.Len() {
+		t.Fatalf("Expected Len %d, got %d", len(keys), tree.Len())
+	}
+	return keys
+}
+
+func TestNewBTree(t *testing.T) {
+	if _, err := NewBTree[int, int](1); !errors.Is(err, ErrInvalidDegree) {
+		t.Errorf("Expected ErrInvalidDegree, got %v", err)
+	}
+	tree, err := NewBTree[string, int](2)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	if _, err := tree.Get("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, _, err := tree.Min(); !errors.Is(err, ErrEmptyCollection) {
+		t.Errorf("Expected ErrEmptyCollection, got %v", err)
+	}
+	if err := tree.Delete("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestBTreeAgainstMap(t *testing.T) {
+	for _, degree := range []int{2, 3, 5, 32} {
+		tree, err := NewBTree[int, int](degree)
+		if err != nil {
+			t.Fatalf("Failed to create tree: %v", err)
+		}
+		expected := map[int]int{}
+		rng := rand.New(rand.NewSource(int64(degree)))
+
+		for i := 0; i < 5000; i++ {
+			key := rng.Intn(500)
+			if rng.Intn(3) == 0 {
+				err := tree.Delete(key)
+				if _, ok := expected[key]; ok != (err == nil) {
+					t.Fatalf("degree %d: Delete(%d) returned %v, key present: %v", degree, key, err, ok)
+				}
+				delete(expected, key)
+			} else {
+				tree.Put(key, i)
+				expected[key] = i
+			}
+			if i%500 == 0 {
+				checkBTree(t, tree)
+			}
+		}
+
+		keys := checkBTree(t, tree)
+		if len(keys) != len(expected) {
+			t.Fatalf("degree %d: Expected %d keys, got %d", degree, len(expected), len(keys))
+		}
+		for k, v := range expected {
+			if got, err := tree.Get(k); err != nil || got != v {
+				t.Errorf("degree %d: Expected Get(%d) to be %d, got %d (%v)", degree, k, v, got, err)
+			}
+		}
+	}
+}
+
+func TestBTreeIteration(t *testing.T) {
+	tree, err := NewBTree[int, string](2)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	for _, k := range []int{50, 10, 40, 20, 30, 60, 0, 70, 80, 90} {
+		tree.Put(k, "v")
+	}
+
+	tests := []struct {
+		name     string
+		from, to int
+		expected []int
+	}{
+		{"Middle", 20, 60, []int{20, 30, 40, 50}},
+		{"Between keys", 15, 55, []int{20, 30, 40, 50}},
+		{"Before start", -10, 15, []int{0, 10}},
+		{"After end", 85, 200, []int{90}},
+		{"Empty", 61, 69, nil},
+		{"Reversed", 60, 20, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for k := range tree.Range(tt.from, tt.to) {
+				got = append(got, k)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Stopping early
+	var first []int
+	for k := range tree.All() {
+		if len(first) == 3 {
+			break
+		}
+		first = append(first, k)
+	}
+	if !slices.Equal(first, []int{0, 10, 20}) {
+		t.Errorf("Expected [0 10 20], got %v", first)
+	}
+
+	minKey, _, _ := tree.Min()
+	maxKey, _, _ := tree.Max()
+	if minKey != 0 || maxKey != 90 {
+		t.Errorf("Expected min 0 and max 90, got %d and %d", minKey, maxKey)
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 4, 7, 8, 100, 1001} {
+		for _, degree := range []int{2, 3, 16} {
+			keys := make([]int, n)
+			values := make([]string, n)
+			for i := range keys {
+				keys[i] = i * 2
+				values[i] = string(rune('a' + i%26))
+			}
+			tree, err := BulkLoad(degree, keys, values)
+			if err != nil {
+				t.Fatalf("Failed to bulk load %d keys: %v", n, err)
+			}
+			if got := checkBTree(t, tree); !slices.Equal(got, keys) && n > 0 {
+				t.Fatalf("Expected keys %v, got %v", keys, got)
+			}
+			// The loaded tree keeps working
+			tree.Put(1, "x")
+			if n > 0 {
+				if err := tree.Delete(0); err != nil {
+					t.Errorf("Failed to delete from loaded tree: %v", err)
+				}
+			}
+			checkBTree(t, tree)
+		}
+	}
+
+	if _, err := BulkLoad(2, []int{1, 3, 2}, []int{0, 0, 0}); err == nil {
+		t.Errorf("Expected an error for unsorted keys, but got none")
+	}
+	if _, err := BulkLoad(2, []int{1, 1}, []int{0, 0}); err == nil {
+		t.Errorf("Expected an error for duplicate keys, but got none")
+	}
+	if _, err := BulkLoad(2, []int{1}, []int{}); err == nil {
+		t.Errorf("Expected an error for missing values, but got none")
+	}
+}
+
+func BenchmarkBTreeGet(b *testing.B) {
+	keys := make([]int, 100000)
+	for i := range keys {
+		keys[i] = i
+	}
+	tree, err := BulkLoad(32, keys, keys)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get(i % len(keys))
+	}
+}
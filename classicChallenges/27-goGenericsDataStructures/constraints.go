@@ -0,0 +1,17 @@
+package generics
+
+// Integer is satisfied by all integer types
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is satisfied by all floating-point types
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is satisfied by all integer and floating-point types
+type Number interface {
+	Integer | Float
+}
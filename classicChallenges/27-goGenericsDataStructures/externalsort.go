@@ -0,0 +1,159 @@
+package generics
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+)
+
+// ExternalSort sorts a sequence that may not fit in memory. Elements are read
+// in runs of runSize, each run is sorted and written to a temporary file in
+// dir (the system default if empty), and the runs are merged and passed to
+// emit in the order given by compare. The sort is stable. Elements are stored
+// with encoding/gob, so T must be encodable. Temporary files are removed
+// before ExternalSort returns.
+func ExternalSort[T any](input iter.Seq[T], compare func(a, b T) int, runSize int, dir string, emit func(T) error) (err error) {
+	if runSize < 1 {
+		return fmt.Errorf("run size must be positive, got %d", runSize)
+	}
+
+	var runs []*os.File
+	defer func() {
+		for _, f := range runs {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	run := make([]T, 0, runSize)
+	for v := range input {
+		run = append(run, v)
+		if len(run) < runSize {
+			continue
+		}
+		f, err := writeRun(run, compare, dir)
+		if f != nil {
+			runs = append(runs, f)
+		}
+		if err != nil {
+			return err
+		}
+		run = run[:0]
+	}
+
+	if len(runs) == 0 {
+		// Everything fit in one run: no need for files
+		MergeSortFunc(run, compare)
+		for _, v := range run {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(run) > 0 {
+		f, err := writeRun(run, compare, dir)
+		if f != nil {
+			runs = append(runs, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return mergeRuns(runs, compare, emit)
+}
+
+// writeRun sorts run and writes it to a new temporary file, rewound for reading
+func writeRun[T any](run []T, compare func(a, b T) int, dir string) (*os.File, error) {
+	MergeSortFunc(run, compare)
+	f, err := os.CreateTemp(dir, "sort-run-*.gob")
+	if err != nil {
+		return nil, fmt.Errorf("creating run file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for _, v := range run {
+		if err := enc.Encode(v); err != nil {
+			return f, fmt.Errorf("writing run: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return f, fmt.Errorf("writing run: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return f, fmt.Errorf("rewinding run: %w", err)
+	}
+	return f, nil
+}
+
+// runReader is the next element of a run during the merge
+type runReader[T any] struct {
+	dec   *gob.Decoder
+	value T
+	index int // position of the run in the input, breaks ties for stability
+}
+
+// runHeap orders runs by their next element
+type runHeap[T any] struct {
+	readers []*runReader[T]
+	compare func(a, b T) int
+}
+
+func (h *runHeap[T]) Len() int { return len(h.readers) }
+func (h *runHeap[T]) Less(i, j int) bool {
+	if c := h.compare(h.readers[i].value, h.readers[j].value); c != 0 {
+		return c < 0
+	}
+	return h.readers[i].index < h.readers[j].index
+}
+func (h *runHeap[T]) Swap(i, j int) { h.readers[i], h.readers[j] = h.readers[j], h.readers[i] }
+func (h *runHeap[T]) Push(x any)    { h.readers = append(h.readers, x.(*runReader[T])) }
+func (h *runHeap[T]) Pop() any {
+	last := h.readers[len(h.readers)-1]
+	h.readers = h.readers[:len(h.readers)-1]
+	return last
+}
+
+// next decodes the following element of the run, returning io.EOF at its end
+func (r *runReader[T]) next() error {
+	var v T
+	if err := r.dec.Decode(&v); err != nil {
+		return err
+	}
+	r.value = v
+	return nil
+}
+
+// mergeRuns merges sorted run files with a min-heap of their next elements
+func mergeRuns[T any](runs []*os.File, compare func(a, b T) int, emit func(T) error) error {
+	h := &runHeap[T]{compare: compare}
+	for i, f := range runs {
+		r := &runReader[T]{dec: gob.NewDecoder(bufio.NewReader(f)), index: i}
+		if err := r.next(); err != nil {
+			return fmt.Errorf("reading run %d: %w", i, err)
+		}
+		h.readers = append(h.readers, r)
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		r := h.readers[0]
+		if err := emit(r.value); err != nil {
+			return err
+		}
+		switch err := r.next(); {
+		case errors.Is(err, io.EOF):
+			heap.Pop(h)
+		case err != nil:
+			return fmt.Errorf("reading run %d: %w", r.index, err)
+		default:
+			heap.Fix(h, 0)
+		}
+	}
+	return nil
+}
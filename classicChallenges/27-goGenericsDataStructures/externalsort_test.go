@@ -0,0 +1,68 @@
+package generics
+
+import (
+	"cmp"
+	"errors"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestExternalSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	records := make([]record, 1000)
+	for i := range records {
+		records[i] = record{Key: rng.Intn(50), Seq: i}
+	}
+	expected := slices.Clone(records)
+	slices.SortStableFunc(expected, compareRecords)
+
+	for _, runSize := range []int{1, 7, 100, 1000, 5000} {
+		dir := t.TempDir()
+		var got []record
+		err := ExternalSort(slices.Values(records), compareRecords, runSize, dir, func(r record) error {
+			got = append(got, r)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to sort with run size %d: %v", runSize, err)
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("Expected stable sorted records with run size %d", runSize)
+		}
+		if files, _ := os.ReadDir(dir); len(files) != 0 {
+			t.Errorf("Expected temporary files to be removed, found %d", len(files))
+		}
+	}
+}
+
+func TestExternalSortErrors(t *testing.T) {
+	input := slices.Values([]int{3, 1, 2, 5, 4})
+
+	if err := ExternalSort(input, cmp.Compare[int], 0, "", func(int) error { return nil }); err == nil {
+		t.Error("Expected an error for a zero run size, but got none")
+	}
+
+	// An error from emit stops the sort and still removes the run files
+	dir := t.TempDir()
+	stop := errors.New("stop")
+	emitted := 0
+	err := ExternalSort(input, cmp.Compare[int], 2, dir, func(int) error {
+		emitted++
+		if emitted == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected the emit error, got %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Expected temporary files to be removed, found %d", len(files))
+	}
+
+	if err := ExternalSort(input, cmp.Compare[int], 2, "/nonexistent/dir", func(int) error { return nil }); err == nil {
+		t.Error("Expected an error for a missing directory, but got none")
+	}
+}
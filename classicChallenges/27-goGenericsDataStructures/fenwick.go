@@ -0,0 +1,84 @@
+package generics
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIndexOutOfRange is returned when an index or range lies outside a collection
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// FenwickTree (binary indexed tree) holds n numbers and supports point updates
+// and prefix sums in O(log n)
+type FenwickTree[T Number] struct {
+	tree []T // tree[i] is the sum of the i&-i values ending at index i-1
+}
+
+// NewFenwickTree creates a new Fenwick tree of n zeros
+func NewFenwickTree[T Number](n int) (*FenwickTree[T], error) {
+	if n < 0 {
+		return nil, fmt.Errorf("size cannot be negative, got %d", n)
+	}
+	return &FenwickTree[T]{tree: make([]T, n+1)}, nil
+}
+
+// NewFenwickTreeFrom creates a new Fenwick tree holding values, in O(n)
+func NewFenwickTreeFrom[T Number](values []T) *FenwickTree[T] {
+	tree := make([]T, len(values)+1)
+	copy(tree[1:], values)
+	for i := 1; i < len(tree); i++ {
+		if parent := i + i&-i; parent < len(tree) {
+			tree[parent] += tree[i]
+		}
+	}
+	return &FenwickTree[T]{tree: tree}
+}
+
+// Len returns the number of values
+func (f *FenwickTree[T]) Len() int {
+	return len(f.tree) - 1
+}
+
+// Add adds delta to the value at index i
+// Returns ErrIndexOutOfRange if i is not a valid index
+func (f *FenwickTree[T]) Add(i int, delta T) error {
+	if i < 0 || i >= f.Len() {
+		return fmt.Errorf("%w: %d not in [0, %d)", ErrIndexOutOfRange, i, f.Len())
+	}
+	for i++; i < len(f.tree); i += i & -i {
+		f.tree[i] += delta
+	}
+	return nil
+}
+
+// PrefixSum returns the sum of the first n values
+// Returns ErrIndexOutOfRange if n is negative or larger than Len
+func (f *FenwickTree[T]) PrefixSum(n int) (T, error) {
+	if n < 0 || n > f.Len() {
+		var zero T
+		return zero, fmt.Errorf("%w: %d not in [0, %d]", ErrIndexOutOfRange, n, f.Len())
+	}
+	var sum T
+	for ; n > 0; n -= n & -n {
+		sum += f.tree[n]
+	}
+	return sum, nil
+}
+
+// RangeSum returns the sum of the values at indexes from from (inclusive) to to (exclusive)
+// Returns ErrIndexOutOfRange if the range is not within the tree
+func (f *FenwickTree[T]) RangeSum(from, to int) (T, error) {
+	if from > to {
+		var zero T
+		return zero, fmt.Errorf("%w: [%d, %d) is reversed", ErrIndexOutOfRange, from, to)
+	}
+	hi, err := f.PrefixSum(to)
+	if err != nil {
+		return hi, err
+	}
+	lo, err := f.PrefixSum(from)
+	if err != nil {
+		return lo, err
+	}
+	return hi - lo, nil
+}
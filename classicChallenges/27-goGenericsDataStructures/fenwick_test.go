@@ -0,0 +1,75 @@
+package generics
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestFenwickTree(t *testing.T) {
+	f := NewFenwickTreeFrom([]int{3, 1, 4, 1, 5, 9, 2, 6})
+
+	tests := []struct {
+		name     string
+		from, to int
+		expected int
+	}{
+		{"All", 0, 8, 31},
+		{"Prefix", 0, 3, 8},
+		{"Middle", 2, 6, 19},
+		{"Single", 5, 6, 9},
+		{"Empty", 4, 4, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.RangeSum(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Failed to sum range: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+
+	if err := f.Add(8, 1); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected ErrIndexOutOfRange, got %v", err)
+	}
+	if _, err := f.RangeSum(3, 2); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected ErrIndexOutOfRange, got %v", err)
+	}
+	if _, err := f.PrefixSum(9); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected ErrIndexOutOfRange, got %v", err)
+	}
+	if _, err := NewFenwickTree[int](-1); err == nil {
+		t.Error("Expected an error for a negative size, but got none")
+	}
+}
+
+func TestFenwickTreeAgainstSlice(t *testing.T) {
+	const n = 100
+	f, err := NewFenwickTree[float64](n)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	values := make([]float64, n)
+	rng := rand.New(rand.NewSource(5))
+	for round := 0; round < 1000; round++ {
+		i, delta := rng.Intn(n), float64(rng.Intn(21)-10)
+		if err := f.Add(i, delta); err != nil {
+			t.Fatalf("Failed to add: %v", err)
+		}
+		values[i] += delta
+
+		from := rng.Intn(n + 1)
+		to := from + rng.Intn(n+1-from)
+		var expected float64
+		for _, v := range values[from:to] {
+			expected += v
+		}
+		if got, _ := f.RangeSum(from, to); got != expected {
+			t.Fatalf("Expected sum of [%d, %d) to be %v, got %v", from, to, expected, got)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package generics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrDimensionMismatch is returned when matrix dimensions do not fit an operation
+var ErrDimensionMismatch = errors.New("matrix dimensions do not match")
+
+// ErrSingularMatrix is returned when a matrix has no inverse
+var ErrSingularMatrix = errors.New("matrix is singular")
+
+// matrixBlockSize is the side of the blocks Mul works on, so that a block of
+// each operand stays in cache
+const matrixBlockSize = 64
+
+// Matrix is a dense matrix of numbers stored in row-major order
+type Matrix[T Number] struct {
+	rows, cols int
+	data       []T
+}
+
+// NewMatrix creates a new rows x cols matrix of zeros
+func NewMatrix[T Number](rows, cols int) (*Matrix[T], error) {
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrDimensionMismatch, rows, cols)
+	}
+	return &Matrix[T]{rows: rows, cols: cols, data: make([]T, rows*cols)}, nil
+}
+
+// NewMatrixFromRows creates a new matrix holding a copy of rows, which must all
+// have the same length
+func NewMatrixFromRows[T Number](rows [][]T) (*Matrix[T], error) {
+	cols := 0
+	if len(rows) > 0 {
+		cols = len(rows[0])
+	}
+	m := &Matrix[T]{rows: len(rows), cols: cols, data: make([]T, 0, len(rows)*cols)}
+	for i, r := range rows {
+		if len(r) != cols {
+			return nil, fmt.Errorf("%w: row %d has %d values, row 0 has %d", ErrDimensionMismatch, i, len(r), cols)
+		}
+		m.data = append(m.data, r...)
+	}
+	return m, nil
+}
+
+// Identity creates a new n x n identity matrix
+func Identity[T Number](n int) (*Matrix[T], error) {
+	m, err := NewMatrix[T](n, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		m.data[i*n+i] = 1
+	}
+	return m, nil
+}
+
+// Rows returns the number of rows
+func (m *Matrix[T]) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns
+func (m *Matrix[T]) Cols() int {
+	return m.cols
+}
+
+// At returns the value in row i and column j
+// Returns ErrIndexOutOfRange if the position is outside the matrix
+func (m *Matrix[T]) At(i, j int) (T, error) {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		var zero T
+		return zero, fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndexOutOfRange, i, j, m.rows, m.cols)
+	}
+	return m.data[i*m.cols+j], nil
+}
+
+// Set replaces the value in row i and column j
+// Returns ErrIndexOutOfRange if the position is outside the matrix
+func (m *Matrix[T]) Set(i, j int, value T) error {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndexOutOfRange, i, j, m.rows, m.cols)
+	}
+	m.data[i*m.cols+j] = value
+	return nil
+}
+
+// Row returns a copy of row i
+// Returns ErrIndexOutOfRange if the row is outside the matrix
+func (m *Matrix[T]) Row(i int) ([]T, error) {
+	if i < 0 || i >= m.rows {
+		return nil, fmt.Errorf("%w: row %d in a %dx%d matrix", ErrIndexOutOfRange, i, m.rows, m.cols)
+	}
+	return append([]T(nil), m.data[i*m.cols:(i+1)*m.cols]...), nil
+}
+
+// Equal returns true if both matrices have the same dimensions and values
+func (m *Matrix[T]) Equal(o *Matrix[T]) bool {
+	if m.rows != o.rows || m.cols != o.cols {
+		return false
+	}
+	for i, v := range m.data {
+		if v != o.data[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the rows of the matrix, one per line
+func (m *Matrix[T]) String() string {
+	var sb strings.Builder
+	for i := 0; i < m.rows; i++ {
+		fmt.Fprintln(&sb, m.data[i*m.cols:(i+1)*m.cols])
+	}
+	return sb.String()
+}
+
+// Transpose returns a new matrix with rows and columns swapped
+func (m *Matrix[T]) Transpose() *Matrix[T] {
+	t := &Matrix[T]{rows: m.cols, cols: m.rows, data: make([]T, len(m.data))}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			t.data[j*t.cols+i] = m.data[i*m.cols+j]
+		}
+	}
+	return t
+}
+
+// Add returns the sum of m and o
+// Returns ErrDimensionMismatch if their dimensions differ
+func (m *Matrix[T]) Add(o *Matrix[T]) (*Matrix[T], error) {
+	if m.rows != o.rows || m.cols != o.cols {
+		return nil, fmt.Errorf("%w: cannot add %dx%d and %dx%d", ErrDimensionMismatch, m.rows, m.cols, o.rows, o.cols)
+	}
+	sum := &Matrix[T]{rows: m.rows, cols: m.cols, data: make([]T, len(m.data))}
+	for i := range m.data {
+		sum.data[i] = m.data[i] + o.data[i]
+	}
+	return sum, nil
+}
+
+// Mul returns the product m * o, working on blocks so each stays in cache
+// Returns ErrDimensionMismatch if m has not as many columns as o has rows
+func (m *Matrix[T]) Mul(o *Matrix[T]) (*Matrix[T], error) {
+	if m.cols != o.rows {
+		return nil, fmt.Errorf("%w: cannot multiply %dx%d by %dx%d", ErrDimensionMismatch, m.rows, m.cols, o.rows, o.cols)
+	}
+	p := &Matrix[T]{rows: m.rows, cols: o.cols, data: make([]T, m.rows*o.cols)}
+	for ii := 0; ii < m.rows; ii += matrixBlockSize {
+		for kk := 0; kk < m.cols; kk += matrixBlockSize {
+			for jj := 0; jj < o.cols; jj += matrixBlockSize {
+				// The i-k-j order walks rows of o and p sequentially
+				for i := ii; i < min(ii+matrixBlockSize, m.rows); i++ {
+					pRow := p.data[i*p.cols : (i+1)*p.cols]
+					for k := kk; k < min(kk+matrixBlockSize, m.cols); k++ {
+						a := m.data[i*m.cols+k]
+						oRow := o.data[k*o.cols : (k+1)*o.cols]
+						for j := jj; j < min(jj+matrixBlockSize, o.cols); j++ {
+							pRow[j] += a * oRow[j]
+						}
+					}
+				}
+			}
+		}
+	}
+	return p, nil
+}
+
+//
+// LU decomposition
+//
+
+// LU is the decomposition P*A = L*U of a square matrix A with partial
+// pivoting, where L is unit lower triangular and U upper triangular
+type LU[T Float] struct {
+	lu       *Matrix[T] // L below the diagonal, U on and above it
+	perm     []int      // row i of P*A is row perm[i] of A
+	sign     T          // determinant of P
+	singular bool
+}
+
+// Decompose computes the LU decomposition of a square matrix
+// Returns ErrDimensionMismatch if the matrix is not square
+func Decompose[T Float](a *Matrix[T]) (*LU[T], error) {
+	if a.rows != a.cols {
+		return nil, fmt.Errorf("%w: %dx%d matrix is not square", ErrDimensionMismatch, a.rows, a.cols)
+	}
+	n := a.rows
+	lu := &Matrix[T]{rows: n, cols: n, data: append([]T(nil), a.data...)}
+	d := &LU[T]{lu: lu, perm: make([]int, n), sign: 1}
+	for i := range d.perm {
+		d.perm[i] = i
+	}
+
+	// A pivot is treated as zero when it is negligible next to both the row of
+	// A it comes from and its column of A, so scaling a row or a column of a
+	// nonsingular matrix never makes it singular
+	rowScale, colScale := make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			v := math.Abs(float64(a.data[i*n+j]))
+			rowScale[i] = math.Max(rowScale[i], v)
+			colScale[j] = math.Max(colScale[j], v)
+		}
+	}
+	eps := 1e-7
+	if T(1)+T(1e-10) != T(1) {
+		// T has float64 precision
+		eps = 1e-15
+	}
+	tol := float64(n) * eps
+
+	for k := 0; k < n; k++ {
+		// Partial pivoting: swap in the row with the largest value in column k
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(float64(lu.data[i*n+k])) > math.Abs(float64(lu.data[p*n+k])) {
+				p = i
+			}
+		}
+		if p != k {
+			for j := 0; j < n; j++ {
+				lu.data[k*n+j], lu.data[p*n+j] = lu.data[p*n+j], lu.data[k*n+j]
+			}
+			d.perm[k], d.perm[p] = d.perm[p], d.perm[k]
+			d.sign = -d.sign
+		}
+		pivot := lu.data[k*n+k]
+		size := math.Abs(float64(pivot))
+		if size <= tol*rowScale[d.perm[k]] && size <= tol*colScale[k] {
+			d.singular = true
+		}
+		if pivot == 0 {
+			// The rest of the column is zero too, so there is nothing to eliminate
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			f := lu.data[i*n+k] / pivot
+			lu.data[i*n+k] = f
+			for j := k + 1; j < n; j++ {
+				lu.data[i*n+j] -= f * lu.data[k*n+j]
+			}
+		}
+	}
+	return d, nil
+}
+
+// Det returns the determinant of the decomposed matrix, the product of the
+// pivots. It is close to but not always exactly zero for a singular matrix.
+func (d *LU[T]) Det() T {
+	det := d.sign
+	for i := 0; i < d.lu.rows; i++ {
+		det *= d.lu.data[i*d.lu.cols+i]
+	}
+	return det
+}
+
+// Solve returns x such that A*x = b for the decomposed matrix A
+// Returns ErrDimensionMismatch if b has the wrong length and ErrSingularMatrix
+// if A has no inverse
+func (d *LU[T]) Solve(b []T) ([]T, error) {
+	n := d.lu.rows
+	if len(b) != n {
+		return nil, fmt.Errorf("%w: %d values for a %dx%d system", ErrDimensionMismatch, len(b), n, n)
+	}
+	if d.singular {
+		return nil, ErrSingularMatrix
+	}
+	x := make([]T, n)
+	// Forward substitution with L, then back substitution with U
+	for i := 0; i < n; i++ {
+		x[i] = b[d.perm[i]]
+		for j := 0; j < i; j++ {
+			x[i] -= d.lu.data[i*n+j] * x[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			x[i] -= d.lu.data[i*n+j] * x[j]
+		}
+		x[i] /= d.lu.data[i*n+i]
+	}
+	return x, nil
+}
+
+// Inverse returns the inverse of the decomposed matrix
+// Returns ErrSingularMatrix if it has none
+func (d *LU[T]) Inverse() (*Matrix[T], error) {
+	n := d.lu.rows
+	inv := &Matrix[T]{rows: n, cols: n, data: make([]T, n*n)}
+	e := make([]T, n)
+	for j := 0; j < n; j++ {
+		clear(e)
+		e[j] = 1
+		col, err := d.Solve(e)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range col {
+			inv.data[i*n+j] = v
+		}
+	}
+	return inv, nil
+}
+
+// Determinant returns the determinant of a square matrix
+// Returns ErrDimensionMismatch if the matrix is not square
+func Determinant[T Float](a *Matrix[T]) (T, error) {
+	d, err := Decompose(a)
+	if err != nil {
+		return 0, err
+	}
+	return d.Det(), nil
+}
+
+// Inverse returns the inverse of a square matrix
+// Returns ErrDimensionMismatch if the matrix is not square and
+// ErrSingularMatrix if it has no inverse
+func Inverse[T Float](a *Matrix[T]) (*Matrix[T], error) {
+	d, err := Decompose(a)
+	if err != nil {
+		return nil, err
+	}
+	return d.Inverse()
+}
+
+// Solve returns x such that a*x = b
+// Returns ErrDimensionMismatch if the dimensions do not fit and
+// ErrSingularMatrix if a has no inverse
+func Solve[T Float](a *Matrix[T], b []T) ([]T, error) {
+	d, err := Decompose(a)
+	if err != nil {
+		return nil, err
+	}
+	return d.Solve(b)
+}
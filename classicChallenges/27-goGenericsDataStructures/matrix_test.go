@@ -0,0 +1,217 @@
+package generics
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func mustMatrix[T Number](t testing.TB, rows [][]T) *Matrix[T] {
+	t.Helper()
+	m, err := NewMatrixFromRows(rows)
+	if err != nil {
+		t.Fatalf("Failed to create matrix: %v", err)
+	}
+	return m
+}
+
+func randomMatrix(rng *rand.Rand, rows, cols int) *Matrix[float64] {
+	m := &Matrix[float64]{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+	for i := range m.data {
+		m.data[i] = rng.Float64()*2 - 1
+	}
+	return m
+}
+
+// closeTo returns true if all values of a and b differ by at most tol
+func closeTo(a, b *Matrix[float64], tol float64) bool {
+	if a.rows != b.rows || a.cols != b.cols {
+		return false
+	}
+	for i := range a.data {
+		if math.Abs(a.data[i]-b.data[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMatrixBasics(t *testing.T) {
+	a := mustMatrix(t, [][]int{{1, 2, 3}, {4, 5, 6}})
+	b := mustMatrix(t, [][]int{{7, 8}, {9, 10}, {11, 12}})
+
+	if got := a.Transpose(); !got.Equal(mustMatrix(t, [][]int{{1, 4}, {2, 5}, {3, 6}})) {
+		t.Errorf("Expected transpose [[1 4] [2 5] [3 6]], got\n%s", got)
+	}
+
+	product, err := a.Mul(b)
+	if err != nil {
+		t.Fatalf("Failed to multiply: %v", err)
+	}
+	if !product.Equal(mustMatrix(t, [][]int{{58, 64}, {139, 154}})) {
+		t.Errorf("Expected product [[58 64] [139 154]], got\n%s", product)
+	}
+
+	sum, err := a.Add(a)
+	if err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if v, _ := sum.At(1, 2); v != 12 {
+		t.Errorf("Expected 12, got %d", v)
+	}
+
+	id, err := Identity[int](3)
+	if err != nil {
+		t.Fatalf("Failed to create identity: %v", err)
+	}
+	if got, _ := b.Transpose().Mul(id); !got.Equal(b.Transpose()) {
+		t.Errorf("Expected multiplying by the identity to change nothing, got\n%s", got)
+	}
+
+	if err := a.Set(0, 0, 100); err != nil {
+		t.Errorf("Failed to set: %v", err)
+	}
+	if row, _ := a.Row(0); row[0] != 100 {
+		t.Errorf("Expected 100, got %v", row)
+	}
+}
+
+func TestMatrixErrors(t *testing.T) {
+	a := mustMatrix(t, [][]float64{{1, 2, 3}, {4, 5, 6}})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"Ragged rows", func() error { _, err := NewMatrixFromRows([][]int{{1, 2}, {3}}); return err }(), ErrDimensionMismatch},
+		{"Negative size", func() error { _, err := NewMatrix[int](-1, 2); return err }(), ErrDimensionMismatch},
+		{"Add", func() error { _, err := a.Add(a.Transpose()); return err }(), ErrDimensionMismatch},
+		{"Mul", func() error { _, err := a.Mul(a); return err }(), ErrDimensionMismatch},
+		{"Determinant of non-square", func() error { _, err := Determinant(a); return err }(), ErrDimensionMismatch},
+		{"Solve with wrong length", func() error { _, err := Solve(mustMatrix(t, [][]float64{{1}}), []float64{1, 2}); return err }(), ErrDimensionMismatch},
+		{"At", func() error { _, err := a.At(2, 0); return err }(), ErrIndexOutOfRange},
+		{"Set", a.Set(0, 3, 1), ErrIndexOutOfRange},
+		{"Inverse of singular", func() error { _, err := Inverse(mustMatrix(t, [][]float64{{1, 2}, {2, 4}})); return err }(), ErrSingularMatrix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tt.err)
+			}
+		})
+	}
+}
+
+func TestDeterminant(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     [][]float64
+		expected float64
+	}{
+		{"1x1", [][]float64{{-3}}, -3},
+		{"2x2", [][]float64{{4, 6}, {3, 8}}, 14},
+		{"Needs pivoting", [][]float64{{0, 1}, {1, 0}}, -1},
+		{"3x3", [][]float64{{6, 1, 1}, {4, -2, 5}, {2, 8, 7}}, -306},
+		{"Singular", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, 0},
+		{"Empty", [][]float64{}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			det, err := Determinant(mustMatrix(t, tt.rows))
+			if err != nil {
+				t.Fatalf("Failed to compute determinant: %v", err)
+			}
+			if math.Abs(det-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, det)
+			}
+		})
+	}
+
+	// Badly scaled matrices are not singular
+	for _, rows := range [][][]float64{
+		{{1e20, 0}, {0, 1}},
+		{{1e-20, 0}, {0, 1e-20}},
+		{{1e20, 1e20}, {1, 2}},
+		{{1e20, 1}, {1e20, 2}},
+	} {
+		a := mustMatrix(t, rows)
+		expected := rows[0][0]*rows[1][1] - rows[0][1]*rows[1][0]
+		if det, err := Determinant(a); err != nil || math.Abs(det-expected) > 1e-9*math.Abs(expected) {
+			t.Errorf("Expected determinant %v of %v, got %v (%v)", expected, rows, det, err)
+		}
+		if _, err := Solve(a, []float64{1, 1}); err != nil {
+			t.Errorf("Expected %v to be solvable, got %v", rows, err)
+		}
+	}
+
+	// float32 matrices work too
+	det, err := Determinant(mustMatrix(t, [][]float32{{2, 0}, {0, 3}}))
+	if err != nil || det != 6 {
+		t.Errorf("Expected 6, got %v (%v)", det, err)
+	}
+}
+
+func TestInverseAndSolve(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	for _, n := range []int{1, 2, 5, 20} {
+		a := randomMatrix(rng, n, n)
+		inv, err := Inverse(a)
+		if err != nil {
+			t.Fatalf("Failed to invert %dx%d matrix: %v", n, n, err)
+		}
+		id, _ := Identity[float64](n)
+		if got, _ := a.Mul(inv); !closeTo(got, id, 1e-9) {
+			t.Errorf("Expected A * inverse(A) to be the identity, got\n%s", got)
+		}
+
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = float64(i + 1)
+		}
+		xm := &Matrix[float64]{rows: n, cols: 1, data: x}
+		bm, _ := a.Mul(xm)
+		got, err := Solve(a, bm.data)
+		if err != nil {
+			t.Fatalf("Failed to solve: %v", err)
+		}
+		if !closeTo(&Matrix[float64]{rows: n, cols: 1, data: got}, xm, 1e-9) {
+			t.Errorf("Expected solution %v, got %v", x, got)
+		}
+	}
+}
+
+func TestBlockedMul(t *testing.T) {
+	// Sizes that are not multiples of the block size
+	rng := rand.New(rand.NewSource(10))
+	a, b := randomMatrix(rng, 70, 130), randomMatrix(rng, 130, 65)
+	got, err := a.Mul(b)
+	if err != nil {
+		t.Fatalf("Failed to multiply: %v", err)
+	}
+	expected, _ := NewMatrix[float64](70, 65)
+	for i := 0; i < 70; i++ {
+		for j := 0; j < 65; j++ {
+			var sum float64
+			for k := 0; k < 130; k++ {
+				sum += a.data[i*130+k] * b.data[k*65+j]
+			}
+			expected.data[i*65+j] = sum
+		}
+	}
+	if !closeTo(got, expected, 1e-9) {
+		t.Error("Expected blocked product to match the naive product")
+	}
+}
+
+func BenchmarkMatrixMul(b *testing.B) {
+	rng := rand.New(rand.NewSource(12))
+	x, y := randomMatrix(rng, 256, 256), randomMatrix(rng, 256, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Mul(y)
+	}
+}
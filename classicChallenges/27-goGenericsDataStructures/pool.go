@@ -0,0 +1,172 @@
+package generics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+//
+// Object pool
+//
+
+// PoolStats counts what happened to values of a pool
+type PoolStats struct {
+	Gets    int // values handed out by Get
+	News    int // values Get had to create because none was idle
+	Puts    int // values returned with Put
+	Dropped int // returned values discarded by the reset hook or the idle limit
+}
+
+// Pool is a typed, concurrency-safe free list of reusable values. Unlike
+// sync.Pool it keeps idle values across garbage collections, up to maxIdle.
+type Pool[T any] struct {
+	mu      sync.Mutex
+	idle    []T
+	newFn   func() T
+	reset   func(T) bool
+	maxIdle int
+	stats   PoolStats
+}
+
+// NewPool creates a new pool that creates values with newFn. When a value is
+// put back, reset (if not nil) prepares it for reuse; it returns false to
+// drop the value instead, e.g. a buffer that grew too large. At most maxIdle
+// values are kept.
+func NewPool[T any](newFn func() T, reset func(T) bool, maxIdle int) (*Pool[T], error) {
+	if newFn == nil {
+		return nil, errors.New("pool needs a function to create values")
+	}
+	if maxIdle < 0 {
+		return nil, fmt.Errorf("maximum idle values cannot be negative, got %d", maxIdle)
+	}
+	return &Pool[T]{
+		idle:    make([]T, 0, maxIdle),
+		newFn:   newFn,
+		reset:   reset,
+		maxIdle: maxIdle,
+	}, nil
+}
+
+// Get returns an idle value, or a new one if none is idle
+func (p *Pool[T]) Get() T {
+	p.mu.Lock()
+	p.stats.Gets++
+	if last := len(p.idle) - 1; last >= 0 {
+		v := p.idle[last]
+		var zero T
+		p.idle[last] = zero
+		p.idle = p.idle[:last]
+		p.mu.Unlock()
+		return v
+	}
+	p.stats.News++
+	p.mu.Unlock()
+	return p.newFn()
+}
+
+// Put returns a value to the pool. The caller must not use it afterwards.
+func (p *Pool[T]) Put(v T) {
+	// Run the hook outside the lock, it may be slow
+	keep := p.reset == nil || p.reset(v)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Puts++
+	if !keep || len(p.idle) >= p.maxIdle {
+		p.stats.Dropped++
+		return
+	}
+	p.idle = append(p.idle, v)
+}
+
+// Idle returns the number of values waiting to be reused
+func (p *Pool[T]) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Stats returns counts of the pool's activity so far
+func (p *Pool[T]) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+//
+// Slab allocator
+//
+
+// Slab allocates values of a fixed-size type, such as the nodes of a linked
+// structure, in chunks: one allocation serves chunkSize values, and freed
+// values are reused before new chunks are allocated. A Slab is not safe for
+// concurrent use.
+type Slab[T any] struct {
+	chunkSize int
+	chunks    [][]T
+	used      int // chunks handed out from; the last one may have room left
+	next      int // index of the next unused value in chunks[used-1]
+	free      []*T
+	live      int
+}
+
+// NewSlab creates a new slab that allocates chunkSize values at a time
+func NewSlab[T any](chunkSize int) (*Slab[T], error) {
+	if chunkSize < 1 {
+		return nil, fmt.Errorf("chunk size must be positive, got %d", chunkSize)
+	}
+	return &Slab[T]{chunkSize: chunkSize, next: chunkSize}, nil
+}
+
+// Alloc returns a pointer to a zero value of T
+func (s *Slab[T]) Alloc() *T {
+	s.live++
+	if last := len(s.free) - 1; last >= 0 {
+		v := s.free[last]
+		s.free[last] = nil
+		s.free = s.free[:last]
+		return v
+	}
+	if s.next == s.chunkSize {
+		// Chunks kept by Reset are reused before allocating new ones
+		if s.used == len(s.chunks) {
+			s.chunks = append(s.chunks, make([]T, s.chunkSize))
+		}
+		s.used++
+		s.next = 0
+	}
+	v := &s.chunks[s.used-1][s.next]
+	s.next++
+	return v
+}
+
+// Free returns v for reuse and zeroes it, so it does not keep other values
+// alive. v must come from Alloc on this slab and must not be used afterwards.
+func (s *Slab[T]) Free(v *T) {
+	var zero T
+	*v = zero
+	s.free = append(s.free, v)
+	s.live--
+}
+
+// Live returns the number of values allocated and not freed
+func (s *Slab[T]) Live() int {
+	return s.live
+}
+
+// Chunks returns the number of chunks allocated so far
+func (s *Slab[T]) Chunks() int {
+	return len(s.chunks)
+}
+
+// Reset frees all values at once, keeping the chunks for reuse. Pointers
+// returned by Alloc must not be used afterwards.
+func (s *Slab[T]) Reset() {
+	for _, c := range s.chunks[:s.used] {
+		clear(c)
+	}
+	clear(s.free)
+	s.free = s.free[:0]
+	s.used, s.next, s.live = 0, s.chunkSize, 0
+}
@@ -0,0 +1,234 @@
+package generics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func newBufferPool(t testing.TB, maxIdle int) *Pool[*bytes.Buffer] {
+	t.Helper()
+	p, err := NewPool(
+		func() *bytes.Buffer { return new(bytes.Buffer) },
+		func(b *bytes.Buffer) bool {
+			b.Reset()
+			// Do not keep buffers that grew large
+			return b.Cap() <= 1<<16
+		},
+		maxIdle,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	return p
+}
+
+func TestPool(t *testing.T) {
+	p := newBufferPool(t, 2)
+
+	a := p.Get()
+	a.WriteString("hello")
+	p.Put(a)
+	if p.Idle() != 1 {
+		t.Errorf("Expected 1 idle value, got %d", p.Idle())
+	}
+	b := p.Get()
+	if b != a {
+		t.Error("Expected Get to reuse the returned buffer")
+	}
+	if b.Len() != 0 {
+		t.Errorf("Expected the reset hook to empty the buffer, got %q", b.String())
+	}
+
+	// The idle limit drops extra values
+	p.Put(b)
+	p.Put(new(bytes.Buffer))
+	p.Put(new(bytes.Buffer))
+	if p.Idle() != 2 {
+		t.Errorf("Expected 2 idle values, got %d", p.Idle())
+	}
+
+	// The reset hook drops oversized values
+	big := new(bytes.Buffer)
+	big.Grow(1 << 20)
+	p.Get()
+	p.Put(big)
+	if p.Idle() != 1 {
+		t.Errorf("Expected the oversized buffer to be dropped, got %d idle", p.Idle())
+	}
+
+	expected := PoolStats{Gets: 3, News: 1, Puts: 5, Dropped: 2}
+	if got := p.Stats(); got != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, got)
+	}
+
+	if _, err := NewPool[int](nil, nil, 1); err == nil {
+		t.Error("Expected an error without a constructor, but got none")
+	}
+	if _, err := NewPool(func() int { return 0 }, nil, -1); err == nil {
+		t.Error("Expected an error for a negative idle limit, but got none")
+	}
+}
+
+func TestPoolConcurrent(t *testing.T) {
+	p := newBufferPool(t, 8)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				b := p.Get()
+				b.WriteString("data")
+				p.Put(b)
+			}
+		}()
+	}
+	wg.Wait()
+	stats := p.Stats()
+	if stats.Gets != 8000 || stats.Puts != 8000 || stats.News > 8 {
+		t.Errorf("Expected 8000 gets and puts with at most 8 new values, got %+v", stats)
+	}
+}
+
+func TestPoolAllocations(t *testing.T) {
+	p := newBufferPool(t, 1)
+	p.Put(p.Get())
+	allocs := testing.AllocsPerRun(100, func() {
+		b := p.Get()
+		b.WriteString("reused")
+		p.Put(b)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations when reusing a value, got %v", allocs)
+	}
+}
+
+// listNode is a typical node of a linked structure
+type listNode struct {
+	value int
+	next  *listNode
+}
+
+func TestSlab(t *testing.T) {
+	s, err := NewSlab[listNode](4)
+	if err != nil {
+		t.Fatalf("Failed to create slab: %v", err)
+	}
+
+	var head *listNode
+	for i := 0; i < 10; i++ {
+		n := s.Alloc()
+		if n.value != 0 || n.next != nil {
+			t.Fatalf("Expected a zero node, got %+v", *n)
+		}
+		n.value, n.next = i, head
+		head = n
+	}
+	if s.Live() != 10 || s.Chunks() != 3 {
+		t.Errorf("Expected 10 live nodes in 3 chunks, got %d in %d", s.Live(), s.Chunks())
+	}
+	sum := 0
+	for n := head; n != nil; n = n.next {
+		sum += n.value
+	}
+	if sum != 45 {
+		t.Errorf("Expected the list to sum to 45, got %d", sum)
+	}
+
+	// Freed nodes are zeroed and reused first
+	second := head.next
+	s.Free(head)
+	if head.next != nil {
+		t.Error("Expected Free to zero the node")
+	}
+	if again := s.Alloc(); again != head {
+		t.Error("Expected Alloc to reuse the freed node")
+	}
+
+	// Reset keeps the chunks
+	s.Reset()
+	if second.value != 0 || s.Live() != 0 {
+		t.Errorf("Expected Reset to zero all nodes, got %+v and %d live", *second, s.Live())
+	}
+	for i := 0; i < 12; i++ {
+		s.Alloc()
+	}
+	if s.Chunks() != 3 {
+		t.Errorf("Expected Reset chunks to be reused, got %d chunks", s.Chunks())
+	}
+
+	if _, err := NewSlab[listNode](0); err == nil {
+		t.Error("Expected an error for a zero chunk size, but got none")
+	}
+}
+
+func TestSlabAllocations(t *testing.T) {
+	s, err := NewSlab[listNode](100)
+	if err != nil {
+		t.Fatalf("Failed to create slab: %v", err)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		s.Reset()
+		var head *listNode
+		for i := 0; i < 1000; i++ {
+			n := s.Alloc()
+			n.value, n.next = i, head
+			head = n
+		}
+	})
+	// After the first run the chunks are reused
+	if allocs > 1 {
+		t.Errorf("Expected at most 1 allocation per 1000 nodes, got %v", allocs)
+	}
+}
+
+func BenchmarkBufferPool(b *testing.B) {
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := new(bytes.Buffer)
+			buf.WriteString("some data to format")
+			_ = buf.String()
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		p := newBufferPool(b, 4)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := p.Get()
+			buf.WriteString("some data to format")
+			_ = buf.Bytes()
+			p.Put(buf)
+		}
+	})
+}
+
+func BenchmarkLinkedListNodes(b *testing.B) {
+	const n = 1000
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var head *listNode
+			for j := 0; j < n; j++ {
+				head = &listNode{value: j, next: head}
+			}
+		}
+	})
+	b.Run("Slab", func(b *testing.B) {
+		s, err := NewSlab[listNode](256)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.Reset()
+			var head *listNode
+			for j := 0; j < n; j++ {
+				node := s.Alloc()
+				node.value, node.next = j, head
+				head = node
+			}
+		}
+	})
+}
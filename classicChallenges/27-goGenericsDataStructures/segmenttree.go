@@ -0,0 +1,182 @@
+package generics
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SegmentTreeOps describes the values of a segment tree and the updates that
+// can be applied to ranges of them
+type SegmentTreeOps[T, U any] struct {
+	// Combine joins the aggregates of two adjacent ranges; it must be associative
+	Combine func(a, b T) T
+	// Identity is the aggregate of an empty range: Combine(Identity, x) == x
+	Identity T
+	// Apply returns the aggregate of a range of length values after update u.
+	// Only needed for range updates.
+	Apply func(u U, aggregate T, length int) T
+	// Compose returns the update equivalent to older followed by newer.
+	// Only needed for range updates.
+	Compose func(newer, older U) U
+}
+
+// SegmentTree holds a sequence of values and answers range queries with a
+// user-supplied associative combine function in O(log n). Range updates are
+// applied lazily, also in O(log n).
+type SegmentTree[T, U any] struct {
+	ops     SegmentTreeOps[T, U]
+	n       int
+	agg     []T
+	pending []U
+	has     []bool // whether pending holds an update for the node's children
+}
+
+// NewSegmentTree creates a new segment tree holding values
+func NewSegmentTree[T, U any](values []T, ops SegmentTreeOps[T, U]) (*SegmentTree[T, U], error) {
+	if ops.Combine == nil {
+		return nil, errors.New("segment tree needs a combine function")
+	}
+	if (ops.Apply == nil) != (ops.Compose == nil) {
+		return nil, errors.New("range updates need both an apply and a compose function")
+	}
+	st := &SegmentTree[T, U]{
+		ops:     ops,
+		n:       len(values),
+		agg:     make([]T, 4*max(len(values), 1)),
+		pending: make([]U, 4*max(len(values), 1)),
+		has:     make([]bool, 4*max(len(values), 1)),
+	}
+	if st.n > 0 {
+		st.build(1, 0, st.n, values)
+	}
+	return st, nil
+}
+
+// build fills node, which covers the values in [lo, hi)
+func (st *SegmentTree[T, U]) build(node, lo, hi int, values []T) {
+	if hi-lo == 1 {
+		st.agg[node] = values[lo]
+		return
+	}
+	mid := (lo + hi) / 2
+	st.build(2*node, lo, mid, values)
+	st.build(2*node+1, mid, hi, values)
+	st.agg[node] = st.ops.Combine(st.agg[2*node], st.agg[2*node+1])
+}
+
+// Len returns the number of values
+func (st *SegmentTree[T, U]) Len() int {
+	return st.n
+}
+
+func (st *SegmentTree[T, U]) checkRange(from, to int) error {
+	if from < 0 || to > st.n || from > to {
+		return fmt.Errorf("%w: [%d, %d) not within [0, %d)", ErrIndexOutOfRange, from, to, st.n)
+	}
+	return nil
+}
+
+// Query returns the combined values at indexes from from (inclusive) to to (exclusive)
+// Returns ErrIndexOutOfRange if the range is not within the tree
+func (st *SegmentTree[T, U]) Query(from, to int) (T, error) {
+	if err := st.checkRange(from, to); err != nil {
+		var zero T
+		return zero, err
+	}
+	if from == to {
+		return st.ops.Identity, nil
+	}
+	return st.query(1, 0, st.n, from, to), nil
+}
+
+func (st *SegmentTree[T, U]) query(node, lo, hi, from, to int) T {
+	if to <= lo || hi <= from {
+		return st.ops.Identity
+	}
+	if from <= lo && hi <= to {
+		return st.agg[node]
+	}
+	st.push(node, lo, hi)
+	mid := (lo + hi) / 2
+	return st.ops.Combine(st.query(2*node, lo, mid, from, to), st.query(2*node+1, mid, hi, from, to))
+}
+
+// Update applies u to the values at indexes from from (inclusive) to to (exclusive)
+// Returns ErrIndexOutOfRange if the range is not within the tree
+func (st *SegmentTree[T, U]) Update(from, to int, u U) error {
+	if st.ops.Apply == nil {
+		return errors.New("segment tree was created without range updates")
+	}
+	if err := st.checkRange(from, to); err != nil {
+		return err
+	}
+	if from < to {
+		st.update(1, 0, st.n, from, to, u)
+	}
+	return nil
+}
+
+func (st *SegmentTree[T, U]) update(node, lo, hi, from, to int, u U) {
+	if to <= lo || hi <= from {
+		return
+	}
+	if from <= lo && hi <= to {
+		st.applyTo(node, lo, hi, u)
+		return
+	}
+	st.push(node, lo, hi)
+	mid := (lo + hi) / 2
+	st.update(2*node, lo, mid, from, to, u)
+	st.update(2*node+1, mid, hi, from, to, u)
+	st.agg[node] = st.ops.Combine(st.agg[2*node], st.agg[2*node+1])
+}
+
+// applyTo updates the aggregate of node and records u for its children
+func (st *SegmentTree[T, U]) applyTo(node, lo, hi int, u U) {
+	st.agg[node] = st.ops.Apply(u, st.agg[node], hi-lo)
+	if hi-lo == 1 {
+		return
+	}
+	if st.has[node] {
+		st.pending[node] = st.ops.Compose(u, st.pending[node])
+	} else {
+		st.pending[node], st.has[node] = u, true
+	}
+}
+
+// push hands the pending update of node down to its children
+func (st *SegmentTree[T, U]) push(node, lo, hi int) {
+	if !st.has[node] {
+		return
+	}
+	mid := (lo + hi) / 2
+	st.applyTo(2*node, lo, mid, st.pending[node])
+	st.applyTo(2*node+1, mid, hi, st.pending[node])
+	var zero U
+	st.pending[node], st.has[node] = zero, false
+}
+
+// Set replaces the value at index i
+// Returns ErrIndexOutOfRange if i is not a valid index
+func (st *SegmentTree[T, U]) Set(i int, value T) error {
+	if i < 0 || i >= st.n {
+		return fmt.Errorf("%w: %d not in [0, %d)", ErrIndexOutOfRange, i, st.n)
+	}
+	st.set(1, 0, st.n, i, value)
+	return nil
+}
+
+func (st *SegmentTree[T, U]) set(node, lo, hi, i int, value T) {
+	if hi-lo == 1 {
+		st.agg[node] = value
+		return
+	}
+	st.push(node, lo, hi)
+	mid := (lo + hi) / 2
+	if i < mid {
+		st.set(2*node, lo, mid, i, value)
+	} else {
+		st.set(2*node+1, mid, hi, i, value)
+	}
+	st.agg[node] = st.ops.Combine(st.agg[2*node], st.agg[2*node+1])
+}
@@ -0,0 +1,127 @@
+package generics
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// rangeAddSum supports adding to ranges and summing them
+var rangeAddSum = SegmentTreeOps[int, int]{
+	Combine:  func(a, b int) int { return a + b },
+	Identity: 0,
+	Apply:    func(u, sum, length int) int { return sum + u*length },
+	Compose:  func(newer, older int) int { return newer + older },
+}
+
+// rangeAssignMin supports assigning to ranges and taking their minimum
+var rangeAssignMin = SegmentTreeOps[int, int]{
+	Combine:  func(a, b int) int { return min(a, b) },
+	Identity: math.MaxInt,
+	Apply:    func(u, _, _ int) int { return u },
+	Compose:  func(newer, _ int) int { return newer },
+}
+
+func TestSegmentTree(t *testing.T) {
+	st, err := NewSegmentTree([]int{5, 3, 8, 1, 9}, SegmentTreeOps[int, struct{}]{
+		Combine:  func(a, b int) int { return max(a, b) },
+		Identity: math.MinInt,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to int
+		expected int
+	}{
+		{"All", 0, 5, 9},
+		{"Left", 0, 2, 5},
+		{"Middle", 1, 4, 8},
+		{"Empty", 2, 2, math.MinInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := st.Query(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+
+	if err := st.Set(4, 0); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+	if got, _ := st.Query(0, 5); got != 8 {
+		t.Errorf("Expected 8 after Set, got %d", got)
+	}
+	if _, err := st.Query(3, 6); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected ErrIndexOutOfRange, got %v", err)
+	}
+	if err := st.Update(0, 1, struct{}{}); err == nil {
+		t.Error("Expected an error for an update without Apply, but got none")
+	}
+	if _, err := NewSegmentTree([]int{1}, SegmentTreeOps[int, int]{}); err == nil {
+		t.Error("Expected an error without a combine function, but got none")
+	}
+}
+
+func TestSegmentTreeLazyUpdates(t *testing.T) {
+	tests := []struct {
+		name   string
+		ops    SegmentTreeOps[int, int]
+		update func(v, u int) int
+	}{
+		{"Range add, sum", rangeAddSum, func(v, u int) int { return v + u }},
+		{"Range assign, min", rangeAssignMin, func(_, u int) int { return u }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(11))
+			values := make([]int, 1+rng.Intn(60))
+			for i := range values {
+				values[i] = rng.Intn(100)
+			}
+			st, err := NewSegmentTree(values, tt.ops)
+			if err != nil {
+				t.Fatalf("Failed to create tree: %v", err)
+			}
+
+			for round := 0; round < 2000; round++ {
+				from := rng.Intn(len(values) + 1)
+				to := from + rng.Intn(len(values)+1-from)
+				switch rng.Intn(3) {
+				case 0:
+					u := rng.Intn(50) - 25
+					if err := st.Update(from, to, u); err != nil {
+						t.Fatalf("Failed to update: %v", err)
+					}
+					for i := from; i < to; i++ {
+						values[i] = tt.update(values[i], u)
+					}
+				case 1:
+					if from < len(values) {
+						v := rng.Intn(100)
+						st.Set(from, v)
+						values[from] = v
+					}
+				default:
+					expected := tt.ops.Identity
+					for _, v := range values[from:to] {
+						expected = tt.ops.Combine(expected, v)
+					}
+					if got, _ := st.Query(from, to); got != expected {
+						t.Fatalf("Expected query of [%d, %d) to be %d, got %d", from, to, expected, got)
+					}
+				}
+			}
+		})
+	}
+}
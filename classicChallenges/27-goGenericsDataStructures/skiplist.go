@@ -0,0 +1,135 @@
+package generics
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// skipListMaxLevel allows about 4^16 keys before searches slow down
+const skipListMaxLevel = 16
+
+// SkipListMap is an ordered map that is safe for concurrent use. Reads never
+// block: they follow atomic pointers, while writers take a mutex among
+// themselves. Iteration is weakly consistent, it may or may not see writes
+// made while it runs.
+type SkipListMap[K cmp.Ordered, V any] struct {
+	head *skipNode[K, V]
+	mu   sync.Mutex // serializes writers
+	size atomic.Int64
+}
+
+type skipNode[K cmp.Ordered, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	next  []atomic.Pointer[skipNode[K, V]]
+}
+
+// NewSkipListMap creates a new empty skip list map
+func NewSkipListMap[K cmp.Ordered, V any]() *SkipListMap[K, V] {
+	return &SkipListMap[K, V]{
+		head: &skipNode[K, V]{next: make([]atomic.Pointer[skipNode[K, V]], skipListMaxLevel)},
+	}
+}
+
+// Len returns the number of keys in the map
+func (m *SkipListMap[K, V]) Len() int {
+	return int(m.size.Load())
+}
+
+// Get returns the value stored for key
+// Returns ErrKeyNotFound if the key is not present
+func (m *SkipListMap[K, V]) Get(key K) (V, error) {
+	if n := m.seek(key, nil); n != nil && n.key == key {
+		return *n.value.Load(), nil
+	}
+	var zero V
+	return zero, ErrKeyNotFound
+}
+
+// seek returns the first node with a key not less than key. If preds is not
+// nil, it receives the last node before key on every level.
+func (m *SkipListMap[K, V]) seek(key K, preds []*skipNode[K, V]) *skipNode[K, V] {
+	n := m.head
+	var next *skipNode[K, V]
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		for next = n.next[level].Load(); next != nil && next.key < key; next = n.next[level].Load() {
+			n = next
+		}
+		if preds != nil {
+			preds[level] = n
+		}
+	}
+	return next
+}
+
+// Put stores value for key, replacing any previous value
+func (m *SkipListMap[K, V]) Put(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	preds := make([]*skipNode[K, V], skipListMaxLevel)
+	if n := m.seek(key, preds); n != nil && n.key == key {
+		n.value.Store(&value)
+		return
+	}
+
+	// Each level is used with a quarter of the probability of the one below
+	level := 1 + bits.TrailingZeros64(rand.Uint64()|1<<(2*skipListMaxLevel-2))/2
+	n := &skipNode[K, V]{key: key, next: make([]atomic.Pointer[skipNode[K, V]], level)}
+	n.value.Store(&value)
+	for i := 0; i < level; i++ {
+		n.next[i].Store(preds[i].next[i].Load())
+	}
+	// Link bottom-up: once readers can reach the node, its own links are set
+	for i := 0; i < level; i++ {
+		preds[i].next[i].Store(n)
+	}
+	m.size.Add(1)
+}
+
+// Delete removes key from the map
+// Returns ErrKeyNotFound if the key is not present
+func (m *SkipListMap[K, V]) Delete(key K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	preds := make([]*skipNode[K, V], skipListMaxLevel)
+	n := m.seek(key, preds)
+	if n == nil || n.key != key {
+		return ErrKeyNotFound
+	}
+	// The node's own links and value stay intact, so readers already on it
+	// can move on as if they had passed it just before it was removed
+	for i := len(n.next) - 1; i >= 0; i-- {
+		preds[i].next[i].Store(n.next[i].Load())
+	}
+	m.size.Add(-1)
+	return nil
+}
+
+// All returns an iterator over all keys and values in increasing key order
+func (m *SkipListMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.ascend(m.head.next[0].Load(), nil, yield)
+	}
+}
+
+// Range returns an iterator over the keys from from (inclusive) to to
+// (exclusive) and their values, in increasing key order
+func (m *SkipListMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.ascend(m.seek(from, nil), &to, yield)
+	}
+}
+
+func (m *SkipListMap[K, V]) ascend(n *skipNode[K, V], to *K, yield func(K, V) bool) {
+	for ; n != nil && (to == nil || n.key < *to); n = n.next[0].Load() {
+		if !yield(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
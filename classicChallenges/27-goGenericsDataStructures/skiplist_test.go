@@ -0,0 +1,157 @@
+package generics
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSkipListMap(t *testing.T) {
+	m := NewSkipListMap[string, int]()
+	if _, err := m.Get("a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	m.Put("b", 2)
+	m.Put("a", 1)
+	m.Put("c", 3)
+	m.Put("b", 20)
+	if m.Len() != 3 {
+		t.Errorf("Expected Len 3, got %d", m.Len())
+	}
+	if v, err := m.Get("b"); err != nil || v != 20 {
+		t.Errorf("Expected 20, got %d (%v)", v, err)
+	}
+
+	if err := m.Delete("a"); err != nil {
+		t.Errorf("Expected Delete to succeed, got %v", err)
+	}
+	if err := m.Delete("a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	var keys []string
+	for k := range m.All() {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []string{"b", "c"}) {
+		t.Errorf("Expected [b c], got %v", keys)
+	}
+}
+
+func TestSkipListMapAgainstMap(t *testing.T) {
+	m := NewSkipListMap[int, int]()
+	expected := map[int]int{}
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 10000; i++ {
+		key := rng.Intn(1000)
+		if rng.Intn(3) == 0 {
+			err := m.Delete(key)
+			if _, ok := expected[key]; ok != (err == nil) {
+				t.Fatalf("Delete(%d) returned %v, key present: %v", key, err, ok)
+			}
+			delete(expected, key)
+		} else {
+			m.Put(key, i)
+			expected[key] = i
+		}
+	}
+
+	if m.Len() != len(expected) {
+		t.Errorf("Expected Len %d, got %d", len(expected), m.Len())
+	}
+	var keys []int
+	for k, v := range m.All() {
+		if expected[k] != v {
+			t.Errorf("Expected %d for key %d, got %d", expected[k], k, v)
+		}
+		keys = append(keys, k)
+	}
+	if !slices.IsSorted(keys) || len(keys) != len(expected) {
+		t.Errorf("Expected %d sorted keys, got %v", len(expected), keys)
+	}
+
+	var inRange []int
+	for k := range m.Range(100, 200) {
+		inRange = append(inRange, k)
+	}
+	for _, k := range inRange {
+		if k < 100 || k >= 200 {
+			t.Errorf("Expected keys in [100, 200), got %d", k)
+		}
+	}
+	count := 0
+	for k := range expected {
+		if k >= 100 && k < 200 {
+			count++
+		}
+	}
+	if len(inRange) != count {
+		t.Errorf("Expected %d keys in range, got %d", count, len(inRange))
+	}
+}
+
+func TestSkipListMapConcurrent(t *testing.T) {
+	m := NewSkipListMap[int, int]()
+	// Even keys are never deleted, so readers must always find them
+	for k := 0; k < 1000; k += 2 {
+		m.Put(k, k)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 2000; i++ {
+				k := 2*rng.Intn(500) + 1
+				if rng.Intn(2) == 0 {
+					m.Put(k, k)
+				} else {
+					m.Delete(k)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(100 + r)))
+			for i := 0; i < 5000; i++ {
+				k := 2 * rng.Intn(500)
+				if v, err := m.Get(k); err != nil || v != k {
+					t.Errorf("Expected %d for key %d, got %d (%v)", k, k, v, err)
+					return
+				}
+			}
+			prev := -1
+			for k := range m.All() {
+				if k <= prev {
+					t.Errorf("Expected increasing keys, got %d after %d", k, prev)
+					return
+				}
+				prev = k
+			}
+		}(r)
+	}
+	wg.Wait()
+}
+
+func BenchmarkSkipListMapParallelGet(b *testing.B) {
+	m := NewSkipListMap[int, int]()
+	for i := 0; i < 100000; i++ {
+		m.Put(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Get(i % 100000)
+			i++
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package generics
+
+import (
+	"cmp"
+	"fmt"
+	"math/bits"
+)
+
+// insertionSortThreshold is the length below which the sorts switch to insertion sort
+const insertionSortThreshold = 12
+
+// maxCountingRange is the largest span of values CountingSort allocates counters for
+const maxCountingRange = 1 << 24
+
+//
+// Merge sort
+//
+
+// MergeSort sorts s in increasing order. The sort is stable and takes
+// O(n log n) time and O(n) extra space.
+func MergeSort[T cmp.Ordered](s []T) {
+	MergeSortFunc(s, cmp.Compare[T])
+}
+
+// MergeSortFunc sorts s in the order given by compare, which returns a
+// negative number when a < b, zero when a == b and a positive number when
+// a > b. Equal elements keep their original order.
+func MergeSortFunc[T any](s []T, compare func(a, b T) int) {
+	if len(s) < 2 {
+		return
+	}
+	// Sort short runs in place, then merge runs of doubling width bottom-up
+	for lo := 0; lo < len(s); lo += insertionSortThreshold {
+		insertionSort(s[lo:min(lo+insertionSortThreshold, len(s))], compare)
+	}
+	buf := make([]T, len(s))
+	src, dst := s, buf
+	for width := insertionSortThreshold; width < len(s); width *= 2 {
+		for lo := 0; lo < len(s); lo += 2 * width {
+			mid, hi := min(lo+width, len(s)), min(lo+2*width, len(s))
+			merge(dst[lo:hi], src[lo:mid], src[mid:hi], compare)
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+// merge merges the sorted slices a and b into dst, taking from a on ties
+func merge[T any](dst, a, b []T, compare func(a, b T) int) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if compare(b[j], a[i]) < 0 {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+// insertionSort is a stable sort for short slices
+func insertionSort[T any](s []T, compare func(a, b T) int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && compare(s[j], s[j-1]) < 0; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+//
+// Introsort
+//
+
+// IntroSort sorts s in increasing order in place. The sort is not stable.
+func IntroSort[T cmp.Ordered](s []T) {
+	IntroSortFunc(s, cmp.Compare[T])
+}
+
+// IntroSortFunc sorts s in the order given by compare, see MergeSortFunc. It
+// runs quicksort, switching to heapsort when partitions go badly so the worst
+// case stays O(n log n). The sort is not stable.
+func IntroSortFunc[T any](s []T, compare func(a, b T) int) {
+	if len(s) > 1 {
+		introSort(s, 2*bits.Len(uint(len(s))), compare)
+	}
+}
+
+func introSort[T any](s []T, depth int, compare func(a, b T) int) {
+	for len(s) > insertionSortThreshold {
+		if depth == 0 {
+			heapSort(s, compare)
+			return
+		}
+		depth--
+		p := partition(s, compare)
+		// Recurse into the smaller side, loop on the larger to bound the stack
+		if p < len(s)-p {
+			introSort(s[:p], depth, compare)
+			s = s[p+1:]
+		} else {
+			introSort(s[p+1:], depth, compare)
+			s = s[:p]
+		}
+	}
+	insertionSort(s, compare)
+}
+
+// partition places a median-of-three pivot at its final index and returns it
+func partition[T any](s []T, compare func(a, b T) int) int {
+	last := len(s) - 1
+	mid := len(s) / 2
+	if compare(s[mid], s[0]) < 0 {
+		s[mid], s[0] = s[0], s[mid]
+	}
+	if compare(s[last], s[0]) < 0 {
+		s[last], s[0] = s[0], s[last]
+	}
+	if compare(s[last], s[mid]) < 0 {
+		s[last], s[mid] = s[mid], s[last]
+	}
+	// The median moves to last-1; s[0] and s[last] act as sentinels
+	s[mid], s[last-1] = s[last-1], s[mid]
+	pivot := s[last-1]
+
+	i, j := 0, last-1
+	for {
+		for i++; compare(s[i], pivot) < 0; i++ {
+		}
+		for j--; compare(pivot, s[j]) < 0; j-- {
+		}
+		if i >= j {
+			break
+		}
+		s[i], s[j] = s[j], s[i]
+	}
+	s[i], s[last-1] = s[last-1], s[i]
+	return i
+}
+
+func heapSort[T any](s []T, compare func(a, b T) int) {
+	for i := len(s)/2 - 1; i >= 0; i-- {
+		siftDown(s, i, len(s), compare)
+	}
+	for end := len(s) - 1; end > 0; end-- {
+		s[0], s[end] = s[end], s[0]
+		siftDown(s, 0, end, compare)
+	}
+}
+
+func siftDown[T any](s []T, root, end int, compare func(a, b T) int) {
+	for {
+		child := 2*root + 1
+		if child >= end {
+			return
+		}
+		if child+1 < end && compare(s[child], s[child+1]) < 0 {
+			child++
+		}
+		if compare(s[root], s[child]) >= 0 {
+			return
+		}
+		s[root], s[child] = s[child], s[root]
+		root = child
+	}
+}
+
+//
+// Radix and counting sorts
+//
+
+// integerKey maps values of an integer type to uint64 keys in the same order
+// and returns the number of bits the type uses
+func integerKey[T Integer]() (func(T) uint64, int) {
+	size := 8
+	for size < 64 && T(1)<<size != 0 {
+		size += 8
+	}
+	mask := uint64(1)<<size - 1
+	if size == 64 {
+		mask = ^uint64(0)
+	}
+	var flip uint64
+	if ^T(0) < 0 {
+		// Flipping the sign bit puts negative numbers before positive ones
+		flip = 1 << (size - 1)
+	}
+	return func(v T) uint64 { return uint64(v)&mask ^ flip }, size
+}
+
+// RadixSort sorts integers in increasing order with a least significant digit
+// radix sort on bytes, in O(n * bytes) time. The sort is stable.
+func RadixSort[T Integer](s []T) {
+	if len(s) < 2 {
+		return
+	}
+	key, size := integerKey[T]()
+	buf := make([]T, len(s))
+	src, dst := s, buf
+	for shift := 0; shift < size; shift += 8 {
+		var counts [257]int
+		for _, v := range src {
+			counts[(key(v)>>shift)&0xff+1]++
+		}
+		// All values share this byte: the pass would not move anything
+		if counts[(key(src[0])>>shift)&0xff+1] == len(src) {
+			continue
+		}
+		for i := 1; i < len(counts); i++ {
+			counts[i] += counts[i-1]
+		}
+		for _, v := range src {
+			b := (key(v) >> shift) & 0xff
+			dst[counts[b]] = v
+			counts[b]++
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+// RadixSortStrings sorts strings in increasing byte-wise order, the order of
+// the < operator, with a most significant digit radix sort
+func RadixSortStrings(s []string) {
+	radixSortStrings(s, make([]string, len(s)), 0)
+}
+
+// radixSortStrings sorts strings sharing their first depth bytes
+func radixSortStrings(s, buf []string, depth int) {
+	if len(s) <= insertionSortThreshold {
+		insertionSort(s, func(a, b string) int { return cmp.Compare(a[depth:], b[depth:]) })
+		return
+	}
+	// Bucket 0 holds strings that end at depth, bucket b+1 those with byte b there
+	var counts [258]int
+	for _, str := range s {
+		counts[stringBucket(str, depth)+1]++
+	}
+	for i := 1; i < len(counts); i++ {
+		counts[i] += counts[i-1]
+	}
+	starts := counts
+	for _, str := range s {
+		b := stringBucket(str, depth)
+		buf[counts[b]] = str
+		counts[b]++
+	}
+	copy(s, buf[:len(s)])
+	for b := 1; b < 257; b++ {
+		if lo, hi := starts[b], starts[b+1]; hi-lo > 1 {
+			radixSortStrings(s[lo:hi], buf[lo:hi], depth+1)
+		}
+	}
+}
+
+func stringBucket(s string, depth int) int {
+	if depth >= len(s) {
+		return 0
+	}
+	return int(s[depth]) + 1
+}
+
+// CountingSort sorts integers in increasing order by counting occurrences of
+// each value, in O(n + k) time where k is the span between the smallest and
+// largest value. It returns an error if k exceeds maxCountingRange.
+func CountingSort[T Integer](s []T) error {
+	if len(s) < 2 {
+		return nil
+	}
+	key, _ := integerKey[T]()
+	lo, hi := s[0], s[0]
+	for _, v := range s {
+		if key(v) < key(lo) {
+			lo = v
+		}
+		if key(v) > key(hi) {
+			hi = v
+		}
+	}
+	span := key(hi) - key(lo)
+	if span >= maxCountingRange {
+		return fmt.Errorf("values span %d..%d, too wide for counting sort", lo, hi)
+	}
+
+	counts := make([]int, span+1)
+	for _, v := range s {
+		counts[key(v)-key(lo)]++
+	}
+	i := 0
+	for offset, c := range counts {
+		// Adding to lo wraps around like the keys, so this also works for signed types
+		v := lo + T(offset)
+		for ; c > 0; c-- {
+			s[i] = v
+			i++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package generics
+
+import (
+	"cmp"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// record is sorted by Key; Seq tells whether equal keys kept their order
+type record struct {
+	Key int
+	Seq int
+}
+
+func compareRecords(a, b record) int {
+	return cmp.Compare(a.Key, b.Key)
+}
+
+func randomInts(rng *rand.Rand, n, spread int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = rng.Intn(2*spread+1) - spread
+	}
+	return s
+}
+
+func TestSortsAgainstSlicesSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sorts := []struct {
+		name string
+		sort func([]int)
+	}{
+		{"MergeSort", MergeSort[int]},
+		{"IntroSort", IntroSort[int]},
+		{"RadixSort", RadixSort[int]},
+		{"CountingSort", func(s []int) {
+			if err := CountingSort(s); err != nil {
+				t.Fatalf("Failed to sort: %v", err)
+			}
+		}},
+	}
+	inputs := map[string][]int{
+		"Empty":      {},
+		"Single":     {42},
+		"Sorted":     {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+		"Reversed":   {17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		"All equal":  slices.Repeat([]int{7}, 100),
+		"Few values": randomInts(rng, 1000, 3),
+		"Random":     randomInts(rng, 5000, 1_000_000),
+	}
+	// Organ pipe: ascending then descending, a bad case for naive quicksort
+	pipe := make([]int, 2000)
+	for i := range pipe {
+		pipe[i] = min(i, len(pipe)-i)
+	}
+	inputs["Organ pipe"] = pipe
+
+	for _, s := range sorts {
+		for name, input := range inputs {
+			t.Run(s.name+"/"+name, func(t *testing.T) {
+				expected := slices.Clone(input)
+				slices.Sort(expected)
+				got := slices.Clone(input)
+				s.sort(got)
+				if !slices.Equal(got, expected) {
+					t.Errorf("Expected %v, got %v", expected, got)
+				}
+			})
+		}
+	}
+}
+
+func TestSortsRandomLengths(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for round := 0; round < 200; round++ {
+		input := randomInts(rng, rng.Intn(300), 50+rng.Intn(1000))
+		expected := slices.Clone(input)
+		slices.Sort(expected)
+		sorts := map[string]func([]int){
+			"MergeSort": MergeSort[int],
+			"IntroSort": IntroSort[int],
+			"RadixSort": RadixSort[int],
+			// No depth left: introsort falls back to heapsort at once
+			"HeapSort": func(s []int) { introSort(s, 0, cmp.Compare[int]) },
+		}
+		for name, sort := range sorts {
+			got := slices.Clone(input)
+			sort(got)
+			if !slices.Equal(got, expected) {
+				t.Fatalf("%s: Expected %v, got %v", name, expected, got)
+			}
+		}
+	}
+}
+
+func TestRadixSortIntegerTypes(t *testing.T) {
+	int8s := []int8{127, -128, 0, -1, 1, 100, -100}
+	RadixSort(int8s)
+	if !slices.IsSorted(int8s) {
+		t.Errorf("Expected sorted int8s, got %v", int8s)
+	}
+
+	uint16s := []uint16{65535, 0, 256, 255, 1, 32768}
+	RadixSort(uint16s)
+	if !slices.IsSorted(uint16s) {
+		t.Errorf("Expected sorted uint16s, got %v", uint16s)
+	}
+
+	int64s := []int64{math.MaxInt64, math.MinInt64, 0, -1, 1 << 40, -1 << 40}
+	RadixSort(int64s)
+	if !slices.IsSorted(int64s) {
+		t.Errorf("Expected sorted int64s, got %v", int64s)
+	}
+
+	uint64s := []uint64{math.MaxUint64, 0, 1 << 63, 1<<63 - 1}
+	RadixSort(uint64s)
+	if !slices.IsSorted(uint64s) {
+		t.Errorf("Expected sorted uint64s, got %v", uint64s)
+	}
+}
+
+func TestRadixSortStrings(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	alphabet := []string{"a", "b", "ab", "", "é", "z", "ä", "\x00", "\xff"}
+	input := make([]string, 2000)
+	for i := range input {
+		var sb strings.Builder
+		for n := rng.Intn(6); n > 0; n-- {
+			sb.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		input[i] = sb.String()
+	}
+	expected := slices.Clone(input)
+	slices.Sort(expected)
+	RadixSortStrings(input)
+	if !slices.Equal(input, expected) {
+		t.Errorf("Expected strings in byte order, got %q", input[:20])
+	}
+}
+
+func TestCountingSort(t *testing.T) {
+	int8s := []int8{127, -128, 5, -3, 5, 0}
+	if err := CountingSort(int8s); err != nil {
+		t.Fatalf("Failed to sort: %v", err)
+	}
+	if !slices.Equal(int8s, []int8{-128, -3, 0, 5, 5, 127}) {
+		t.Errorf("Expected [-128 -3 0 5 5 127], got %v", int8s)
+	}
+
+	wide := []int64{math.MinInt64, math.MaxInt64}
+	if err := CountingSort(wide); err == nil {
+		t.Error("Expected an error for a span too wide, but got none")
+	}
+}
+
+func TestMergeSortStability(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	records := make([]record, 1000)
+	for i := range records {
+		records[i] = record{Key: rng.Intn(10), Seq: i}
+	}
+	expected := slices.Clone(records)
+	slices.SortStableFunc(expected, compareRecords)
+	MergeSortFunc(records, compareRecords)
+	if !slices.Equal(records, expected) {
+		t.Error("Expected merge sort to keep equal keys in their original order")
+	}
+}
+
+func BenchmarkSorts(b *testing.B) {
+	input := randomInts(rand.New(rand.NewSource(5)), 100000, 1_000_000)
+	sorts := []struct {
+		name string
+		sort func([]int)
+	}{
+		{"slices.Sort", slices.Sort[[]int]},
+		{"MergeSort", MergeSort[int]},
+		{"IntroSort", IntroSort[int]},
+		{"RadixSort", RadixSort[int]},
+	}
+	for _, s := range sorts {
+		b.Run(s.name, func(b *testing.B) {
+			buf := make([]int, len(input))
+			for i := 0; i < b.N; i++ {
+				copy(buf, input)
+				s.sort(buf)
+			}
+		})
+	}
+}
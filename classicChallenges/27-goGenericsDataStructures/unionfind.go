@@ -0,0 +1,105 @@
+package generics
+
+// UnionFind is a disjoint-set forest over comparable elements, with path
+// compression and union by rank. Operations take nearly constant amortized time.
+type UnionFind[T comparable] struct {
+	index    map[T]int
+	elements []T
+	parent   []int
+	rank     []uint8
+	size     []int
+	sets     int
+}
+
+// NewUnionFind creates a new empty union-find structure
+func NewUnionFind[T comparable]() *UnionFind[T] {
+	return &UnionFind[T]{
+		index: make(map[T]int),
+	}
+}
+
+// Add adds x as a set of its own if it's not already present
+func (uf *UnionFind[T]) Add(x T) {
+	uf.add(x)
+}
+
+func (uf *UnionFind[T]) add(x T) int {
+	if i, ok := uf.index[x]; ok {
+		return i
+	}
+	i := len(uf.elements)
+	uf.index[x] = i
+	uf.elements = append(uf.elements, x)
+	uf.parent = append(uf.parent, i)
+	uf.rank = append(uf.rank, 0)
+	uf.size = append(uf.size, 1)
+	uf.sets++
+	return i
+}
+
+// root returns the index of the root of i's set, halving the path on the way
+func (uf *UnionFind[T]) root(i int) int {
+	for uf.parent[i] != i {
+		uf.parent[i] = uf.parent[uf.parent[i]]
+		i = uf.parent[i]
+	}
+	return i
+}
+
+// Find returns the representative element of the set containing x
+// Returns ErrKeyNotFound if x was never added
+func (uf *UnionFind[T]) Find(x T) (T, error) {
+	i, ok := uf.index[x]
+	if !ok {
+		var zero T
+		return zero, ErrKeyNotFound
+	}
+	return uf.elements[uf.root(i)], nil
+}
+
+// Union merges the sets containing x and y, adding them first if needed.
+// It returns true if they were in different sets.
+func (uf *UnionFind[T]) Union(x, y T) bool {
+	a, b := uf.root(uf.add(x)), uf.root(uf.add(y))
+	if a == b {
+		return false
+	}
+	// Attach the shallower tree under the deeper one
+	if uf.rank[a] < uf.rank[b] {
+		a, b = b, a
+	}
+	uf.parent[b] = a
+	uf.size[a] += uf.size[b]
+	if uf.rank[a] == uf.rank[b] {
+		uf.rank[a]++
+	}
+	uf.sets--
+	return true
+}
+
+// Connected returns true if x and y are in the same set
+func (uf *UnionFind[T]) Connected(x, y T) bool {
+	i, ok := uf.index[x]
+	j, ok2 := uf.index[y]
+	return ok && ok2 && uf.root(i) == uf.root(j)
+}
+
+// SetSize returns the number of elements in the set containing x, or 0 if x
+// was never added
+func (uf *UnionFind[T]) SetSize(x T) int {
+	i, ok := uf.index[x]
+	if !ok {
+		return 0
+	}
+	return uf.size[uf.root(i)]
+}
+
+// Size returns the number of elements
+func (uf *UnionFind[T]) Size() int {
+	return len(uf.elements)
+}
+
+// Sets returns the number of disjoint sets
+func (uf *UnionFind[T]) Sets() int {
+	return uf.sets
+}
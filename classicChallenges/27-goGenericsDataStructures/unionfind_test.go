@@ -0,0 +1,97 @@
+package generics
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestUnionFind(t *testing.T) {
+	uf := NewUnionFind[string]()
+	if _, err := uf.Find("a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	uf.Add("a")
+	uf.Add("a")
+	if uf.Size() != 1 || uf.Sets() != 1 {
+		t.Errorf("Expected 1 element in 1 set, got %d in %d", uf.Size(), uf.Sets())
+	}
+
+	if !uf.Union("a", "b") {
+		t.Error("Expected Union of new elements to merge sets")
+	}
+	uf.Union("c", "d")
+	if uf.Union("b", "a") {
+		t.Error("Expected Union within a set to return false")
+	}
+	if uf.Connected("a", "c") {
+		t.Error("Expected a and c not to be connected")
+	}
+	uf.Union("b", "d")
+
+	tests := []struct {
+		x, y     string
+		expected bool
+	}{
+		{"a", "d", true},
+		{"c", "b", true},
+		{"a", "missing", false},
+	}
+	for _, tt := range tests {
+		if got := uf.Connected(tt.x, tt.y); got != tt.expected {
+			t.Errorf("Expected Connected(%s, %s) to be %v, got %v", tt.x, tt.y, tt.expected, got)
+		}
+	}
+
+	ra, _ := uf.Find("a")
+	rc, _ := uf.Find("c")
+	if ra != rc {
+		t.Errorf("Expected a and c to have the same representative, got %s and %s", ra, rc)
+	}
+	if uf.SetSize("c") != 4 || uf.Sets() != 1 {
+		t.Errorf("Expected one set of 4, got %d sets, size %d", uf.Sets(), uf.SetSize("c"))
+	}
+}
+
+func TestUnionFindAgainstLabels(t *testing.T) {
+	// Compare with naive relabelling of components
+	const n = 300
+	uf := NewUnionFind[int]()
+	label := make([]int, n)
+	for i := range label {
+		label[i] = i
+		uf.Add(i)
+	}
+	rng := rand.New(rand.NewSource(9))
+	for round := 0; round < 400; round++ {
+		a, b := rng.Intn(n), rng.Intn(n)
+		merged := uf.Union(a, b)
+		if merged != (label[a] != label[b]) {
+			t.Fatalf("Expected Union(%d, %d) to return %v", a, b, label[a] != label[b])
+		}
+		old := label[b]
+		for i := range label {
+			if label[i] == old {
+				label[i] = label[a]
+			}
+		}
+	}
+
+	components := map[int]int{}
+	for i := range label {
+		components[label[i]]++
+	}
+	if uf.Sets() != len(components) {
+		t.Errorf("Expected %d sets, got %d", len(components), uf.Sets())
+	}
+	for i := 0; i < n; i++ {
+		if uf.SetSize(i) != components[label[i]] {
+			t.Fatalf("Expected set of %d to have size %d, got %d", i, components[label[i]], uf.SetSize(i))
+		}
+		j := rng.Intn(n)
+		if uf.Connected(i, j) != (label[i] == label[j]) {
+			t.Fatalf("Expected Connected(%d, %d) to be %v", i, j, label[i] == label[j])
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// Clock tells the time and waits for it to pass. Tests substitute a fake
+// clock so that time-dependent code runs without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock of the operating system
+type systemClock struct{}
+
+// SystemClock is the real clock, used when no other is given
+var SystemClock Clock = systemClock{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
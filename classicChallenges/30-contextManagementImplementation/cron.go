@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCron is returned for cron expressions that cannot be parsed
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// ErrCronJobNotFound is returned for names of jobs that were never added
+var ErrCronJobNotFound = errors.New("cron job not found")
+
+// cronField describes the values one field of an expression may take
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Both 0 and 7 are Sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// cronDescriptors are the shorthands for common schedules
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchYears bounds the search for the next run, so that schedules that
+// can never match (like February 30th) end
+const cronSearchYears = 5
+
+// CronSchedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type CronSchedule struct {
+	expr                         string
+	second, minute, hour         uint64
+	dom, month, dow              uint64
+	domRestricted, dowRestricted bool
+	loc                          *time.Location
+}
+
+// ParseCron parses a cron expression of 5 fields (minute, hour, day of month,
+// month, day of week) or 6 fields (with seconds first), or a descriptor such
+// as @daily. Fields take *, ?, values, names (JAN, MON), ranges, lists and
+// steps. The schedule is in loc, or time.Local if loc is nil, unless the
+// expression starts with CRON_TZ=<zone>.
+func ParseCron(expr string, loc *time.Location) (*CronSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	fields := strings.Fields(expr)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		zone := fields[0][strings.Index(fields[0], "=")+1:]
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidCron, zone)
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown descriptor %q", ErrInvalidCron, fields[0])
+		}
+		fields = strings.Fields(descriptor)
+	}
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: %q has %d fields, expected 5 or 6", ErrInvalidCron, expr, len(fields))
+	}
+
+	s := &CronSchedule{expr: expr, loc: loc}
+	var err error
+	parsers := []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{secondField, &s.second}, {minuteField, &s.minute}, {hourField, &s.hour},
+		{domField, &s.dom}, {monthField, &s.month}, {dowField, &s.dow},
+	}
+	for i, p := range parsers {
+		if *p.bits, err = p.field.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday may be written as 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domRestricted = fields[3] != "*" && fields[3] != "?"
+	s.dowRestricted = fields[5] != "*" && fields[5] != "?"
+	return s, nil
+}
+
+// parse returns the bit set of the values matched by one field
+func (f cronField) parse(text string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("%w: bad step %q in %s field", ErrInvalidCron, stepText, f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeText == "*" || rangeText == "?":
+		case strings.Contains(rangeText, "-"):
+			loText, hiText, _ := strings.Cut(rangeText, "-")
+			var err error
+			if lo, err = f.value(loText); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiText); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%w: range %q in %s field is backwards", ErrInvalidCron, rangeText, f.name)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangeText); err != nil {
+				return 0, err
+			}
+			// A single value with a step, like 5/15, runs to the end of the field
+			if !hasStep {
+				hi = lo
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name in the field's bounds
+func (f cronField) value(text string) (int, error) {
+	if v, ok := f.names[strings.ToUpper(text)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%w: %q is not a valid %s (%d-%d)", ErrInvalidCron, text, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Location returns the time zone of the schedule
+func (s *CronSchedule) Location() *time.Location {
+	return s.loc
+}
+
+// dayMatches applies the cron rule that a day matches either field when both
+// the day of month and the day of week are restricted
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<t.Weekday()) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time after t that matches the schedule, or the zero
+// time if there is none in the next few years. Times skipped when DST starts
+// do not run; times repeated when it ends run once.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + cronSearchYears
+
+	for t.Year() <= limit {
+		// Skip ahead field by field, from the largest unit. time.Date
+		// normalises overflows and times that fall into DST gaps.
+		y, mo, d := t.Date()
+		h, mi, _ := t.Clock()
+		var next time.Time
+		switch {
+		case s.month&(1<<mo) == 0:
+			next = time.Date(y, mo+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			next = time.Date(y, mo, d+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<h) == 0:
+			next = time.Date(y, mo, d, h+1, 0, 0, 0, s.loc)
+		case s.minute&(1<<mi) == 0:
+			next = t.Truncate(time.Minute).Add(time.Minute)
+		case s.second&(1<<t.Second()) == 0:
+			next = t.Add(time.Second)
+		default:
+			return t
+		}
+		// When DST ends the wall clock repeats an hour; skip the repeat so
+		// each time runs once
+		if nh, nm, _ := next.Clock(); next.Day() == d && nh*60+nm < h*60+mi {
+			next = time.Date(y, mo, d, h+1, 0, 0, 0, s.loc)
+		}
+		// Normalising in a DST transition can go backwards; never loop
+		if !next.After(t) {
+			next = t.Add(time.Hour).Truncate(time.Hour)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// CronJobSpec describes a job run by a CronScheduler
+type CronJobSpec struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context) error
+	// NoOverlap skips a run while the previous one is still going
+	NoOverlap bool
+}
+
+// CronJobStatus reports the runs of a job
+type CronJobStatus struct {
+	Name         string
+	Schedule     string
+	NextRun      time.Time
+	LastRun      time.Time // start of the last finished run
+	LastDuration time.Duration
+	LastErr      error // error or *PanicError of the last finished run
+	Runs         int   // runs started
+	Skipped      int   // runs skipped because the previous one was still going
+	Running      int   // runs going on now
+}
+
+// cronJob is a job with its state
+type cronJob struct {
+	spec     CronJobSpec
+	schedule *CronSchedule
+	status   CronJobStatus
+}
+
+// CronScheduler runs jobs at the times given by their cron expressions
+type CronScheduler struct {
+	clock Clock
+	loc   *time.Location
+
+	mu   sync.Mutex
+	jobs map[string]*cronJob
+	wake chan struct{} // tells Run that the next run time may have changed
+	runs sync.WaitGroup
+}
+
+// NewCronScheduler creates a new scheduler. Expressions without CRON_TZ are in
+// loc; nil means time.Local. A nil clock means SystemClock.
+func NewCronScheduler(clock Clock, loc *time.Location) *CronScheduler {
+	if clock == nil {
+		clock = SystemClock
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &CronScheduler{
+		clock: clock,
+		loc:   loc,
+		jobs:  make(map[string]*cronJob),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Add parses the schedule of a job and adds it to the scheduler. Jobs can be
+// added while the scheduler runs.
+func (s *CronScheduler) Add(spec CronJobSpec) error {
+	if spec.Name == "" || spec.Run == nil {
+		return errors.New("cron job needs a name and a Run function")
+	}
+	schedule, err := ParseCron(spec.Schedule, s.loc)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", spec.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[spec.Name]; exists {
+		return fmt.Errorf("duplicate cron job name %q", spec.Name)
+	}
+	s.jobs[spec.Name] = &cronJob{
+		spec:     spec,
+		schedule: schedule,
+		status: CronJobStatus{
+			Name:     spec.Name,
+			Schedule: spec.Schedule,
+			NextRun:  schedule.Next(s.clock.Now()),
+		},
+	}
+	s.signal()
+	return nil
+}
+
+// Remove removes a job; runs that already started are not stopped
+func (s *CronScheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[name]; !exists {
+		return fmt.Errorf("%w: %q", ErrCronJobNotFound, name)
+	}
+	delete(s.jobs, name)
+	s.signal()
+	return nil
+}
+
+// signal wakes Run without blocking
+func (s *CronScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the status of the named job
+func (s *CronScheduler) Status(name string) (CronJobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[name]
+	if !exists {
+		return CronJobStatus{}, fmt.Errorf("%w: %q", ErrCronJobNotFound, name)
+	}
+	return job.status, nil
+}
+
+// Statuses returns the status of every job, ordered by name
+func (s *CronScheduler) Statuses() []CronJobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]CronJobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		statuses = append(statuses, job.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Run starts jobs when they are due until ctx is cancelled, then waits for
+// running jobs to return. Jobs get ctx, so they are asked to stop too.
+// Runs missed while the scheduler was not running are skipped.
+// Returns ctx.Err().
+func (s *CronScheduler) Run(ctx context.Context) error {
+	defer s.runs.Wait()
+	// The first pass sees every job added so far
+	select {
+	case <-s.wake:
+	default:
+	}
+	s.skipMissed(s.clock.Now())
+	for {
+		now := s.clock.Now()
+		next := s.startDue(ctx, now)
+
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = s.clock.After(next.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+		case <-s.wake:
+		}
+	}
+}
+
+// skipMissed moves the next run of the jobs whose run time passed before the
+// scheduler started to their first run time after now
+func (s *CronScheduler) skipMissed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if st := &job.status; !st.NextRun.IsZero() && st.NextRun.Before(now) {
+			st.NextRun = job.schedule.Next(now)
+		}
+	}
+}
+
+// startDue starts the jobs that are due at now and returns the earliest next
+// run time of all jobs, or the zero time if there is none
+func (s *CronScheduler) startDue(ctx context.Context, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	for _, job := range s.jobs {
+		st := &job.status
+		if !st.NextRun.IsZero() && !st.NextRun.After(now) {
+			if job.spec.NoOverlap && st.Running > 0 {
+				st.Skipped++
+			} else {
+				st.Runs++
+				st.Running++
+				s.runs.Add(1)
+				go s.run(ctx, job, now)
+			}
+			st.NextRun = job.schedule.Next(now)
+		}
+		if !st.NextRun.IsZero() && (earliest.IsZero() || st.NextRun.Before(earliest)) {
+			earliest = st.NextRun
+		}
+	}
+	return earliest
+}
+
+// run runs a job once and records the outcome
+func (s *CronScheduler) run(ctx context.Context, job *cronJob, start time.Time) {
+	defer s.runs.Done()
+	err := callSafely(func() error { return job.spec.Run(ctx) })
+	end := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &job.status
+	st.Running--
+	// An older run finishing late does not overwrite a newer outcome
+	if !start.Before(st.LastRun) {
+		st.LastRun = start
+		st.LastDuration = end.Sub(start)
+		st.LastErr = err
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("Time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(y int, mo time.Month, d, h, mi, s int) time.Time {
+		return time.Date(y, mo, d, h, mi, s, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected []time.Time
+	}{
+		{"Every 15 minutes", "*/15 * * * *", utc(2024, 1, 1, 12, 7, 30),
+			[]time.Time{utc(2024, 1, 1, 12, 15, 0), utc(2024, 1, 1, 12, 30, 0), utc(2024, 1, 1, 12, 45, 0)}},
+		{"Weekdays by name", "0 9 * * MON-FRI", utc(2024, 1, 5, 10, 0, 0),
+			[]time.Time{utc(2024, 1, 8, 9, 0, 0), utc(2024, 1, 9, 9, 0, 0)}},
+		{"Seconds field", "30 * * * * *", utc(2024, 1, 1, 12, 0, 30),
+			[]time.Time{utc(2024, 1, 1, 12, 1, 30), utc(2024, 1, 1, 12, 2, 30)}},
+		{"List", "0 0 1,15 * *", utc(2024, 1, 1, 0, 0, 0),
+			[]time.Time{utc(2024, 1, 15, 0, 0, 0), utc(2024, 2, 1, 0, 0, 0)}},
+		{"Value with step", "5/20 * * * *", utc(2024, 1, 1, 12, 0, 0),
+			[]time.Time{utc(2024, 1, 1, 12, 5, 0), utc(2024, 1, 1, 12, 25, 0), utc(2024, 1, 1, 12, 45, 0)}},
+		{"Descriptor", "@monthly", utc(2024, 1, 31, 0, 0, 0),
+			[]time.Time{utc(2024, 2, 1, 0, 0, 0), utc(2024, 3, 1, 0, 0, 0)}},
+		{"Sunday as 7", "0 0 * * 7", utc(2024, 1, 1, 0, 0, 0),
+			[]time.Time{utc(2024, 1, 7, 0, 0, 0), utc(2024, 1, 14, 0, 0, 0)}},
+		{"Day of month or day of week", "0 12 13 * FRI", utc(2024, 1, 1, 0, 0, 0),
+			[]time.Time{utc(2024, 1, 5, 12, 0, 0), utc(2024, 1, 12, 12, 0, 0), utc(2024, 1, 13, 12, 0, 0)}},
+		{"Leap day", "0 0 29 FEB *", utc(2024, 3, 1, 0, 0, 0),
+			[]time.Time{utc(2028, 2, 29, 0, 0, 0)}},
+		{"Never", "0 0 30 2 *", utc(2024, 1, 1, 0, 0, 0),
+			[]time.Time{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.expr, err)
+			}
+			from := tt.from
+			for _, expected := range tt.expected {
+				got := s.Next(from)
+				if !got.Equal(expected) {
+					t.Fatalf("Expected next run after %v to be %v, got %v", from, expected, got)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestCronTimeZones(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	s, err := ParseCron("CRON_TZ=America/New_York 0 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if s.Location().String() != "America/New_York" {
+		t.Errorf("Expected America/New_York, got %v", s.Location())
+	}
+	got := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if expected := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// 02:30 does not exist when DST starts, so that day is skipped
+	s, _ = ParseCron("30 2 * * *", newYork)
+	got = s.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, newYork))
+	if expected := time.Date(2024, 3, 11, 2, 30, 0, 0, newYork); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// 01:30 happens twice when DST ends, but runs once
+	s, _ = ParseCron("30 1 * * *", newYork)
+	first := s.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, newYork))
+	second := s.Next(first)
+	if first.Day() != 3 || second.Day() != 4 || second.Hour() != 1 || second.Minute() != 30 {
+		t.Errorf("Expected runs on November 3rd and 4th at 01:30, got %v and %v", first, second)
+	}
+
+	// Hourly runs go on through the end of DST
+	s, _ = ParseCron("0 * * * *", newYork)
+	from := time.Date(2024, 11, 3, 0, 30, 0, 0, newYork)
+	for i := 0; i < 4; i++ {
+		next := s.Next(from)
+		if gap := next.Sub(from); gap <= 0 || gap > 2*time.Hour {
+			t.Fatalf("Expected the next hourly run within 2 hours of %v, got %v", from, next)
+		}
+		from = next
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Empty", ""},
+		{"Too few fields", "* * * *"},
+		{"Too many fields", "* * * * * * *"},
+		{"Minute out of range", "60 * * * *"},
+		{"Day of month zero", "* * 0 * *"},
+		{"Backwards range", "5-1 * * * *"},
+		{"Zero step", "*/0 * * * *"},
+		{"Unknown name", "* * * FOO *"},
+		{"Unknown descriptor", "@often"},
+		{"Unknown time zone", "CRON_TZ=Mars/Olympus * * * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr, time.UTC); !errors.Is(err, ErrInvalidCron) {
+				t.Errorf("Expected ErrInvalidCron, got %v", err)
+			}
+		})
+	}
+}
+
+// runScheduler runs s in the background until the test ends
+func runScheduler(t *testing.T, s *CronScheduler) (cancel func(), wait func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- s.Run(ctx) }()
+	t.Cleanup(cancel)
+	return cancel, func() error {
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("Expected the scheduler to return")
+			return nil
+		}
+	}
+}
+
+func statusOf(t *testing.T, s *CronScheduler, name string) CronJobStatus {
+	t.Helper()
+	st, err := s.Status(name)
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	return st
+}
+
+func TestCronScheduler(t *testing.T) {
+	clock := newFakeClock()
+	s := NewCronScheduler(clock, time.UTC)
+	start := clock.Now()
+
+	calls := 0
+	err := s.Add(CronJobSpec{Name: "report", Schedule: "* * * * *", Run: func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return errors.New("database down")
+		case 2:
+			panic("bad report")
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+	if st := statusOf(t, s, "report"); !st.NextRun.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected next run at %v, got %v", start.Add(time.Minute), st.NextRun)
+	}
+	cancel, wait := runScheduler(t, s)
+
+	for minute := 1; minute <= 3; minute++ {
+		clock.waitForWaiters(t, 1)
+		clock.Advance(time.Minute)
+		waitFor(t, "the job to finish", func() bool {
+			st := statusOf(t, s, "report")
+			return st.Runs == minute && st.Running == 0
+		})
+
+		st := statusOf(t, s, "report")
+		if expected := start.Add(time.Duration(minute) * time.Minute); !st.LastRun.Equal(expected) {
+			t.Errorf("Expected last run at %v, got %v", expected, st.LastRun)
+		}
+		if expected := start.Add(time.Duration(minute+1) * time.Minute); !st.NextRun.Equal(expected) {
+			t.Errorf("Expected next run at %v, got %v", expected, st.NextRun)
+		}
+		var pe *PanicError
+		switch {
+		case minute == 1 && (st.LastErr == nil || st.LastErr.Error() != "database down"):
+			t.Errorf("Expected the error of the first run, got %v", st.LastErr)
+		case minute == 2 && !errors.As(st.LastErr, &pe):
+			t.Errorf("Expected a *PanicError for the second run, got %v", st.LastErr)
+		case minute == 3 && st.LastErr != nil:
+			t.Errorf("Expected the third run to succeed, got %v", st.LastErr)
+		}
+	}
+
+	cancel()
+	if err := wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCronSchedulerSkipsMissedRuns(t *testing.T) {
+	clock := newFakeClock()
+	s := NewCronScheduler(clock, time.UTC)
+	start := clock.Now()
+	s.Add(CronJobSpec{Name: "report", Schedule: "* * * * *", Run: func(ctx context.Context) error { return nil }})
+
+	// The scheduler starts ten minutes after the job was added
+	clock.Advance(10 * time.Minute)
+	runScheduler(t, s)
+	clock.waitForWaiters(t, 1)
+	st := statusOf(t, s, "report")
+	if st.Runs != 0 {
+		t.Errorf("Expected the missed runs to be skipped, got %d runs", st.Runs)
+	}
+	if expected := start.Add(11 * time.Minute); !st.NextRun.Equal(expected) {
+		t.Errorf("Expected next run at %v, got %v", expected, st.NextRun)
+	}
+
+	clock.Advance(time.Minute)
+	waitFor(t, "the job to run", func() bool { return statusOf(t, s, "report").Runs == 1 })
+	if st := statusOf(t, s, "report"); !st.LastRun.Equal(start.Add(11 * time.Minute)) {
+		t.Errorf("Expected the run at %v, got %v", start.Add(11*time.Minute), st.LastRun)
+	}
+}
+
+func TestCronSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		name            string
+		noOverlap       bool
+		expectedRuns    int
+		expectedSkipped int
+	}{
+		{"Overlapping", false, 3, 0},
+		{"No overlap", true, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			s := NewCronScheduler(clock, time.UTC)
+			release := make(chan struct{})
+			s.Add(CronJobSpec{Name: "slow", Schedule: "* * * * *", NoOverlap: tt.noOverlap,
+				Run: func(ctx context.Context) error {
+					<-release
+					return nil
+				}})
+			cancel, wait := runScheduler(t, s)
+
+			for i := 0; i < 3; i++ {
+				clock.waitForWaiters(t, 1)
+				clock.Advance(time.Minute)
+			}
+			waitFor(t, "the third run time to pass", func() bool {
+				st := statusOf(t, s, "slow")
+				return st.Runs+st.Skipped == 3
+			})
+			st := statusOf(t, s, "slow")
+			if st.Runs != tt.expectedRuns || st.Skipped != tt.expectedSkipped || st.Running != tt.expectedRuns {
+				t.Errorf("Expected %d runs going and %d skipped, got %+v", tt.expectedRuns, tt.expectedSkipped, st)
+			}
+
+			close(release)
+			cancel()
+			wait()
+			if st := statusOf(t, s, "slow"); st.Running != 0 {
+				t.Errorf("Expected Run to wait for running jobs, got %d running", st.Running)
+			}
+		})
+	}
+}
+
+func TestCronSchedulerShutdown(t *testing.T) {
+	clock := newFakeClock()
+	s := NewCronScheduler(clock, time.UTC)
+	stopped := make(chan struct{})
+	s.Add(CronJobSpec{Name: "long", Schedule: "* * * * *", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	}})
+	cancel, wait := runScheduler(t, s)
+
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	waitFor(t, "the job to start", func() bool { return statusOf(t, s, "long").Running == 1 })
+	cancel()
+	wait()
+	select {
+	case <-stopped:
+	default:
+		t.Error("Expected the job to see the cancellation")
+	}
+	if st := statusOf(t, s, "long"); !errors.Is(st.LastErr, context.Canceled) {
+		t.Errorf("Expected context.Canceled as the outcome, got %v", st.LastErr)
+	}
+}
+
+func TestCronSchedulerAddWhileRunning(t *testing.T) {
+	clock := newFakeClock()
+	s := NewCronScheduler(clock, time.UTC)
+	s.Add(CronJobSpec{Name: "daily", Schedule: "@daily", Run: func(ctx context.Context) error { return nil }})
+	runScheduler(t, s)
+	clock.waitForWaiters(t, 1)
+
+	// The new job runs before the daily one, so the scheduler must wake up
+	ran := make(chan struct{})
+	s.Add(CronJobSpec{Name: "soon", Schedule: "0 * * * * *", Run: func(ctx context.Context) error {
+		close(ran)
+		return nil
+	}})
+	clock.waitForWaiters(t, 2)
+	clock.Advance(time.Minute)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the added job to run")
+	}
+
+	if err := s.Remove("soon"); err != nil {
+		t.Errorf("Failed to remove job: %v", err)
+	}
+	if _, err := s.Status("soon"); !errors.Is(err, ErrCronJobNotFound) {
+		t.Errorf("Expected ErrCronJobNotFound, got %v", err)
+	}
+	if statuses := s.Statuses(); len(statuses) != 1 || statuses[0].Name != "daily" {
+		t.Errorf("Expected only the daily job, got %+v", statuses)
+	}
+	if err := s.Add(CronJobSpec{Name: "daily", Schedule: "@hourly", Run: func(ctx context.Context) error { return nil }}); err == nil {
+		t.Error("Expected an error for a duplicate name, but got none")
+	}
+	if err := s.Add(CronJobSpec{Name: "bad", Schedule: "@often", Run: func(ctx context.Context) error { return nil }}); !errors.Is(err, ErrInvalidCron) {
+		t.Errorf("Expected ErrInvalidCron, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hedger runs hedged requests: when the primary attempt has not returned
+// after a delay, it starts another attempt, up to a maximum number of
+// hedges. The first success wins and the other attempts are cancelled.
+// A Hedger keeps statistics over all calls and is safe for concurrent use.
+type Hedger struct {
+	delay     time.Duration
+	maxHedges int
+	clock     Clock
+
+	mu    sync.Mutex
+	stats HedgeStats
+}
+
+// HedgeStats counts the outcomes of hedged calls
+type HedgeStats struct {
+	Calls    int
+	Failures int   // calls where no attempt succeeded
+	Hedges   int   // attempts started besides the primary ones
+	Wins     []int // Wins[i] counts calls won by attempt i; 0 is the primary
+}
+
+// HedgeOutcome reports how one hedged call went
+type HedgeOutcome struct {
+	Winner   int // attempt that succeeded, 0 for the primary, -1 if none did
+	Attempts int // attempts started
+	Elapsed  time.Duration
+}
+
+// NewHedger creates a new Hedger that starts a hedge each time delay passes
+// without a success, at most maxHedges times per call. A nil clock means
+// SystemClock.
+func NewHedger(delay time.Duration, maxHedges int, clock Clock) (*Hedger, error) {
+	if delay < 0 {
+		return nil, fmt.Errorf("hedge delay must not be negative, got %v", delay)
+	}
+	if maxHedges < 0 {
+		return nil, fmt.Errorf("max hedges must not be negative, got %d", maxHedges)
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &Hedger{delay: delay, maxHedges: maxHedges, clock: clock}, nil
+}
+
+// Stats returns the statistics of all calls so far
+func (h *Hedger) Stats() HedgeStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := h.stats
+	stats.Wins = append([]int(nil), h.stats.Wins...)
+	return stats
+}
+
+// String reports which attempts won, e.g.
+// "calls=10 failures=1 hedges=4 wins: primary=6 hedge1=3"
+func (s HedgeStats) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "calls=%d failures=%d hedges=%d wins:", s.Calls, s.Failures, s.Hedges)
+	for i, n := range s.Wins {
+		if i == 0 {
+			fmt.Fprintf(&sb, " primary=%d", n)
+		} else if n > 0 {
+			fmt.Fprintf(&sb, " hedge%d=%d", i, n)
+		}
+	}
+	return sb.String()
+}
+
+func (h *Hedger) record(o HedgeOutcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stats.Calls++
+	h.stats.Hedges += o.Attempts - 1
+	if o.Winner < 0 {
+		h.stats.Failures++
+		return
+	}
+	for len(h.stats.Wins) <= o.Winner {
+		h.stats.Wins = append(h.stats.Wins, 0)
+	}
+	h.stats.Wins[o.Winner]++
+}
+
+// Hedge calls task as the primary attempt 0 and, while no attempt has
+// succeeded, starts attempt 1, 2, ... each time the hedger's delay passes or
+// an attempt fails. Returns the value of the first attempt to succeed and
+// cancels the context of the others, without waiting for them. If every
+// attempt fails, the errors are joined. Panics become *PanicError.
+func Hedge[T any](ctx context.Context, h *Hedger, task func(ctx context.Context, attempt int) (T, error)) (T, HedgeOutcome, error) {
+	start := h.clock.Now()
+	value, winner, attempts, err := race(ctx, h.clock, h.delay, h.maxHedges+1, task)
+	outcome := HedgeOutcome{Winner: winner, Attempts: attempts, Elapsed: h.clock.Now().Sub(start)}
+	h.record(outcome)
+	return value, outcome, err
+}
+
+// Race starts all tasks at once and returns the value and index of the
+// first to succeed, cancelling the others. If all fail, the errors are joined.
+func Race[T any](ctx context.Context, tasks ...func(ctx context.Context) (T, error)) (T, int, error) {
+	if len(tasks) == 0 {
+		var zero T
+		return zero, -1, errors.New("no tasks to race")
+	}
+	value, winner, _, err := race(ctx, SystemClock, 0, len(tasks), func(ctx context.Context, i int) (T, error) {
+		return tasks[i](ctx)
+	})
+	return value, winner, err
+}
+
+// attemptResult is what one attempt of race returned
+type attemptResult[T any] struct {
+	attempt int
+	value   T
+	err     error
+}
+
+// race runs up to n attempts of task, starting the next one each time delay
+// passes or an attempt fails; with no delay all start at once. Returns the
+// first success, its attempt (-1 if none) and the number of attempts started.
+func race[T any](ctx context.Context, clock Clock, delay time.Duration, n int, task func(ctx context.Context, attempt int) (T, error)) (T, int, int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// Losers see the cancellation once a winner returns
+	defer cancel()
+
+	// Buffered so that losers never block after race returned
+	results := make(chan attemptResult[T], n)
+	started, pending := 0, 0
+	launch := func() {
+		attempt := started
+		started++
+		pending++
+		go func() {
+			var value T
+			err := callSafely(func() error {
+				var err error
+				value, err = task(ctx, attempt)
+				return err
+			})
+			results <- attemptResult[T]{attempt: attempt, value: value, err: err}
+		}()
+	}
+
+	launch()
+	for delay == 0 && started < n {
+		launch()
+	}
+	var errs []error
+	for {
+		var timer <-chan time.Time
+		if started < n {
+			timer = clock.After(delay)
+		}
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.value, r.attempt, started, nil
+			}
+			errs = append(errs, fmt.Errorf("attempt %d: %w", r.attempt, r.err))
+			if started < n {
+				// No point waiting out the delay after a failure
+				launch()
+			} else if pending == 0 {
+				var zero T
+				return zero, -1, started, errors.Join(errs...)
+			}
+		case <-timer:
+			launch()
+		case <-ctx.Done():
+			var zero T
+			return zero, -1, started, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func mustHedger(t *testing.T, delay time.Duration, maxHedges int, clock Clock) *Hedger {
+	t.Helper()
+	h, err := NewHedger(delay, maxHedges, clock)
+	if err != nil {
+		t.Fatalf("Failed to create hedger: %v", err)
+	}
+	return h
+}
+
+func TestHedgePrimaryWins(t *testing.T) {
+	h := mustHedger(t, time.Second, 2, newFakeClock())
+	value, outcome, err := Hedge(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		return "fast", nil
+	})
+	if err != nil || value != "fast" {
+		t.Fatalf("Expected fast, got %q (%v)", value, err)
+	}
+	if outcome.Winner != 0 || outcome.Attempts != 1 {
+		t.Errorf("Expected the primary to win alone, got %+v", outcome)
+	}
+}
+
+func TestHedgeAfterDelay(t *testing.T) {
+	clock := newFakeClock()
+	h := mustHedger(t, 100*time.Millisecond, 2, clock)
+	primaryCancelled := make(chan struct{})
+
+	done := make(chan struct{})
+	var value string
+	var outcome HedgeOutcome
+	var err error
+	go func() {
+		defer close(done)
+		value, outcome, err = Hedge(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+			if attempt == 0 {
+				// The primary is stuck until the hedge wins
+				<-ctx.Done()
+				close(primaryCancelled)
+				return "", ctx.Err()
+			}
+			return "hedge", nil
+		})
+	}()
+
+	clock.waitForWaiters(t, 1)
+	clock.Advance(100 * time.Millisecond)
+	<-done
+	if err != nil || value != "hedge" || outcome.Winner != 1 || outcome.Attempts != 2 {
+		t.Errorf("Expected the first hedge to win after 2 attempts, got %q %+v (%v)", value, outcome, err)
+	}
+	if outcome.Elapsed != 100*time.Millisecond {
+		t.Errorf("Expected 100ms elapsed, got %v", outcome.Elapsed)
+	}
+	select {
+	case <-primaryCancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the primary to be cancelled")
+	}
+}
+
+func TestHedgeFailureStartsNextAttempt(t *testing.T) {
+	// The delay never passes; hedges start because attempts fail
+	h := mustHedger(t, time.Hour, 3, newFakeClock())
+	value, outcome, err := Hedge(context.Background(), h, func(ctx context.Context, attempt int) (int, error) {
+		if attempt < 2 {
+			return 0, errors.New("unavailable")
+		}
+		return attempt, nil
+	})
+	if err != nil || value != 2 || outcome.Winner != 2 || outcome.Attempts != 3 {
+		t.Errorf("Expected attempt 2 to win, got %d %+v (%v)", value, outcome, err)
+	}
+}
+
+func TestHedgeAllFail(t *testing.T) {
+	h := mustHedger(t, time.Hour, 2, newFakeClock())
+	_, outcome, err := Hedge(context.Background(), h, func(ctx context.Context, attempt int) (int, error) {
+		if attempt == 1 {
+			panic("bad replica")
+		}
+		return 0, errors.New("unavailable")
+	})
+	if outcome.Winner != -1 || outcome.Attempts != 3 {
+		t.Errorf("Expected 3 failed attempts, got %+v", outcome)
+	}
+	var pe *PanicError
+	if err == nil || !errors.As(err, &pe) || strings.Count(err.Error(), "unavailable") != 2 {
+		t.Errorf("Expected the errors of all attempts, got %v", err)
+	}
+}
+
+func TestHedgeMaxHedges(t *testing.T) {
+	clock := newFakeClock()
+	h := mustHedger(t, time.Second, 2, clock)
+	var started atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, outcome, err := Hedge(ctx, h, func(ctx context.Context, attempt int) (int, error) {
+			started.Add(1)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		if outcome.Attempts != 3 {
+			t.Errorf("Expected 3 attempts, got %d", outcome.Attempts)
+		}
+		done <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		clock.waitForWaiters(t, 1)
+		clock.Advance(time.Second)
+	}
+	waitFor(t, "both hedges to start", func() bool { return started.Load() == 3 })
+	// No more hedges however long it takes
+	clock.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if started.Load() != 3 {
+		t.Errorf("Expected at most 2 hedges, got %d attempts", started.Load())
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestHedgeStats(t *testing.T) {
+	h := mustHedger(t, time.Hour, 2, newFakeClock())
+	winners := []int{0, 0, 1, 2, -1}
+	for _, winner := range winners {
+		Hedge(context.Background(), h, func(ctx context.Context, attempt int) (int, error) {
+			if attempt == winner {
+				return attempt, nil
+			}
+			return 0, errors.New("failed")
+		})
+	}
+
+	stats := h.Stats()
+	if stats.Calls != 5 || stats.Failures != 1 || stats.Hedges != 1+2+2 {
+		t.Errorf("Expected 5 calls, 1 failure and 5 hedges, got %+v", stats)
+	}
+	expected := "calls=5 failures=1 hedges=5 wins: primary=2 hedge1=1 hedge2=1"
+	if got := stats.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestNewHedgerErrors(t *testing.T) {
+	if _, err := NewHedger(-time.Second, 1, nil); err == nil {
+		t.Error("Expected an error for a negative delay, but got none")
+	}
+	if _, err := NewHedger(time.Second, -1, nil); err == nil {
+		t.Error("Expected an error for negative max hedges, but got none")
+	}
+}
+
+func TestRace(t *testing.T) {
+	var cancelled atomic.Int32
+	slow := func(ctx context.Context) (string, error) {
+		select {
+		case <-ctx.Done():
+			cancelled.Add(1)
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+			return "slow", nil
+		}
+	}
+	fast := func(ctx context.Context) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "fast", nil
+	}
+	failing := func(ctx context.Context) (string, error) {
+		return "", errors.New("refused")
+	}
+
+	value, winner, err := Race(context.Background(), slow, failing, fast, slow)
+	if err != nil || value != "fast" || winner != 2 {
+		t.Errorf("Expected task 2 to win with fast, got %d %q (%v)", winner, value, err)
+	}
+	waitFor(t, "the slow tasks to be cancelled", func() bool { return cancelled.Load() == 2 })
+
+	if _, winner, err := Race(context.Background(), failing, failing); err == nil || winner != -1 {
+		t.Errorf("Expected all tasks to fail, got winner %d (%v)", winner, err)
+	}
+	if _, _, err := Race[string](context.Background()); err == nil {
+		t.Error("Expected an error without tasks, but got none")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := Race(ctx, slow); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+)
+
+// redacted replaces the values of sensitive keys
+const redacted = "[REDACTED]"
+
+// sensitiveKeyWords mark keys whose values are redacted even if they were
+// never registered
+var sensitiveKeyWords = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "authorization", "credential", "session"}
+
+// ContextKey is a typed context key with a name for inspection. Create keys
+// with NewContextKey; each one is distinct even if names repeat, so keys are
+// meant to be package-level variables rather than created per request.
+type ContextKey[T any] struct {
+	name      string
+	sensitive bool
+}
+
+// inspectableKey is implemented by every ContextKey
+type inspectableKey interface {
+	keyName() string
+	keySensitive() bool
+}
+
+// contextKeys holds every key created by NewContextKey, so InspectContext can
+// also find values that were added with context.WithValue. The keys are held
+// weakly: a key nothing else refers to can't be in any context any more, so it
+// is dropped once collected and keys created per request don't pile up.
+var contextKeys struct {
+	sync.Mutex
+	keys  []func() inspectableKey // returns nil once the key is collected
+	limit int                     // prune when len(keys) reaches it
+}
+
+// NewContextKey creates and registers a new key for values of type T. The
+// values of sensitive keys are redacted by InspectContext.
+func NewContextKey[T any](name string, sensitive bool) *ContextKey[T] {
+	k := &ContextKey[T]{name: name, sensitive: sensitive}
+	ptr := weak.Make(k)
+	contextKeys.Lock()
+	defer contextKeys.Unlock()
+	if len(contextKeys.keys) >= contextKeys.limit {
+		pruneContextKeys()
+		contextKeys.limit = max(2*len(contextKeys.keys), 64)
+	}
+	contextKeys.keys = append(contextKeys.keys, func() inspectableKey {
+		if key := ptr.Value(); key != nil {
+			return key
+		}
+		return nil
+	})
+	return k
+}
+
+// pruneContextKeys drops the collected keys. The caller holds contextKeys.
+func pruneContextKeys() {
+	live := contextKeys.keys[:0]
+	for _, key := range contextKeys.keys {
+		if key() != nil {
+			live = append(live, key)
+		}
+	}
+	clear(contextKeys.keys[len(live):])
+	contextKeys.keys = live
+}
+
+// Get returns the value of the key in ctx
+func (k *ContextKey[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+func (k *ContextKey[T]) String() string {
+	return k.name
+}
+
+func (k *ContextKey[T]) keyName() string {
+	return k.name
+}
+
+func (k *ContextKey[T]) keySensitive() bool {
+	return k.sensitive
+}
+
+// inspectChainKey finds the innermost trackedValueCtx of a context
+type inspectChainKey struct{}
+
+// trackedValueCtx is the context AddValue returns. Unlike the one of
+// context.WithValue, it can be found through contexts derived from it, and
+// links to the tracked context it was derived from.
+type trackedValueCtx struct {
+	context.Context // holds the value
+	key, value      interface{}
+	prev            *trackedValueCtx
+}
+
+func newTrackedValueCtx(parent context.Context, key, value interface{}) *trackedValueCtx {
+	prev, _ := parent.Value(inspectChainKey{}).(*trackedValueCtx)
+	return &trackedValueCtx{
+		Context: context.WithValue(parent, key, value),
+		key:     key,
+		value:   value,
+		prev:    prev,
+	}
+}
+
+func (c *trackedValueCtx) Value(key interface{}) interface{} {
+	if _, ok := key.(inspectChainKey); ok {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// ContextValue is a value found by InspectContext
+type ContextValue struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Redacted bool   `json:"redacted,omitempty"`
+	Shadowed bool   `json:"shadowed,omitempty"` // hidden by a later value for the same key
+}
+
+// ContextSnapshot describes a context at the time it was inspected
+type ContextSnapshot struct {
+	Values    []ContextValue // innermost first
+	Deadline  time.Time      // zero without a deadline
+	Remaining time.Duration  // until the deadline, negative once passed
+	Done      bool
+	Err       error
+	Cause     error // set if it differs from Err
+}
+
+// InspectContext lists the values of ctx and reports its deadline and
+// cancellation state. It finds every value added through ContextManager and
+// the values of keys created by NewContextKey. A tracked value hidden by one
+// added later with context.WithValue is reported as shadowed, after the value
+// that hides it. Values of sensitive keys are redacted.
+func InspectContext(ctx context.Context) *ContextSnapshot {
+	s := &ContextSnapshot{}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.Deadline = deadline
+		s.Remaining = time.Until(deadline)
+	}
+	if s.Err = ctx.Err(); s.Err != nil {
+		s.Done = true
+		if cause := context.Cause(ctx); cause != s.Err {
+			s.Cause = cause
+		}
+	}
+
+	seen := make(map[interface{}]bool)
+	node, _ := ctx.Value(inspectChainKey{}).(*trackedValueCtx)
+	for ; node != nil; node = node.prev {
+		v := describeValue(node.key, node.value)
+		if seen[node.key] {
+			v.Shadowed = true
+		} else if value := ctx.Value(node.key); !sameValue(value, node.value) {
+			// A value added later with context.WithValue hides the tracked one
+			s.Values = append(s.Values, describeValue(node.key, value))
+			v.Shadowed = true
+		}
+		seen[node.key] = true
+		s.Values = append(s.Values, v)
+	}
+
+	contextKeys.Lock()
+	keys := make([]inspectableKey, 0, len(contextKeys.keys))
+	for _, key := range contextKeys.keys {
+		if k := key(); k != nil {
+			keys = append(keys, k)
+		}
+	}
+	contextKeys.Unlock()
+	for _, k := range keys {
+		if seen[k] {
+			continue
+		}
+		if value := ctx.Value(k); value != nil {
+			s.Values = append(s.Values, describeValue(k, value))
+		}
+	}
+	return s
+}
+
+// sameValue reports whether a value found in a context is the tracked value.
+// An equal value added later can't be told apart, and doesn't need to be.
+func sameValue(found, tracked interface{}) bool {
+	f, t := reflect.ValueOf(found), reflect.ValueOf(tracked)
+	if f.Kind() == reflect.Func && t.Kind() == reflect.Func {
+		return f.Pointer() == t.Pointer()
+	}
+	return reflect.DeepEqual(found, tracked)
+}
+
+// describeValue formats a value, redacting it if its key is sensitive
+func describeValue(key, value interface{}) ContextValue {
+	v := ContextValue{Type: fmt.Sprintf("%T", value)}
+	if k, ok := key.(inspectableKey); ok {
+		v.Key = k.keyName()
+		v.Redacted = k.keySensitive()
+	} else {
+		v.Key = fmt.Sprint(key)
+		v.Redacted = looksSensitive(v.Key)
+	}
+	if v.Redacted {
+		v.Value = redacted
+	} else {
+		v.Value = fmt.Sprintf("%v", value)
+	}
+	return v
+}
+
+// looksSensitive returns true if the name of an unregistered key suggests a secret
+func looksSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveKeyWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// State describes whether the context is still active
+func (s *ContextSnapshot) State() string {
+	switch {
+	case !s.Done:
+		return "active"
+	case s.Err == context.DeadlineExceeded:
+		return "deadline exceeded"
+	default:
+		return "cancelled"
+	}
+}
+
+// String returns the snapshot as indented text
+func (s *ContextSnapshot) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "state: %s", s.State())
+	if s.Cause != nil {
+		fmt.Fprintf(&sb, " (cause: %v)", s.Cause)
+	}
+	sb.WriteString("\n")
+	if s.Deadline.IsZero() {
+		sb.WriteString("deadline: none\n")
+	} else {
+		fmt.Fprintf(&sb, "deadline: %s (remaining %v)\n", s.Deadline.Format(time.RFC3339Nano), s.Remaining.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&sb, "values: %d\n", len(s.Values))
+	for _, v := range s.Values {
+		fmt.Fprintf(&sb, "  %s (%s) = %s", v.Key, v.Type, v.Value)
+		if v.Shadowed {
+			sb.WriteString(" [shadowed]")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// MarshalJSON writes the snapshot with errors and durations as strings
+func (s *ContextSnapshot) MarshalJSON() ([]byte, error) {
+	out := struct {
+		State     string         `json:"state"`
+		Err       string         `json:"err,omitempty"`
+		Cause     string         `json:"cause,omitempty"`
+		Deadline  *time.Time     `json:"deadline,omitempty"`
+		Remaining string         `json:"remaining,omitempty"`
+		Values    []ContextValue `json:"values"`
+	}{State: s.State(), Values: s.Values}
+	if s.Err != nil {
+		out.Err = s.Err.Error()
+	}
+	if s.Cause != nil {
+		out.Cause = s.Cause.Error()
+	}
+	if !s.Deadline.IsZero() {
+		out.Deadline = &s.Deadline
+		out.Remaining = s.Remaining.String()
+	}
+	if out.Values == nil {
+		out.Values = []ContextValue{}
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	userKey  = NewContextKey[string]("user", false)
+	tokenKey = NewContextKey[string]("auth-token", true)
+	retryKey = NewContextKey[int]("retries", false)
+)
+
+// valuesByKey indexes the visible values of a snapshot
+func valuesByKey(s *ContextSnapshot) map[string]ContextValue {
+	m := make(map[string]ContextValue)
+	for _, v := range s.Values {
+		if !v.Shadowed {
+			m[v.Key] = v
+		}
+	}
+	return m
+}
+
+func TestInspectContextValues(t *testing.T) {
+	cm := NewContextManager()
+	ctx := cm.AddValue(context.Background(), userKey, "alice")
+	ctx = cm.AddValue(ctx, tokenKey, "s3cr3t")
+	// Values stay visible through contexts derived without ContextManager
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = cm.AddValue(ctx, "requestID", "12345")
+	ctx = cm.AddValue(ctx, "db_password", "hunter2")
+	ctx = context.WithValue(ctx, retryKey, 3)
+
+	s := InspectContext(ctx)
+	values := valuesByKey(s)
+
+	tests := []struct {
+		key      string
+		typ      string
+		value    string
+		redacted bool
+	}{
+		{"user", "string", "alice", false},
+		{"auth-token", "string", redacted, true},
+		{"requestID", "string", "12345", false},
+		{"db_password", "string", redacted, true},
+		{"retries", "int", "3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v, ok := values[tt.key]
+			if !ok {
+				t.Fatalf("Expected key %s in %+v", tt.key, s.Values)
+			}
+			if v.Type != tt.typ || v.Value != tt.value || v.Redacted != tt.redacted {
+				t.Errorf("Expected %s (%s) redacted=%v, got %+v", tt.value, tt.typ, tt.redacted, v)
+			}
+		})
+	}
+
+	// Innermost values come first
+	if s.Values[0].Key != "db_password" {
+		t.Errorf("Expected the last added value first, got %s", s.Values[0].Key)
+	}
+	// Typed keys still work as keys
+	if user, ok := userKey.Get(ctx); !ok || user != "alice" {
+		t.Errorf("Expected alice, got %q", user)
+	}
+	if got, ok := cm.GetValue(ctx, "requestID"); !ok || got != "12345" {
+		t.Errorf("Expected 12345, got %v", got)
+	}
+}
+
+func TestInspectContextShadowed(t *testing.T) {
+	cm := NewContextManager()
+	ctx := cm.AddValue(context.Background(), userKey, "alice")
+	ctx = cm.AddValue(ctx, userKey, "bob")
+
+	s := InspectContext(ctx)
+	if len(s.Values) != 2 {
+		t.Fatalf("Expected 2 values, got %+v", s.Values)
+	}
+	if s.Values[0].Value != "bob" || s.Values[0].Shadowed {
+		t.Errorf("Expected bob to be visible, got %+v", s.Values[0])
+	}
+	if s.Values[1].Value != "alice" || !s.Values[1].Shadowed {
+		t.Errorf("Expected alice to be shadowed, got %+v", s.Values[1])
+	}
+}
+
+func TestInspectContextShadowedByWithValue(t *testing.T) {
+	cm := NewContextManager()
+	ctx := cm.AddValue(context.Background(), userKey, "alice")
+	ctx = context.WithValue(ctx, userKey, "mallory")
+
+	s := InspectContext(ctx)
+	if len(s.Values) != 2 {
+		t.Fatalf("Expected 2 values, got %+v", s.Values)
+	}
+	if s.Values[0].Value != "mallory" || s.Values[0].Shadowed {
+		t.Errorf("Expected mallory to be visible, got %+v", s.Values[0])
+	}
+	if s.Values[1].Value != "alice" || !s.Values[1].Shadowed {
+		t.Errorf("Expected alice to be shadowed, got %+v", s.Values[1])
+	}
+
+	// Adding the same value again hides nothing
+	ctx = context.WithValue(cm.AddValue(context.Background(), userKey, "alice"), userKey, "alice")
+	if s := InspectContext(ctx); len(s.Values) != 1 || s.Values[0].Shadowed {
+		t.Errorf("Expected a single visible value, got %+v", s.Values)
+	}
+}
+
+func TestContextKeysAreReleased(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		NewContextKey[int]("per-request", false)
+	}
+	runtime.GC()
+
+	contextKeys.Lock()
+	pruneContextKeys()
+	registered := len(contextKeys.keys)
+	contextKeys.Unlock()
+	if registered >= 1000 {
+		t.Errorf("Expected unused keys to be dropped, got %d registered", registered)
+	}
+
+	// Keys still in use stay registered
+	ctx := context.WithValue(context.Background(), retryKey, 3)
+	if values := valuesByKey(InspectContext(ctx)); values["retries"].Value != "3" {
+		t.Errorf("Expected retries to be found, got %+v", values)
+	}
+}
+
+func TestInspectContextState(t *testing.T) {
+	cm := NewContextManager()
+
+	s := InspectContext(context.Background())
+	if s.State() != "active" || !s.Deadline.IsZero() || len(s.Values) != 0 {
+		t.Errorf("Expected an active context without deadline or values, got %+v", s)
+	}
+
+	ctx, cancel := cm.CreateTimeoutContext(context.Background(), time.Hour)
+	s = InspectContext(ctx)
+	if s.Done || s.Remaining <= 59*time.Minute || s.Remaining > time.Hour {
+		t.Errorf("Expected about an hour remaining, got %v", s.Remaining)
+	}
+	cancel()
+	s = InspectContext(ctx)
+	if s.State() != "cancelled" || !errors.Is(s.Err, context.Canceled) {
+		t.Errorf("Expected a cancelled context, got %s (%v)", s.State(), s.Err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if s = InspectContext(ctx); s.State() != "deadline exceeded" || s.Remaining >= 0 {
+		t.Errorf("Expected an exceeded deadline, got %s with %v remaining", s.State(), s.Remaining)
+	}
+
+	cause := errors.New("client went away")
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(cause)
+	if s = InspectContext(ctx); s.Cause != cause {
+		t.Errorf("Expected cause %v, got %v", cause, s.Cause)
+	}
+}
+
+func TestContextSnapshotOutput(t *testing.T) {
+	cm := NewContextManager()
+	ctx, cancel := cm.CreateTimeoutContext(context.Background(), time.Minute)
+	defer cancel()
+	ctx = cm.AddValue(ctx, userKey, "alice")
+	ctx = cm.AddValue(ctx, tokenKey, "s3cr3t")
+	s := InspectContext(ctx)
+
+	text := s.String()
+	for _, expected := range []string{"state: active", "remaining", "user (string) = alice", "auth-token (string) = [REDACTED]"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected text to contain %q, got\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "s3cr3t") {
+		t.Errorf("Expected the token to be redacted, got\n%s", text)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var decoded struct {
+		State     string         `json:"state"`
+		Deadline  *time.Time     `json:"deadline"`
+		Remaining string         `json:"remaining"`
+		Values    []ContextValue `json:"values"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", data, err)
+	}
+	if decoded.State != "active" || decoded.Deadline == nil || decoded.Remaining == "" || len(decoded.Values) != 2 {
+		t.Errorf("Expected state, deadline, remaining time and 2 values, got %s", data)
+	}
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("Expected the token to be redacted, got %s", data)
+	}
+
+	// Without values the JSON has an empty list rather than null
+	data, _ = json.Marshal(InspectContext(context.Background()))
+	if !strings.Contains(string(data), `"values":[]`) {
+		t.Errorf("Expected an empty values list, got %s", data)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNoJob is returned by Lease when no job is due
+var ErrNoJob = errors.New("no job available")
+
+// ErrLeaseLost is returned when a job is completed, failed or extended after
+// its lease expired and another worker may have taken it
+var ErrLeaseLost = errors.New("job lease lost")
+
+// JobStatus is the state of a job in the queue
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending" // waiting for its run time or a worker
+	JobLeased  JobStatus = "leased"  // being worked on until the lease expires
+	JobDone    JobStatus = "done"
+	JobDead    JobStatus = "dead" // failed too often, kept for inspection
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID          int64
+	Queue       string
+	Payload     []byte
+	Status      JobStatus
+	Attempts    int // leases so far, including the current one
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+
+	leaseToken string
+}
+
+// jobSchema holds the statements NewJobQueue runs. Times are Unix nanoseconds
+// so they compare exactly.
+var jobSchema = []string{
+	`CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY,
+		queue TEXT NOT NULL,
+		payload BLOB,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		run_at INTEGER NOT NULL,
+		lease_token TEXT,
+		lease_expires INTEGER,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	)`,
+	"CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(queue, status, run_at)",
+}
+
+// JobQueue is a persistent queue of jobs stored in SQLite. Jobs survive
+// restarts: a job whose worker died is handed out again once its lease expires.
+type JobQueue struct {
+	db    *sql.DB
+	clock Clock
+
+	// VisibilityTimeout is how long a leased job stays hidden from other workers
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the number of leases after which a failing job is dead
+	MaxAttempts int
+	// Backoff returns how long to wait before retrying after the given number of attempts
+	Backoff func(attempts int) time.Duration
+	// PollInterval is how long idle workers wait before looking for jobs again
+	PollInterval time.Duration
+}
+
+// DefaultBackoff doubles the wait after each attempt, from one second up to an hour
+func DefaultBackoff(attempts int) time.Duration {
+	if attempts > 12 {
+		return time.Hour
+	}
+	return min(time.Second<<max(attempts-1, 0), time.Hour)
+}
+
+// OpenJobQueue opens the SQLite database at path and creates a queue in it.
+// SQLite allows one writer at a time, so the queue uses a single connection.
+func OpenJobQueue(path string, clock Clock) (*JobQueue, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	q, err := NewJobQueue(db, clock)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// NewJobQueue creates a new JobQueue in db, creating its table if needed. A
+// nil clock means SystemClock.
+func NewJobQueue(db *sql.DB, clock Clock) (*JobQueue, error) {
+	for _, stmt := range jobSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &JobQueue{
+		db:                db,
+		clock:             clock,
+		VisibilityTimeout: 30 * time.Second,
+		MaxAttempts:       5,
+		Backoff:           DefaultBackoff,
+		PollInterval:      time.Second,
+	}, nil
+}
+
+// Close closes the database
+func (q *JobQueue) Close() error {
+	return q.db.Close()
+}
+
+// Enqueue adds a job to queue that becomes due after delay, and returns its ID
+func (q *JobQueue) Enqueue(ctx context.Context, queue string, payload []byte, delay time.Duration) (int64, error) {
+	now := q.clock.Now()
+	res, err := q.db.ExecContext(ctx,
+		"INSERT INTO jobs (queue, payload, status, max_attempts, run_at, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		queue, payload, JobPending, q.MaxAttempts, now.Add(delay).UnixNano(), now.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Lease takes the job of queue that has been due longest and hides it from
+// other workers for VisibilityTimeout. Jobs whose lease expired are due again.
+// Returns ErrNoJob if no job is due.
+func (q *JobQueue) Lease(ctx context.Context, queue string) (*Job, error) {
+	now := q.clock.Now().UnixNano()
+	// Jobs whose last lease expired without a result have used up their attempts
+	_, err := q.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, last_error = 'lease expired' WHERE queue = ? AND status = ? AND lease_expires <= ? AND attempts >= max_attempts",
+		JobDead, queue, JobLeased, now)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newLeaseToken()
+	if err != nil {
+		return nil, err
+	}
+	// A single statement, so two workers cannot lease the same job
+	row := q.db.QueryRowContext(ctx, `UPDATE jobs
+		SET status = ?, attempts = attempts + 1, lease_token = ?, lease_expires = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE queue = ? AND ((status = ? AND run_at <= ?) OR (status = ? AND lease_expires <= ?))
+			ORDER BY run_at, id LIMIT 1
+		)
+		RETURNING id, queue, payload, status, attempts, max_attempts, run_at, last_error`,
+		JobLeased, token, now+q.VisibilityTimeout.Nanoseconds(),
+		queue, JobPending, now, JobLeased, now)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoJob
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.leaseToken = token
+	return job, nil
+}
+
+func newLeaseToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jobScanner is implemented by *sql.Row and *sql.Rows
+type jobScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row jobScanner) (*Job, error) {
+	j := &Job{}
+	var runAt int64
+	err := row.Scan(&j.ID, &j.Queue, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &runAt, &j.LastError)
+	if err != nil {
+		return nil, err
+	}
+	j.RunAt = time.Unix(0, runAt)
+	return j, nil
+}
+
+// finish updates a job leased as job, or returns ErrLeaseLost
+func (q *JobQueue) finish(ctx context.Context, job *Job, set string, args ...interface{}) error {
+	args = append(args, job.ID, job.leaseToken, JobLeased, q.clock.Now().UnixNano())
+	res, err := q.db.ExecContext(ctx,
+		"UPDATE jobs SET "+set+" WHERE id = ? AND lease_token = ? AND status = ? AND lease_expires > ?", args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("job %d: %w", job.ID, ErrLeaseLost)
+	}
+	return nil
+}
+
+// Complete marks a leased job as done
+// Returns ErrLeaseLost if the lease expired
+func (q *JobQueue) Complete(ctx context.Context, job *Job) error {
+	return q.finish(ctx, job, "status = ?, lease_token = NULL", JobDone)
+}
+
+// Fail records a failed attempt of a leased job. The job is retried after
+// Backoff, or becomes dead once it has used MaxAttempts.
+// Returns ErrLeaseLost if the lease expired
+func (q *JobQueue) Fail(ctx context.Context, job *Job, cause error) error {
+	if job.Attempts >= job.MaxAttempts {
+		return q.finish(ctx, job, "status = ?, lease_token = NULL, last_error = ?", JobDead, cause.Error())
+	}
+	runAt := q.clock.Now().Add(q.Backoff(job.Attempts)).UnixNano()
+	return q.finish(ctx, job, "status = ?, lease_token = NULL, last_error = ?, run_at = ?", JobPending, cause.Error(), runAt)
+}
+
+// Release hands a leased job back without counting the attempt, e.g. when a
+// worker shuts down before it could finish
+// Returns ErrLeaseLost if the lease expired
+func (q *JobQueue) Release(ctx context.Context, job *Job) error {
+	return q.finish(ctx, job, "status = ?, lease_token = NULL, attempts = attempts - 1", JobPending)
+}
+
+// ExtendLease keeps a leased job hidden for another VisibilityTimeout, for
+// work that takes longer than expected
+// Returns ErrLeaseLost if the lease expired
+func (q *JobQueue) ExtendLease(ctx context.Context, job *Job) error {
+	return q.finish(ctx, job, "lease_expires = ?", q.clock.Now().Add(q.VisibilityTimeout).UnixNano())
+}
+
+// Get returns the job with the given ID
+func (q *JobQueue) Get(ctx context.Context, id int64) (*Job, error) {
+	row := q.db.QueryRowContext(ctx,
+		"SELECT id, queue, payload, status, attempts, max_attempts, run_at, last_error FROM jobs WHERE id = ?", id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("job %d not found", id)
+	}
+	return job, err
+}
+
+// DeadLetters returns the dead jobs of queue, oldest first
+func (q *JobQueue) DeadLetters(ctx context.Context, queue string) ([]*Job, error) {
+	rows, err := q.db.QueryContext(ctx,
+		"SELECT id, queue, payload, status, attempts, max_attempts, run_at, last_error FROM jobs WHERE queue = ? AND status = ? ORDER BY id",
+		queue, JobDead)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Requeue makes a dead job due again with a fresh set of attempts
+func (q *JobQueue) Requeue(ctx context.Context, id int64) error {
+	res, err := q.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, attempts = 0, run_at = ? WHERE id = ? AND status = ?",
+		JobPending, q.clock.Now().UnixNano(), id, JobDead)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("job %d is not dead", id)
+	}
+	return nil
+}
+
+// JobHandler does the work of a job. Returning an error fails the attempt.
+type JobHandler func(ctx context.Context, job *Job) error
+
+// Work runs concurrency workers that lease jobs of queue and pass them to
+// handler, until ctx is cancelled. Handlers see ctx; a job whose handler
+// returns after ctx was cancelled is released for another worker rather than
+// failed. Work returns once all handlers have returned, with the first
+// database error if any.
+func (q *JobQueue) Work(ctx context.Context, queue string, concurrency int, handler JobHandler) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be positive, got %d", concurrency)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+	workCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.worker(workCtx, queue, handler); err != nil {
+				errs <- err
+				// One broken worker stops the others
+				stop()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (q *JobQueue) worker(ctx context.Context, queue string, handler JobHandler) error {
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Bookkeeping must go through even when ctx is cancelled mid-job
+		bookkeeping := context.WithoutCancel(ctx)
+		job, err := q.Lease(bookkeeping, queue)
+		if errors.Is(err, ErrNoJob) {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-q.clock.After(q.PollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		herr := callSafely(func() error { return handler(ctx, job) })
+		switch {
+		case herr != nil && ctx.Err() != nil:
+			err = q.Release(bookkeeping, job)
+		case herr != nil:
+			err = q.Fail(bookkeeping, job, herr)
+		default:
+			err = q.Complete(bookkeeping, job)
+		}
+		// Someone else has the job now; that is not a failure of this worker
+		if err != nil && !errors.Is(err, ErrLeaseLost) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires the waiters that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiting = append(waiting, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = waiting
+}
+
+// waitForWaiters blocks until n goroutines are waiting on the clock
+func (c *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		waiting := len(c.waiters)
+		c.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d goroutines to wait on the clock", n)
+}
+
+func newTestJobQueue(t *testing.T, clock Clock) *JobQueue {
+	t.Helper()
+	q, err := OpenJobQueue(filepath.Join(t.TempDir(), "jobs.db"), clock)
+	if err != nil {
+		t.Fatalf("Failed to open job queue: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestJobQueueLease(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	q := newTestJobQueue(t, clock)
+
+	first, _ := q.Enqueue(ctx, "email", []byte("first"), 0)
+	delayed, _ := q.Enqueue(ctx, "email", []byte("delayed"), time.Minute)
+	q.Enqueue(ctx, "sms", []byte("other queue"), 0)
+
+	job, err := q.Lease(ctx, "email")
+	if err != nil {
+		t.Fatalf("Failed to lease: %v", err)
+	}
+	if job.ID != first || string(job.Payload) != "first" || job.Attempts != 1 {
+		t.Errorf("Expected job %d with payload first on attempt 1, got %+v", first, job)
+	}
+
+	// The delayed job is not due and the leased one is hidden
+	if _, err := q.Lease(ctx, "email"); !errors.Is(err, ErrNoJob) {
+		t.Errorf("Expected ErrNoJob, got %v", err)
+	}
+	if err := q.Complete(ctx, job); err != nil {
+		t.Errorf("Failed to complete: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	job, err = q.Lease(ctx, "email")
+	if err != nil || job.ID != delayed {
+		t.Fatalf("Expected the delayed job %d once due, got %v (%v)", delayed, job, err)
+	}
+	if got, _ := q.Get(ctx, first); got.Status != JobDone {
+		t.Errorf("Expected status %s, got %s", JobDone, got.Status)
+	}
+}
+
+func TestJobQueueVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	q := newTestJobQueue(t, clock)
+	q.VisibilityTimeout = 10 * time.Second
+
+	q.Enqueue(ctx, "work", nil, 0)
+	crashed, _ := q.Lease(ctx, "work")
+
+	// Before the lease expires the job stays hidden
+	clock.Advance(9 * time.Second)
+	if _, err := q.Lease(ctx, "work"); !errors.Is(err, ErrNoJob) {
+		t.Errorf("Expected ErrNoJob, got %v", err)
+	}
+
+	// The worker holding the job died; another one gets it
+	clock.Advance(time.Second)
+	again, err := q.Lease(ctx, "work")
+	if err != nil {
+		t.Fatalf("Failed to lease the expired job: %v", err)
+	}
+	if again.ID != crashed.ID || again.Attempts != 2 {
+		t.Errorf("Expected job %d on attempt 2, got %+v", crashed.ID, again)
+	}
+
+	// The first worker can no longer report a result
+	if err := q.Complete(ctx, crashed); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+
+	// Extending the lease keeps the job hidden
+	clock.Advance(8 * time.Second)
+	if err := q.ExtendLease(ctx, again); err != nil {
+		t.Fatalf("Failed to extend lease: %v", err)
+	}
+	clock.Advance(8 * time.Second)
+	if _, err := q.Lease(ctx, "work"); !errors.Is(err, ErrNoJob) {
+		t.Errorf("Expected ErrNoJob after extending the lease, got %v", err)
+	}
+	if err := q.Complete(ctx, again); err != nil {
+		t.Errorf("Failed to complete: %v", err)
+	}
+}
+
+func TestJobQueueRetryAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	q := newTestJobQueue(t, clock)
+	q.MaxAttempts = 3
+	q.Backoff = func(attempts int) time.Duration { return time.Duration(attempts) * time.Minute }
+
+	id, _ := q.Enqueue(ctx, "work", []byte("flaky"), 0)
+	for attempt := 1; attempt <= 3; attempt++ {
+		job, err := q.Lease(ctx, "work")
+		if err != nil {
+			t.Fatalf("Attempt %d: failed to lease: %v", attempt, err)
+		}
+		if job.Attempts != attempt {
+			t.Errorf("Expected attempt %d, got %d", attempt, job.Attempts)
+		}
+		if err := q.Fail(ctx, job, errors.New("boom")); err != nil {
+			t.Fatalf("Attempt %d: failed to fail: %v", attempt, err)
+		}
+
+		// Not due again until the backoff has passed
+		if _, err := q.Lease(ctx, "work"); !errors.Is(err, ErrNoJob) {
+			t.Errorf("Attempt %d: expected ErrNoJob during backoff, got %v", attempt, err)
+		}
+		clock.Advance(time.Duration(attempt) * time.Minute)
+	}
+
+	dead, err := q.DeadLetters(ctx, "work")
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != id || dead[0].LastError != "boom" {
+		t.Fatalf("Expected job %d to be dead with error boom, got %+v", id, dead)
+	}
+
+	if err := q.Requeue(ctx, id); err != nil {
+		t.Fatalf("Failed to requeue: %v", err)
+	}
+	job, err := q.Lease(ctx, "work")
+	if err != nil || job.Attempts != 1 {
+		t.Errorf("Expected the requeued job on attempt 1, got %v (%v)", job, err)
+	}
+	if err := q.Requeue(ctx, id); err == nil {
+		t.Error("Expected an error requeueing a job that is not dead, but got none")
+	}
+}
+
+func TestJobQueueExpiredLeaseDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	q := newTestJobQueue(t, clock)
+	q.MaxAttempts = 1
+
+	id, _ := q.Enqueue(ctx, "work", nil, 0)
+	q.Lease(ctx, "work")
+	clock.Advance(q.VisibilityTimeout)
+
+	if _, err := q.Lease(ctx, "work"); !errors.Is(err, ErrNoJob) {
+		t.Errorf("Expected ErrNoJob, got %v", err)
+	}
+	job, _ := q.Get(ctx, id)
+	if job.Status != JobDead || job.LastError != "lease expired" {
+		t.Errorf("Expected the job to be dead after its last lease expired, got %+v", job)
+	}
+}
+
+func TestJobQueuePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	q, err := OpenJobQueue(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to open job queue: %v", err)
+	}
+	id, _ := q.Enqueue(ctx, "work", []byte("survivor"), 0)
+	q.Close()
+
+	q, err = OpenJobQueue(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen job queue: %v", err)
+	}
+	defer q.Close()
+	job, err := q.Lease(ctx, "work")
+	if err != nil || job.ID != id || string(job.Payload) != "survivor" {
+		t.Errorf("Expected job %d to survive a restart, got %v (%v)", id, job, err)
+	}
+}
+
+func TestJobQueueWork(t *testing.T) {
+	q := newTestJobQueue(t, nil)
+	q.PollInterval = 5 * time.Millisecond
+	q.Backoff = func(int) time.Duration { return 0 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 20; i++ {
+		q.Enqueue(ctx, "work", []byte{byte(i)}, 0)
+	}
+	panicky, _ := q.Enqueue(ctx, "work", []byte("panic"), 0)
+
+	var done atomic.Int32
+	var failedOnce sync.Map
+	handler := func(ctx context.Context, job *Job) error {
+		if string(job.Payload) == "panic" {
+			panic("handler bug")
+		}
+		// Every job fails on its first attempt and is retried
+		if _, seen := failedOnce.LoadOrStore(job.ID, true); !seen {
+			return errors.New("transient")
+		}
+		if done.Add(1) == 20 {
+			cancel()
+		}
+		return nil
+	}
+
+	if err := q.Work(ctx, "work", 4, handler); err != nil {
+		t.Fatalf("Expected Work to stop cleanly, got %v", err)
+	}
+	if done.Load() != 20 {
+		t.Errorf("Expected 20 jobs done, got %d", done.Load())
+	}
+	job, _ := q.Get(context.Background(), panicky)
+	if job.Status == JobDone || job.LastError == "" {
+		t.Errorf("Expected the panicking job to fail, got %+v", job)
+	}
+}
+
+func TestJobQueueWorkGracefulShutdown(t *testing.T) {
+	q := newTestJobQueue(t, nil)
+	q.PollInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, _ := q.Enqueue(ctx, "work", nil, 0)
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		q.Work(ctx, "work", 2, func(ctx context.Context, job *Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Work to return after cancellation")
+	}
+
+	// The interrupted job goes back to the queue without using an attempt
+	job, _ := q.Get(context.Background(), id)
+	if job.Status != JobPending || job.Attempts != 0 {
+		t.Errorf("Expected the job to be pending with no attempts, got %+v", job)
+	}
+}
+
+func TestJobQueueWorkPollsWithClock(t *testing.T) {
+	clock := newFakeClock()
+	q := newTestJobQueue(t, clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Enqueue(ctx, "work", nil, 2*time.Second)
+
+	ran := make(chan struct{})
+	go q.Work(ctx, "work", 1, func(ctx context.Context, job *Job) error {
+		close(ran)
+		return nil
+	})
+
+	// The worker waits on the clock rather than sleeping
+	for i := 0; i < 2; i++ {
+		clock.waitForWaiters(t, 1)
+		select {
+		case <-ran:
+			t.Fatalf("Expected the delayed job to wait for the clock, ran after %d polls", i)
+		default:
+		}
+		clock.Advance(time.Second)
+	}
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the delayed job to run once the clock passed its run time")
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{12, 2048 * time.Second},
+		{13, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := DefaultBackoff(tt.attempts); got != tt.expected {
+			t.Errorf("Attempt %d: expected %v, got %v", tt.attempts, tt.expected, got)
+		}
+	}
+}
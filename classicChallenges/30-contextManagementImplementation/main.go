@@ -55,8 +55,8 @@ func (cm *simpleContextManager) CreateTimeoutContext(parent context.Context, tim
 func (cm *simpleContextManager) AddValue(parent context.Context, key, value interface{}) context.Context {
 	// DONE: Implement value context creation
 	// Hint: Use context.WithValue(parent, key, value)
-	ctx := context.WithValue(parent, key, value)
-	return ctx
+	// The tracked context lets InspectContext list the value later
+	return newTrackedValueCtx(parent, key, value)
 }
 
 // GetValue retrieves a value from the context
@@ -75,7 +75,8 @@ func (cm *simpleContextManager) ExecuteWithContext(ctx context.Context, task fun
 	done := make(chan error, 1)
 
 	go func() {
-		done <- task()
+		// A panic in the task becomes a *PanicError instead of crashing
+		done <- callSafely(task)
 	}()
 
 	// Return context error if cancelled, task error if task fails
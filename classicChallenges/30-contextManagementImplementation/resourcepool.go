@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Acquire once the pool is closed
+var ErrPoolClosed = errors.New("resource pool closed")
+
+// ResourcePoolOptions configures a ResourcePool
+type ResourcePoolOptions[T any] struct {
+	// New creates a resource; required
+	New func(ctx context.Context) (T, error)
+	// Close frees a resource the pool no longer needs; optional
+	Close func(T)
+	// Check returns an error if an idle resource is broken; optional. It
+	// runs before an idle resource is handed out.
+	Check func(ctx context.Context, resource T) error
+	// MaxSize bounds the resources in use and idle together; required
+	MaxSize int
+	// MaxIdleTime is how long a resource may stay idle before it is closed;
+	// zero keeps idle resources forever
+	MaxIdleTime time.Duration
+	// Clock measures idle time; nil means SystemClock
+	Clock Clock
+}
+
+// ResourcePoolStats counts what a pool did
+type ResourcePoolStats struct {
+	Idle      int // resources waiting to be acquired
+	InUse     int // resources leased out
+	Created   int
+	Destroyed int // closed because broken, idle too long, discarded or the pool closed
+	Waits     int // acquisitions that had to wait for a free slot
+}
+
+// idleResource is a resource with the time it was released
+type idleResource[T any] struct {
+	value T
+	since time.Time
+}
+
+// ResourcePool hands out a bounded number of resources such as connections.
+// Acquire waits for a free resource as long as its context allows. For
+// example, a pool of *sql.Conn with MaxSize 4 limits a store to four
+// concurrent transactions.
+type ResourcePool[T any] struct {
+	opts  ResourcePoolOptions[T]
+	slots chan struct{} // holds a token for each resource in use or being created
+
+	mu      sync.Mutex
+	idle    []idleResource[T] // oldest first; taken from the end
+	stats   ResourcePoolStats
+	closed  chan struct{}
+	drained chan struct{} // closed once the pool is closed and all leases released
+}
+
+// NewResourcePool creates a new pool
+func NewResourcePool[T any](opts ResourcePoolOptions[T]) (*ResourcePool[T], error) {
+	if opts.New == nil {
+		return nil, errors.New("resource pool needs a New function")
+	}
+	if opts.MaxSize < 1 {
+		return nil, fmt.Errorf("resource pool size must be positive, got %d", opts.MaxSize)
+	}
+	if opts.MaxIdleTime < 0 {
+		return nil, fmt.Errorf("max idle time must not be negative, got %v", opts.MaxIdleTime)
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	return &ResourcePool[T]{
+		opts:    opts,
+		slots:   make(chan struct{}, opts.MaxSize),
+		closed:  make(chan struct{}),
+		drained: make(chan struct{}),
+	}, nil
+}
+
+// Lease is a resource acquired from a pool. Call Release or Discard exactly
+// once when done with it.
+type Lease[T any] struct {
+	pool  *ResourcePool[T]
+	value T
+	once  sync.Once
+}
+
+// Value returns the leased resource
+func (l *Lease[T]) Value() T {
+	return l.value
+}
+
+// Release returns the resource to the pool for reuse
+func (l *Lease[T]) Release() {
+	l.once.Do(func() { l.pool.release(l.value, true) })
+}
+
+// Discard closes a resource that is broken instead of returning it
+func (l *Lease[T]) Discard() {
+	l.once.Do(func() { l.pool.release(l.value, false) })
+}
+
+// Acquire returns an idle resource, or creates one if the pool is not full.
+// Otherwise it waits until a resource is released, ctx is done or the pool
+// is closed. Idle resources that failed their check or were idle too long are
+// closed and skipped.
+func (p *ResourcePool[T]) Acquire(ctx context.Context) (*Lease[T], error) {
+	if err := p.takeSlot(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		value, ok := p.takeIdle()
+		if !ok {
+			break
+		}
+		if p.opts.Check != nil {
+			if err := p.opts.Check(ctx, value); err != nil {
+				p.destroy(value)
+				continue
+			}
+		}
+		return &Lease[T]{pool: p, value: value}, nil
+	}
+
+	value, err := p.opts.New(ctx)
+	if err != nil {
+		p.releaseSlot()
+		return nil, err
+	}
+	p.mu.Lock()
+	p.stats.Created++
+	p.mu.Unlock()
+	return &Lease[T]{pool: p, value: value}, nil
+}
+
+// takeSlot waits for room for one more resource in use and counts it
+func (p *ResourcePool[T]) takeSlot(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		p.mu.Lock()
+		p.stats.Waits++
+		p.mu.Unlock()
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.closed:
+			return ErrPoolClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Checked under the lock so Close sees every lease
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isClosed() {
+		<-p.slots
+		return ErrPoolClosed
+	}
+	p.stats.InUse++
+	return nil
+}
+
+// releaseSlot ends a lease; it must be called with p.mu unlocked
+func (p *ResourcePool[T]) releaseSlot() {
+	p.mu.Lock()
+	p.stats.InUse--
+	if p.isClosed() && p.stats.InUse == 0 {
+		close(p.drained)
+	}
+	p.mu.Unlock()
+	<-p.slots
+}
+
+// takeIdle takes the most recently used idle resource, closing those that
+// were idle too long
+func (p *ResourcePool[T]) takeIdle() (T, bool) {
+	p.mu.Lock()
+	var expired []T
+	if p.opts.MaxIdleTime > 0 {
+		now := p.opts.Clock.Now()
+		n := 0
+		for n < len(p.idle) && now.Sub(p.idle[n].since) >= p.opts.MaxIdleTime {
+			expired = append(expired, p.idle[n].value)
+			n++
+		}
+		p.idle = p.idle[n:]
+	}
+	var value T
+	ok := len(p.idle) > 0
+	if ok {
+		value = p.idle[len(p.idle)-1].value
+		p.idle = p.idle[:len(p.idle)-1]
+	}
+	p.mu.Unlock()
+
+	for _, r := range expired {
+		p.destroy(r)
+	}
+	return value, ok
+}
+
+// release ends a lease, keeping the resource if reuse is true and the pool
+// is still open
+func (p *ResourcePool[T]) release(value T, reuse bool) {
+	p.mu.Lock()
+	keep := reuse && !p.isClosed()
+	if keep {
+		p.idle = append(p.idle, idleResource[T]{value: value, since: p.opts.Clock.Now()})
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		p.destroy(value)
+	}
+	p.releaseSlot()
+}
+
+// destroy closes a resource the pool drops
+func (p *ResourcePool[T]) destroy(value T) {
+	if p.opts.Close != nil {
+		p.opts.Close(value)
+	}
+	p.mu.Lock()
+	p.stats.Destroyed++
+	p.mu.Unlock()
+}
+
+func (p *ResourcePool[T]) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stats returns what the pool did so far
+func (p *ResourcePool[T]) Stats() ResourcePoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Idle = len(p.idle)
+	return stats
+}
+
+// Close stops the pool from handing out resources, closes the idle ones and
+// waits until every lease was released, whose resources are then closed too.
+// Returns ctx.Err() if ctx is done first; the remaining resources are still
+// closed when released.
+func (p *ResourcePool[T]) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.isClosed() {
+		close(p.closed)
+		if p.stats.InUse == 0 {
+			close(p.drained)
+		}
+	}
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, r := range idle {
+		p.destroy(r.value)
+	}
+	select {
+	case <-p.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
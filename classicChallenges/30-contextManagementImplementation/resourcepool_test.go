@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeConn is a resource that records what the pool did with it
+type fakeConn struct {
+	id     int
+	broken atomic.Bool
+	closed atomic.Bool
+}
+
+// newConnPool creates a pool of fakeConns with ids counting from 1
+func newConnPool(t *testing.T, maxSize int, maxIdle time.Duration, clock Clock) *ResourcePool[*fakeConn] {
+	t.Helper()
+	var next atomic.Int32
+	p, err := NewResourcePool(ResourcePoolOptions[*fakeConn]{
+		New: func(ctx context.Context) (*fakeConn, error) {
+			return &fakeConn{id: int(next.Add(1))}, nil
+		},
+		Close: func(c *fakeConn) { c.closed.Store(true) },
+		Check: func(ctx context.Context, c *fakeConn) error {
+			if c.broken.Load() {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+		MaxSize:     maxSize,
+		MaxIdleTime: maxIdle,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	return p
+}
+
+func mustAcquire(t *testing.T, p *ResourcePool[*fakeConn]) *Lease[*fakeConn] {
+	t.Helper()
+	lease, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+	return lease
+}
+
+func TestResourcePoolReuse(t *testing.T) {
+	p := newConnPool(t, 2, 0, nil)
+
+	a := mustAcquire(t, p)
+	b := mustAcquire(t, p)
+	if a.Value() == b.Value() {
+		t.Fatal("Expected two different resources")
+	}
+	a.Release()
+	a.Release() // a second release does nothing
+
+	c := mustAcquire(t, p)
+	if c.Value() != a.Value() {
+		t.Errorf("Expected resource %d to be reused, got %d", a.Value().id, c.Value().id)
+	}
+
+	// A discarded resource is closed and replaced
+	b.Discard()
+	if !b.Value().closed.Load() {
+		t.Error("Expected the discarded resource to be closed")
+	}
+	d := mustAcquire(t, p)
+	if d.Value().id != 3 {
+		t.Errorf("Expected a new resource 3, got %d", d.Value().id)
+	}
+
+	expected := ResourcePoolStats{Idle: 0, InUse: 2, Created: 3, Destroyed: 1}
+	if got := p.Stats(); got != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, got)
+	}
+}
+
+func TestResourcePoolAcquireWaits(t *testing.T) {
+	p := newConnPool(t, 1, 0, nil)
+	held := mustAcquire(t, p)
+
+	tests := []struct {
+		name     string
+		ctx      func() (context.Context, context.CancelFunc)
+		expected error
+	}{
+		{"Deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 20*time.Millisecond)
+		}, context.DeadlineExceeded},
+		{"Cancelled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			if _, err := p.Acquire(ctx); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// A waiting Acquire gets the resource once it is released
+	time.AfterFunc(20*time.Millisecond, held.Release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lease, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Expected to acquire the released resource, got %v", err)
+	}
+	if lease.Value() != held.Value() {
+		t.Errorf("Expected resource %d, got %d", held.Value().id, lease.Value().id)
+	}
+	if waits := p.Stats().Waits; waits != 3 {
+		t.Errorf("Expected 3 waits, got %d", waits)
+	}
+}
+
+func TestResourcePoolHealthCheck(t *testing.T) {
+	p := newConnPool(t, 2, 0, nil)
+	a := mustAcquire(t, p)
+	a.Release()
+	a.Value().broken.Store(true)
+
+	b := mustAcquire(t, p)
+	if b.Value() == a.Value() {
+		t.Fatal("Expected the broken resource to be skipped")
+	}
+	if !a.Value().closed.Load() {
+		t.Error("Expected the broken resource to be closed")
+	}
+}
+
+func TestResourcePoolMaxIdleTime(t *testing.T) {
+	clock := newFakeClock()
+	p := newConnPool(t, 3, time.Minute, clock)
+
+	old := mustAcquire(t, p)
+	old.Release()
+	clock.Advance(45 * time.Second)
+	recent := mustAcquire(t, p)
+	if recent.Value() != old.Value() {
+		t.Fatal("Expected the resource to be reused within the idle time")
+	}
+	recent.Release()
+
+	clock.Advance(time.Minute)
+	fresh := mustAcquire(t, p)
+	if fresh.Value() == old.Value() || !old.Value().closed.Load() {
+		t.Error("Expected the resource idle for too long to be closed and replaced")
+	}
+}
+
+func TestResourcePoolClose(t *testing.T) {
+	p := newConnPool(t, 1, 0, nil)
+	idle := mustAcquire(t, p)
+	idle.Release()
+	held := mustAcquire(t, p)
+	if held.Value() != idle.Value() {
+		t.Fatal("Expected the idle resource to be reused")
+	}
+
+	// A waiter is woken by Close
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := p.Acquire(context.Background())
+		waiterErr <- err
+	}()
+	waitFor(t, "the waiter to block", func() bool { return p.Stats().Waits == 1 })
+
+	closeErr := make(chan error, 1)
+	go func() { closeErr <- p.Close(context.Background()) }()
+	if err := <-waiterErr; !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed for the waiter, got %v", err)
+	}
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+
+	// Close waits for the outstanding lease
+	select {
+	case err := <-closeErr:
+		t.Fatalf("Expected Close to wait for the lease, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	held.Release()
+	if err := <-closeErr; err != nil {
+		t.Errorf("Expected Close to succeed, got %v", err)
+	}
+	if !held.Value().closed.Load() {
+		t.Error("Expected the released resource to be closed")
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Errorf("Expected closing twice to succeed, got %v", err)
+	}
+}
+
+func TestResourcePoolCloseTimeout(t *testing.T) {
+	p := newConnPool(t, 1, 0, nil)
+	held := mustAcquire(t, p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	held.Release()
+	if !held.Value().closed.Load() {
+		t.Error("Expected a resource released after Close gave up to be closed")
+	}
+}
+
+func TestResourcePoolNewError(t *testing.T) {
+	fail := true
+	p, err := NewResourcePool(ResourcePoolOptions[int]{
+		New: func(ctx context.Context) (int, error) {
+			if fail {
+				return 0, errors.New("dial failed")
+			}
+			return 1, nil
+		},
+		MaxSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	if _, err := p.Acquire(context.Background()); err == nil {
+		t.Fatal("Expected the error of New, but got none")
+	}
+	// The failed attempt gave its slot back
+	fail = false
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := p.Acquire(ctx); err != nil {
+		t.Errorf("Expected to acquire after a failed New, got %v", err)
+	}
+}
+
+func TestNewResourcePoolErrors(t *testing.T) {
+	newInt := func(ctx context.Context) (int, error) { return 0, nil }
+	tests := []struct {
+		name string
+		opts ResourcePoolOptions[int]
+	}{
+		{"Missing New", ResourcePoolOptions[int]{MaxSize: 1}},
+		{"Zero size", ResourcePoolOptions[int]{New: newInt}},
+		{"Negative idle time", ResourcePoolOptions[int]{New: newInt, MaxSize: 1, MaxIdleTime: -time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewResourcePool(tt.opts); err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestResourcePoolLimitsTransactions(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "store.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY, quantity INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	const maxSize = 2
+	p, err := NewResourcePool(ResourcePoolOptions[*sql.Conn]{
+		New:     func(ctx context.Context) (*sql.Conn, error) { return db.Conn(ctx) },
+		Close:   func(c *sql.Conn) { c.Close() },
+		Check:   func(ctx context.Context, c *sql.Conn) error { return c.PingContext(ctx) },
+		MaxSize: maxSize,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+
+	var active, peak atomic.Int32
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			lease, err := p.Acquire(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer lease.Release()
+
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			tx, err := lease.Value().BeginTx(ctx, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO products (quantity) VALUES (1)"); err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Transaction failed: %v", err)
+	}
+
+	if peak.Load() > maxSize {
+		t.Errorf("Expected at most %d concurrent transactions, got %d", maxSize, peak.Load())
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM products").Scan(&count)
+	if count != 10 {
+		t.Errorf("Expected 10 products, got %d", count)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Errorf("Failed to close pool: %v", err)
+	}
+	if stats := p.Stats(); stats.Created > maxSize || stats.Destroyed != stats.Created {
+		t.Errorf("Expected at most %d connections, all closed, got %+v", maxSize, stats)
+	}
+}
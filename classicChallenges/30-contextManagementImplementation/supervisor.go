@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrRestartIntensity is returned by a supervisor whose children failed more
+// often than its restart limit allows
+var ErrRestartIntensity = errors.New("restart intensity exceeded")
+
+// PanicError is the error of a goroutine that panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte // stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// callSafely calls fn, turning a panic into a *PanicError
+func callSafely(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// RestartStrategy decides which children a supervisor restarts when one fails
+type RestartStrategy int
+
+const (
+	OneForOne RestartStrategy = iota // restart only the child that exited
+	OneForAll                        // stop all children and restart them together
+)
+
+func (s RestartStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	default:
+		return fmt.Sprintf("RestartStrategy(%d)", int(s))
+	}
+}
+
+// RestartPolicy decides whether a child is restarted after it exits
+type RestartPolicy int
+
+const (
+	Permanent RestartPolicy = iota // always restarted
+	Transient                      // restarted only after an error or panic
+	Temporary                      // never restarted
+)
+
+// ChildSpec describes a service run by a supervisor
+type ChildSpec struct {
+	Name    string
+	Run     func(ctx context.Context) error // should return when ctx is cancelled
+	Restart RestartPolicy
+}
+
+// shouldRestart returns true if the child is restarted after exiting with err
+func (c ChildSpec) shouldRestart(err error) bool {
+	switch c.Restart {
+	case Permanent:
+		return true
+	case Transient:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Supervisor runs child services and restarts them when they exit, like an
+// Erlang/OTP supervisor. Since Run has the signature of ChildSpec.Run,
+// supervisors can supervise other supervisors to form a tree.
+type Supervisor struct {
+	strategy    RestartStrategy
+	maxRestarts int
+	period      time.Duration
+	children    []ChildSpec
+	clock       Clock
+
+	// OnChildExit, if set, is called from Run each time a child exits, with
+	// the error it returned or a *PanicError
+	OnChildExit func(child string, err error)
+}
+
+// NewSupervisor creates a new supervisor that allows at most maxRestarts
+// restarts within period before giving up
+func NewSupervisor(strategy RestartStrategy, maxRestarts int, period time.Duration, children ...ChildSpec) (*Supervisor, error) {
+	if strategy != OneForOne && strategy != OneForAll {
+		return nil, fmt.Errorf("unknown restart strategy %v", strategy)
+	}
+	if maxRestarts < 0 || period <= 0 {
+		return nil, fmt.Errorf("invalid restart intensity: %d restarts in %v", maxRestarts, period)
+	}
+	names := make(map[string]bool, len(children))
+	for _, c := range children {
+		if c.Run == nil {
+			return nil, fmt.Errorf("child %q has no Run function", c.Name)
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate child name %q", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return &Supervisor{
+		strategy:    strategy,
+		maxRestarts: maxRestarts,
+		period:      period,
+		children:    children,
+		clock:       SystemClock,
+	}, nil
+}
+
+// childExit is sent by a child's goroutine when it returns
+type childExit struct {
+	index int
+	err   error
+}
+
+// Run starts all children and supervises them until ctx is cancelled, which
+// stops the children, or until they all exited for good. Returns ctx.Err()
+// after a shutdown, and ErrRestartIntensity if children failed too often.
+// Run waits for every child to return before it returns.
+func (s *Supervisor) Run(ctx context.Context) error {
+	exits := make(chan childExit)
+	cancels := make([]context.CancelFunc, len(s.children))
+	running := 0
+
+	start := func(i int) {
+		childCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		running++
+		go func() {
+			err := callSafely(func() error { return s.children[i].Run(childCtx) })
+			exits <- childExit{index: i, err: err}
+		}()
+	}
+	// exited records that a child returned
+	exited := func(e childExit) {
+		running--
+		cancels[e.index]()
+		cancels[e.index] = nil
+		if s.OnChildExit != nil {
+			s.OnChildExit(s.children[e.index].Name, e.err)
+		}
+	}
+	// stopAll cancels the running children and waits for them
+	stopAll := func() {
+		for _, cancel := range cancels {
+			if cancel != nil {
+				cancel()
+			}
+		}
+		for running > 0 {
+			exited(<-exits)
+		}
+	}
+
+	for i := range s.children {
+		start(i)
+	}
+
+	var restarts []time.Time
+	for running > 0 {
+		e := <-exits
+		exited(e)
+		child := s.children[e.index]
+		if ctx.Err() != nil || !child.shouldRestart(e.err) {
+			continue
+		}
+
+		// Forget restarts that are older than the period
+		now := s.clock.Now()
+		for len(restarts) > 0 && now.Sub(restarts[0]) >= s.period {
+			restarts = restarts[1:]
+		}
+		if len(restarts) >= s.maxRestarts {
+			stopAll()
+			cause := e.err
+			if cause == nil {
+				cause = errors.New("returned without error")
+			}
+			return fmt.Errorf("%w: %d restarts in %v, child %q: %w",
+				ErrRestartIntensity, len(restarts), s.period, child.Name, cause)
+		}
+		restarts = append(restarts, now)
+
+		switch s.strategy {
+		case OneForOne:
+			start(e.index)
+		case OneForAll:
+			// The failed child and every child that was still running
+			var restart []int
+			for i, cancel := range cancels {
+				if i == e.index || cancel != nil && s.children[i].Restart != Temporary {
+					restart = append(restart, i)
+				}
+			}
+			stopAll()
+			for _, i := range restart {
+				start(i)
+			}
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it is true or a second passed
+func waitFor(t *testing.T, description string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blockUntilDone is a well-behaved service
+func blockUntilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// runSupervisor runs s in the background and returns a function that waits
+// for Run to return
+func runSupervisor(t *testing.T, ctx context.Context, s *Supervisor) func() error {
+	t.Helper()
+	result := make(chan error, 1)
+	go func() { result <- s.Run(ctx) }()
+	return func() error {
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("Expected the supervisor to return")
+			return nil
+		}
+	}
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	var flakyStarts, steadyStarts atomic.Int32
+	s, err := NewSupervisor(OneForOne, 5, time.Minute,
+		ChildSpec{Name: "flaky", Run: func(ctx context.Context) error {
+			if flakyStarts.Add(1) <= 2 {
+				panic("flaky service crashed")
+			}
+			return blockUntilDone(ctx)
+		}},
+		ChildSpec{Name: "steady", Run: func(ctx context.Context) error {
+			steadyStarts.Add(1)
+			return blockUntilDone(ctx)
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+	var mu sync.Mutex
+	var panics []*PanicError
+	s.OnChildExit = func(child string, err error) {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			mu.Lock()
+			panics = append(panics, pe)
+			mu.Unlock()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := runSupervisor(t, ctx, s)
+	waitFor(t, "the flaky child to be restarted twice", func() bool { return flakyStarts.Load() == 3 })
+	cancel()
+	if err := wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if steadyStarts.Load() != 1 {
+		t.Errorf("Expected the steady child to start once, got %d", steadyStarts.Load())
+	}
+	if len(panics) != 2 {
+		t.Fatalf("Expected 2 panics, got %d", len(panics))
+	}
+	if panics[0].Value != "flaky service crashed" || !strings.Contains(string(panics[0].Stack), "supervisor_test.go") {
+		t.Errorf("Expected the panic value and a stack through the test, got %v\n%s", panics[0].Value, panics[0].Stack)
+	}
+}
+
+func TestSupervisorOneForAll(t *testing.T) {
+	var failed atomic.Bool
+	var aStarts, bStarts, tempStarts atomic.Int32
+	tempUp := make(chan struct{})
+	s, err := NewSupervisor(OneForAll, 5, time.Minute,
+		ChildSpec{Name: "a", Run: func(ctx context.Context) error {
+			aStarts.Add(1)
+			return blockUntilDone(ctx)
+		}},
+		ChildSpec{Name: "b", Run: func(ctx context.Context) error {
+			bStarts.Add(1)
+			if failed.Load() {
+				return blockUntilDone(ctx)
+			}
+			// Fail once, after the other children are up
+			select {
+			case <-tempUp:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			failed.Store(true)
+			return errors.New("lost connection")
+		}},
+		ChildSpec{Name: "temp", Restart: Temporary, Run: func(ctx context.Context) error {
+			if tempStarts.Add(1) == 1 {
+				close(tempUp)
+			}
+			return blockUntilDone(ctx)
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := runSupervisor(t, ctx, s)
+	waitFor(t, "a to be restarted", func() bool { return aStarts.Load() == 2 })
+	waitFor(t, "b to be restarted", func() bool { return bStarts.Load() == 2 })
+	cancel()
+	wait()
+
+	if aStarts.Load() != 2 || bStarts.Load() != 2 {
+		t.Errorf("Expected a and b to start twice, got %d and %d", aStarts.Load(), bStarts.Load())
+	}
+	if tempStarts.Load() != 1 {
+		t.Errorf("Expected the temporary child not to be restarted, got %d starts", tempStarts.Load())
+	}
+}
+
+func TestSupervisorRestartIntensity(t *testing.T) {
+	var starts atomic.Int32
+	cause := errors.New("bad config")
+	s, err := NewSupervisor(OneForOne, 3, time.Minute,
+		ChildSpec{Name: "broken", Run: func(ctx context.Context) error {
+			starts.Add(1)
+			return cause
+		}},
+		ChildSpec{Name: "fine", Run: blockUntilDone},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+
+	err = runSupervisor(t, context.Background(), s)()
+	if !errors.Is(err, ErrRestartIntensity) || !errors.Is(err, cause) {
+		t.Errorf("Expected ErrRestartIntensity wrapping the cause, got %v", err)
+	}
+	if starts.Load() != 4 {
+		t.Errorf("Expected 1 start and 3 restarts, got %d starts", starts.Load())
+	}
+}
+
+func TestSupervisorRestartPeriod(t *testing.T) {
+	clock := newFakeClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var starts atomic.Int32
+	s, err := NewSupervisor(OneForOne, 1, time.Minute,
+		ChildSpec{Name: "slow-failing", Run: func(ctx context.Context) error {
+			if starts.Add(1) == 10 {
+				cancel()
+				return blockUntilDone(ctx)
+			}
+			// Failures a period apart never exceed the intensity
+			clock.Advance(time.Minute)
+			return errors.New("failed")
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+	s.clock = clock
+
+	if err := runSupervisor(t, ctx, s)(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if starts.Load() != 10 {
+		t.Errorf("Expected 10 starts, got %d", starts.Load())
+	}
+}
+
+func TestSupervisorRestartPolicies(t *testing.T) {
+	var permanentStarts, transientStarts, temporaryStarts atomic.Int32
+	s, err := NewSupervisor(OneForOne, 10, time.Minute,
+		ChildSpec{Name: "permanent", Restart: Permanent, Run: func(ctx context.Context) error {
+			// Returns normally but is restarted anyway
+			if permanentStarts.Add(1) < 3 {
+				return nil
+			}
+			return blockUntilDone(ctx)
+		}},
+		ChildSpec{Name: "transient", Restart: Transient, Run: func(ctx context.Context) error {
+			if transientStarts.Add(1) == 1 {
+				return errors.New("failed once")
+			}
+			return nil
+		}},
+		ChildSpec{Name: "temporary", Restart: Temporary, Run: func(ctx context.Context) error {
+			temporaryStarts.Add(1)
+			return errors.New("never restarted")
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := runSupervisor(t, ctx, s)
+	waitFor(t, "the permanent child to be restarted", func() bool { return permanentStarts.Load() == 3 })
+	waitFor(t, "the transient child to finish", func() bool { return transientStarts.Load() == 2 })
+	cancel()
+	wait()
+
+	if transientStarts.Load() != 2 || temporaryStarts.Load() != 1 {
+		t.Errorf("Expected 2 transient and 1 temporary starts, got %d and %d",
+			transientStarts.Load(), temporaryStarts.Load())
+	}
+}
+
+func TestSupervisorReturnsWhenChildrenFinish(t *testing.T) {
+	s, err := NewSupervisor(OneForOne, 1, time.Minute,
+		ChildSpec{Name: "once", Restart: Transient, Run: func(ctx context.Context) error { return nil }},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+	if err := runSupervisor(t, context.Background(), s)(); err != nil {
+		t.Errorf("Expected nil once all children finished, got %v", err)
+	}
+}
+
+func TestSupervisorTree(t *testing.T) {
+	var leafStarts, leafStops atomic.Int32
+	leaf := func(ctx context.Context) error {
+		leafStarts.Add(1)
+		defer leafStops.Add(1)
+		return blockUntilDone(ctx)
+	}
+	inner, err := NewSupervisor(OneForOne, 1, time.Minute,
+		ChildSpec{Name: "leaf-1", Run: leaf},
+		ChildSpec{Name: "leaf-2", Run: leaf},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+	root, err := NewSupervisor(OneForOne, 1, time.Minute,
+		ChildSpec{Name: "inner", Run: inner.Run},
+		ChildSpec{Name: "leaf-3", Run: leaf},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create supervisor: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := runSupervisor(t, ctx, root)
+	waitFor(t, "all leaves to start", func() bool { return leafStarts.Load() == 3 })
+	cancel()
+	wait()
+	if leafStops.Load() != 3 {
+		t.Errorf("Expected shutdown to stop all 3 leaves, got %d", leafStops.Load())
+	}
+}
+
+func TestNewSupervisorErrors(t *testing.T) {
+	run := func(ctx context.Context) error { return nil }
+	tests := []struct {
+		name        string
+		strategy    RestartStrategy
+		maxRestarts int
+		period      time.Duration
+		children    []ChildSpec
+	}{
+		{"Unknown strategy", RestartStrategy(7), 1, time.Second, nil},
+		{"Negative restarts", OneForOne, -1, time.Second, nil},
+		{"Zero period", OneForAll, 1, 0, nil},
+		{"Missing Run", OneForOne, 1, time.Second, []ChildSpec{{Name: "a"}}},
+		{"Duplicate name", OneForOne, 1, time.Second, []ChildSpec{{Name: "a", Run: run}, {Name: "a", Run: run}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSupervisor(tt.strategy, tt.maxRestarts, tt.period, tt.children...); err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestExecuteWithContextRecoversPanic(t *testing.T) {
+	cm := NewContextManager()
+	err := cm.ExecuteWithContext(context.Background(), func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected a *PanicError, got %v", err)
+	}
+	if len(pe.Stack) == 0 {
+		t.Error("Expected the panic to capture a stack")
+	}
+	// Runtime panics are errors and can be unwrapped
+	var re interface{ RuntimeError() }
+	if !errors.As(err, &re) {
+		t.Errorf("Expected to unwrap a runtime error, got %v", pe.Value)
+	}
+}
@@ -14,6 +14,11 @@ type BankAccount struct {
 	Balance    float64
 	MinBalance float64
 	mu         sync.Mutex // For thread safety
+
+	fraud      *FraudDetector        // optional, see SetFraudDetector
+	history    []Operation           // screened operations, only kept while fraud is set
+	held       map[int]heldOperation // operations waiting for review by hold ID
+	nextHoldID int
 }
 
 // Constants for account operations
@@ -155,6 +160,7 @@ func (a *BankAccount) Deposit(amount float64) error {
 // Withdraw removes the specified amount from the account balance.
 // It returns an error if the amount is invalid, exceeds the transaction limit,
 // or would bring the balance below the minimum required balance.
+// If a fraud detector is set, the withdrawal is screened first and may fail with a FraudError.
 func (a *BankAccount) Withdraw(amount float64) error {
 	if amount < 0 {
 		return &NegativeAmountError{
@@ -172,8 +178,15 @@ func (a *BankAccount) Withdraw(amount float64) error {
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
+
+	op := Operation{Kind: OperationWithdraw, Amount: amount}
+	if err := a.screen(&op, nil); err != nil {
+		return err
+	}
+
 	remain := a.Balance - amount
 	if remain < a.MinBalance {
+		a.record(op)
 		return &InsufficientFundsError{
 			Code:       "INSUFFICIENT_FUNDS",
 			Message:    "account balance cannot be less than min amount",
@@ -181,12 +194,15 @@ func (a *BankAccount) Withdraw(amount float64) error {
 		}
 	}
 	a.Balance = remain
+	op.Executed = true
+	a.record(op)
 	return nil
 }
 
 // Transfer moves the specified amount from this account to the target account.
 // It returns an error if the amount is invalid, exceeds the transaction limit,
 // or would bring the balance below the minimum required balance.
+// If a fraud detector is set, the transfer is screened first and may fail with a FraudError.
 func (a *BankAccount) Transfer(amount float64, target *BankAccount) error {
 	if amount < 0 {
 		return &NegativeAmountError{
@@ -218,30 +234,25 @@ func (a *BankAccount) Transfer(amount float64, target *BankAccount) error {
 		}
 	}
 
-	// The lock order is determined by the account ID number
-	var first, second *BankAccount
-	if a.ID < target.ID {
-		first = a
-		second = target
-	} else if a.ID > target.ID {
-		first = target
-		second = a
-	} else {
-		// a.ID == target.ID but a != target (duplicate IDs)
+	if a.ID == target.ID {
+		// a != target but with duplicate IDs there is no lock order
 		return &AccountError{
 			Code:      "DUPLICATE_ACCOUNT_ID",
 			Message:   "source and target accounts have duplicate IDs",
 			AccountID: a.ID,
 		}
 	}
+	unlock := lockPair(a, target)
+	defer unlock()
 
-	first.mu.Lock()
-	second.mu.Lock()
-	defer second.mu.Unlock()
-	defer first.mu.Unlock()
+	op := Operation{Kind: OperationTransfer, Amount: amount, Counterparty: target.ID}
+	if err := a.screen(&op, target); err != nil {
+		return err
+	}
 
 	remain := a.Balance - amount
 	if remain < a.MinBalance {
+		a.record(op)
 		return &InsufficientFundsError{
 			Code:       "INSUFFICIENT_FUNDS",
 			Message:    "account balance cannot be less than min amount",
@@ -250,5 +261,22 @@ func (a *BankAccount) Transfer(amount float64, target *BankAccount) error {
 	}
 	a.Balance = remain
 	target.Balance += amount
+	op.Executed = true
+	a.record(op)
 	return nil
 }
+
+// lockPair locks two accounts with different IDs, in the order of their IDs
+// so that concurrent transfers cannot deadlock, and returns the unlock.
+func lockPair(a, b *BankAccount) (unlock func()) {
+	first, second := a, b
+	if b.ID < a.ID {
+		first, second = b, a
+	}
+	first.mu.Lock()
+	second.mu.Lock()
+	return func() {
+		second.mu.Unlock()
+		first.mu.Unlock()
+	}
+}
@@ -0,0 +1,500 @@
+package challenge7
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Decision is the outcome of screening an operation against the fraud rules.
+// Decisions are ordered by severity, so the most severe one wins.
+type Decision int
+
+const (
+	DecisionAllow  Decision = iota // operation proceeds normally
+	DecisionFlag                   // operation proceeds but is marked as suspicious
+	DecisionReview                 // operation is held until someone reviews it
+	DecisionBlock                  // operation is rejected
+)
+
+// String returns the name of the decision
+func (d Decision) String() string {
+	switch d {
+	case DecisionAllow:
+		return "ALLOW"
+	case DecisionFlag:
+		return "FLAG"
+	case DecisionReview:
+		return "REVIEW"
+	case DecisionBlock:
+		return "BLOCK"
+	}
+	return fmt.Sprintf("Decision(%d)", int(d))
+}
+
+// OperationKind identifies the type of a screened operation.
+type OperationKind string
+
+const (
+	OperationWithdraw OperationKind = "WITHDRAW"
+	OperationTransfer OperationKind = "TRANSFER"
+)
+
+// Operation records a withdrawal or transfer attempt together with the
+// fraud decision and score it received.
+type Operation struct {
+	Kind         OperationKind
+	Amount       float64
+	Counterparty string // target account ID, empty for withdrawals
+	Time         time.Time
+	Decision     Decision
+	Score        float64
+	Reasons      []string
+	Executed     bool // whether the balance was actually changed
+	HoldID       int  // set while an operation held for review awaits a decision
+}
+
+// RuleResult is the verdict of a single rule.
+type RuleResult struct {
+	Decision Decision
+	Score    float64
+	Reason   string
+}
+
+// FraudRule inspects an operation in the light of the account history.
+// history holds earlier operations of the same account, oldest first.
+type FraudRule interface {
+	Name() string
+	Evaluate(op Operation, history []Operation) RuleResult
+}
+
+// WindowedRule is implemented by rules that only look at operations younger
+// than HistoryWindow: zero if they ignore the history, AllHistory if they
+// need all of it. Accounts keep the history for the longest window of their
+// detector's rules, and rules that are not windowed count as AllHistory.
+// Either way an account keeps at most the detector's MaxHistory operations.
+type WindowedRule interface {
+	FraudRule
+	HistoryWindow() time.Duration
+}
+
+// AllHistory is the window of rules that look at the whole history
+const AllHistory = time.Duration(math.MaxInt64)
+
+// DefaultMaxHistory is how many operations an account keeps at most
+const DefaultMaxHistory = 1000
+
+// FraudError occurs when an operation is blocked or held for review by the fraud rules.
+type FraudError struct {
+	Code     string
+	Message  string
+	Decision Decision
+	Score    float64
+	Reasons  []string
+	HoldID   int // for operations held for review, see ApproveHeld
+}
+
+func (e *FraudError) Error() string {
+	return fmt.Sprintf("[%s] %s, decision: %s, score: %.2f, reasons: %s",
+		e.Code, e.Message, e.Decision, e.Score, strings.Join(e.Reasons, "; "))
+}
+
+// FraudDetector evaluates a set of rules on every withdrawal and transfer attempt.
+type FraudDetector struct {
+	// MaxHistory caps the operations kept per account, whatever the windows
+	// of the rules
+	MaxHistory int
+
+	rules []FraudRule
+	now   func() time.Time
+}
+
+// NewFraudDetector creates a detector with the given rules.
+// If no rules are passed, DefaultFraudRules is used.
+func NewFraudDetector(rules ...FraudRule) *FraudDetector {
+	if len(rules) == 0 {
+		rules = DefaultFraudRules()
+	}
+	return &FraudDetector{
+		MaxHistory: DefaultMaxHistory,
+		rules:      rules,
+		now:        time.Now,
+	}
+}
+
+// DefaultFraudRules returns the standard rule set.
+func DefaultFraudRules() []FraudRule {
+	return []FraudRule{
+		&LargeAmountRule{Multiplier: 5, MinHistory: 3, Window: 30 * 24 * time.Hour, Decision: DecisionReview, Score: 40},
+		&RapidSuccessionRule{Window: time.Minute, MaxTransfers: 3, Decision: DecisionBlock, Score: 60},
+		&NewCounterpartyRule{Window: 180 * 24 * time.Hour, Decision: DecisionFlag, Score: 10},
+		&StructuringRule{Threshold: 0.9, RoundTo: 100, Decision: DecisionReview, Score: 50},
+	}
+}
+
+// Evaluate runs every rule and fills in the decision, score and reasons of op.
+// The most severe decision wins and the scores are summed.
+func (d *FraudDetector) Evaluate(op *Operation, history []Operation) {
+	op.Decision = DecisionAllow
+	op.Score = 0
+	op.Reasons = nil
+	for _, rule := range d.rules {
+		res := rule.Evaluate(*op, history)
+		if res.Decision == DecisionAllow && res.Score == 0 {
+			continue
+		}
+		op.Score += res.Score
+		if res.Decision > op.Decision {
+			op.Decision = res.Decision
+		}
+		op.Reasons = append(op.Reasons, fmt.Sprintf("%s: %s", rule.Name(), res.Reason))
+	}
+}
+
+// historyWindow returns the longest window the rules look at
+func (d *FraudDetector) historyWindow() time.Duration {
+	var longest time.Duration
+	for _, rule := range d.rules {
+		w, ok := rule.(WindowedRule)
+		if !ok {
+			return AllHistory
+		}
+		longest = max(longest, w.HistoryWindow())
+	}
+	return longest
+}
+
+// trim drops the operations no rule looks at any more, keeping at most
+// MaxHistory. It reuses the backing array of history.
+func (d *FraudDetector) trim(history []Operation, now time.Time) []Operation {
+	start := 0
+	if window := d.historyWindow(); window != AllHistory {
+		for start < len(history) && now.Sub(history[start].Time) > window {
+			start++
+		}
+	}
+	if d.MaxHistory > 0 && len(history)-start > d.MaxHistory {
+		start = len(history) - d.MaxHistory
+	}
+	if start == 0 {
+		return history
+	}
+	n := copy(history, history[start:])
+	clear(history[n:])
+	return history[:n]
+}
+
+// within reports whether h is recent enough for a rule with the given
+// window, where zero means no limit
+func within(op, h Operation, window time.Duration) bool {
+	return window <= 0 || op.Time.Sub(h.Time) <= window
+}
+
+// ruleWindow returns the history window of a rule whose zero window means
+// the whole history
+func ruleWindow(window time.Duration) time.Duration {
+	if window <= 0 {
+		return AllHistory
+	}
+	return window
+}
+
+// LargeAmountRule fires when an amount is much larger than the average of
+// the executed operations so far.
+type LargeAmountRule struct {
+	Multiplier float64       // how many times the average counts as large
+	MinHistory int           // executed operations needed before the rule applies
+	Window     time.Duration // how far back to average, zero for the whole history
+	Decision   Decision
+	Score      float64
+}
+
+// Name returns the rule name
+func (r *LargeAmountRule) Name() string { return "large_amount" }
+
+// HistoryWindow returns how far back the average goes
+func (r *LargeAmountRule) HistoryWindow() time.Duration { return ruleWindow(r.Window) }
+
+// Evaluate compares the amount with the historical average
+func (r *LargeAmountRule) Evaluate(op Operation, history []Operation) RuleResult {
+	var sum float64
+	var count int
+	for _, h := range history {
+		if h.Executed && within(op, h, r.Window) {
+			sum += h.Amount
+			count++
+		}
+	}
+	if count == 0 || count < r.MinHistory {
+		return RuleResult{}
+	}
+	avg := sum / float64(count)
+	if op.Amount > avg*r.Multiplier {
+		return RuleResult{
+			Decision: r.Decision,
+			Score:    r.Score,
+			Reason:   fmt.Sprintf("amount %.2f exceeds %.1fx the average of %.2f", op.Amount, r.Multiplier, avg),
+		}
+	}
+	return RuleResult{}
+}
+
+// RapidSuccessionRule fires when too many transfers are attempted within a time window.
+type RapidSuccessionRule struct {
+	Window       time.Duration
+	MaxTransfers int // number of earlier transfers in the window that triggers the rule
+	Decision     Decision
+	Score        float64
+}
+
+// Name returns the rule name
+func (r *RapidSuccessionRule) Name() string { return "rapid_succession" }
+
+// HistoryWindow returns the window transfers are counted in
+func (r *RapidSuccessionRule) HistoryWindow() time.Duration { return r.Window }
+
+// Evaluate counts the transfer attempts inside the window
+func (r *RapidSuccessionRule) Evaluate(op Operation, history []Operation) RuleResult {
+	if op.Kind != OperationTransfer {
+		return RuleResult{}
+	}
+	count := 0
+	for _, h := range history {
+		if h.Kind == OperationTransfer && op.Time.Sub(h.Time) <= r.Window {
+			count++
+		}
+	}
+	if count >= r.MaxTransfers {
+		return RuleResult{
+			Decision: r.Decision,
+			Score:    r.Score,
+			Reason:   fmt.Sprintf("%d transfers within %s", count, r.Window),
+		}
+	}
+	return RuleResult{}
+}
+
+// NewCounterpartyRule fires on a transfer to an account that did not receive
+// an executed transfer from this account within Window.
+type NewCounterpartyRule struct {
+	Window   time.Duration // zero for the whole history
+	Decision Decision
+	Score    float64
+}
+
+// Name returns the rule name
+func (r *NewCounterpartyRule) Name() string { return "new_counterparty" }
+
+// HistoryWindow returns how far back earlier transfers are looked for
+func (r *NewCounterpartyRule) HistoryWindow() time.Duration { return ruleWindow(r.Window) }
+
+// Evaluate looks for the counterparty in the executed transfers
+func (r *NewCounterpartyRule) Evaluate(op Operation, history []Operation) RuleResult {
+	if op.Kind != OperationTransfer {
+		return RuleResult{}
+	}
+	for _, h := range history {
+		if h.Kind == OperationTransfer && h.Executed && h.Counterparty == op.Counterparty && within(op, h, r.Window) {
+			return RuleResult{}
+		}
+	}
+	return RuleResult{
+		Decision: r.Decision,
+		Score:    r.Score,
+		Reason:   fmt.Sprintf("first transfer to %s", op.Counterparty),
+	}
+}
+
+// StructuringRule fires on round amounts just below MaxTransactionAmount,
+// a common way to stay under reporting limits.
+type StructuringRule struct {
+	Threshold float64 // fraction of MaxTransactionAmount where the rule starts
+	RoundTo   float64 // amounts that are a multiple of this count as round
+	Decision  Decision
+	Score     float64
+}
+
+// Name returns the rule name
+func (r *StructuringRule) Name() string { return "structuring" }
+
+// HistoryWindow returns zero, as the rule ignores the history
+func (r *StructuringRule) HistoryWindow() time.Duration { return 0 }
+
+// Evaluate checks whether the amount is round and close to the limit
+func (r *StructuringRule) Evaluate(op Operation, history []Operation) RuleResult {
+	if op.Amount < MaxTransactionAmount*r.Threshold || op.Amount >= MaxTransactionAmount {
+		return RuleResult{}
+	}
+	if r.RoundTo > 0 && math.Mod(op.Amount, r.RoundTo) != 0 {
+		return RuleResult{}
+	}
+	return RuleResult{
+		Decision: r.Decision,
+		Score:    r.Score,
+		Reason:   fmt.Sprintf("round amount %.2f just below the limit of %.2f", op.Amount, MaxTransactionAmount),
+	}
+}
+
+// SetFraudDetector enables fraud screening for withdrawals and transfers from this account.
+// Passing nil disables screening.
+func (a *BankAccount) SetFraudDetector(d *FraudDetector) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fraud = d
+}
+
+// History returns a copy of the screened operations, oldest first.
+func (a *BankAccount) History() []Operation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := make([]Operation, len(a.history))
+	copy(res, a.history)
+	return res
+}
+
+// screen evaluates op against the fraud rules. It returns a FraudError if the
+// operation must not proceed; operations needing review are held, together
+// with the target account of a transfer. The caller must hold a.mu.
+func (a *BankAccount) screen(op *Operation, target *BankAccount) error {
+	if a.fraud == nil {
+		return nil
+	}
+	op.Time = a.fraud.now()
+	a.fraud.Evaluate(op, a.history)
+
+	switch op.Decision {
+	case DecisionBlock:
+		a.record(*op)
+		return &FraudError{
+			Code:     "FRAUD_BLOCKED",
+			Message:  "operation blocked by fraud rules",
+			Decision: op.Decision,
+			Score:    op.Score,
+			Reasons:  op.Reasons,
+		}
+	case DecisionReview:
+		a.nextHoldID++
+		op.HoldID = a.nextHoldID
+		if a.held == nil {
+			a.held = make(map[int]heldOperation)
+		}
+		a.held[op.HoldID] = heldOperation{op: *op, target: target}
+		a.record(*op)
+		return &FraudError{
+			Code:     "REVIEW_REQUIRED",
+			Message:  "operation held for review, see ApproveHeld and RejectHeld",
+			Decision: op.Decision,
+			Score:    op.Score,
+			Reasons:  op.Reasons,
+			HoldID:   op.HoldID,
+		}
+	}
+	return nil
+}
+
+// record appends op to the history if screening is enabled, and drops the
+// operations the rules no longer need. The caller must hold a.mu.
+func (a *BankAccount) record(op Operation) {
+	if a.fraud != nil {
+		a.history = a.fraud.trim(append(a.history, op), op.Time)
+	}
+}
+
+// heldOperation is an operation waiting for review, with the account a
+// transfer goes to
+type heldOperation struct {
+	op     Operation
+	target *BankAccount
+}
+
+// HeldOperations returns the operations waiting for review, oldest first.
+func (a *BankAccount) HeldOperations() []Operation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := make([]Operation, 0, len(a.held))
+	for _, h := range a.held {
+		res = append(res, h.op)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].HoldID < res[j].HoldID })
+	return res
+}
+
+// ApproveHeld carries out an operation that was held for review, without
+// screening it again. The balance checks still apply: if the funds are no
+// longer there, the operation fails and stays held.
+func (a *BankAccount) ApproveHeld(holdID int) error {
+	a.mu.Lock()
+	h, ok := a.held[holdID]
+	a.mu.Unlock()
+	if !ok {
+		return holdNotFound(a.ID, holdID)
+	}
+
+	if h.target != nil {
+		unlock := lockPair(a, h.target)
+		defer unlock()
+	} else {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+	}
+	// Someone else may have decided in the meantime
+	if _, ok := a.held[holdID]; !ok {
+		return holdNotFound(a.ID, holdID)
+	}
+
+	remain := a.Balance - h.op.Amount
+	if remain < a.MinBalance {
+		return &InsufficientFundsError{
+			Code:       "INSUFFICIENT_FUNDS",
+			Message:    "account balance cannot be less than min amount",
+			MinBalance: a.MinBalance,
+		}
+	}
+	a.Balance = remain
+	if h.target != nil {
+		h.target.Balance += h.op.Amount
+	}
+	delete(a.held, holdID)
+
+	// The attempt is in the history already. Mark it executed there, so rules
+	// counting attempts do not count it twice.
+	for i := range a.history {
+		if a.history[i].HoldID == holdID {
+			a.history[i].Executed = true
+			a.history[i].Reasons = append(slices.Clone(a.history[i].Reasons), "approved after review")
+			return nil
+		}
+	}
+
+	// It has been trimmed from the history, so the approval is recorded instead
+	op := h.op
+	op.Executed = true
+	if a.fraud != nil {
+		op.Time = a.fraud.now()
+	}
+	op.Reasons = append(slices.Clone(op.Reasons), "approved after review")
+	a.record(op)
+	return nil
+}
+
+// RejectHeld drops an operation that was held for review.
+func (a *BankAccount) RejectHeld(holdID int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.held[holdID]; !ok {
+		return holdNotFound(a.ID, holdID)
+	}
+	delete(a.held, holdID)
+	return nil
+}
+
+func holdNotFound(accountID string, holdID int) error {
+	return &AccountError{
+		Code:      "HOLD_NOT_FOUND",
+		Message:   fmt.Sprintf("no operation held for review with ID %d", holdID),
+		AccountID: accountID,
+	}
+}
@@ -0,0 +1,384 @@
+package challenge7
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a clock function that advances by step on every call
+func fakeClock(start time.Time, step time.Duration) func() time.Time {
+	current := start.Add(-step)
+	return func() time.Time {
+		current = current.Add(step)
+		return current
+	}
+}
+
+func newScreenedAccount(t *testing.T, id string, balance float64, step time.Duration, rules ...FraudRule) *BankAccount {
+	t.Helper()
+	account, err := NewBankAccount(id, "Owner "+id, balance, 0)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	detector := NewFraudDetector(rules...)
+	detector.now = fakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), step)
+	account.SetFraudDetector(detector)
+	return account
+}
+
+func TestFraudRules(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	executed := func(kind OperationKind, amount float64, counterparty string, at time.Duration) Operation {
+		return Operation{Kind: kind, Amount: amount, Counterparty: counterparty, Time: base.Add(at), Executed: true}
+	}
+
+	testCases := []struct {
+		name     string
+		rule     FraudRule
+		op       Operation
+		history  []Operation
+		expected Decision
+	}{
+		{
+			name: "Large amount against history",
+			rule: &LargeAmountRule{Multiplier: 5, MinHistory: 2, Decision: DecisionReview, Score: 40},
+			op:   Operation{Kind: OperationWithdraw, Amount: 1000},
+			history: []Operation{
+				executed(OperationWithdraw, 100, "", 0),
+				executed(OperationWithdraw, 100, "", time.Hour),
+			},
+			expected: DecisionReview,
+		},
+		{
+			name:     "Large amount without enough history",
+			rule:     &LargeAmountRule{Multiplier: 5, MinHistory: 2, Decision: DecisionReview, Score: 40},
+			op:       Operation{Kind: OperationWithdraw, Amount: 1000},
+			history:  []Operation{executed(OperationWithdraw, 100, "", 0)},
+			expected: DecisionAllow,
+		},
+		{
+			name: "Rapid succession of transfers",
+			rule: &RapidSuccessionRule{Window: time.Minute, MaxTransfers: 2, Decision: DecisionBlock, Score: 60},
+			op:   Operation{Kind: OperationTransfer, Amount: 10, Counterparty: "B", Time: base.Add(40 * time.Second)},
+			history: []Operation{
+				executed(OperationTransfer, 10, "B", 0),
+				executed(OperationTransfer, 10, "B", 20*time.Second),
+			},
+			expected: DecisionBlock,
+		},
+		{
+			name: "Transfers outside the window",
+			rule: &RapidSuccessionRule{Window: time.Minute, MaxTransfers: 2, Decision: DecisionBlock, Score: 60},
+			op:   Operation{Kind: OperationTransfer, Amount: 10, Counterparty: "B", Time: base.Add(2 * time.Hour)},
+			history: []Operation{
+				executed(OperationTransfer, 10, "B", 0),
+				executed(OperationTransfer, 10, "B", 20*time.Second),
+			},
+			expected: DecisionAllow,
+		},
+		{
+			name:     "New counterparty",
+			rule:     &NewCounterpartyRule{Decision: DecisionFlag, Score: 10},
+			op:       Operation{Kind: OperationTransfer, Amount: 10, Counterparty: "C"},
+			history:  []Operation{executed(OperationTransfer, 10, "B", 0)},
+			expected: DecisionFlag,
+		},
+		{
+			name:     "Known counterparty",
+			rule:     &NewCounterpartyRule{Decision: DecisionFlag, Score: 10},
+			op:       Operation{Kind: OperationTransfer, Amount: 10, Counterparty: "B"},
+			history:  []Operation{executed(OperationTransfer, 10, "B", 0)},
+			expected: DecisionAllow,
+		},
+		{
+			name:     "Round amount just below the limit",
+			rule:     &StructuringRule{Threshold: 0.9, RoundTo: 100, Decision: DecisionReview, Score: 50},
+			op:       Operation{Kind: OperationWithdraw, Amount: 9900},
+			expected: DecisionReview,
+		},
+		{
+			name:     "Odd amount just below the limit",
+			rule:     &StructuringRule{Threshold: 0.9, RoundTo: 100, Decision: DecisionReview, Score: 50},
+			op:       Operation{Kind: OperationWithdraw, Amount: 9937.15},
+			expected: DecisionAllow,
+		},
+		{
+			name:     "Round amount far below the limit",
+			rule:     &StructuringRule{Threshold: 0.9, RoundTo: 100, Decision: DecisionReview, Score: 50},
+			op:       Operation{Kind: OperationWithdraw, Amount: 5000},
+			expected: DecisionAllow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := tc.rule.Evaluate(tc.op, tc.history)
+			if res.Decision != tc.expected {
+				t.Errorf("Expected decision %s but got %s (%s)", tc.expected, res.Decision, res.Reason)
+			}
+		})
+	}
+}
+
+func TestFraudDetectorCombinesRules(t *testing.T) {
+	detector := NewFraudDetector(
+		&NewCounterpartyRule{Decision: DecisionFlag, Score: 10},
+		&StructuringRule{Threshold: 0.9, RoundTo: 100, Decision: DecisionReview, Score: 50},
+	)
+
+	op := Operation{Kind: OperationTransfer, Amount: 9500, Counterparty: "B"}
+	detector.Evaluate(&op, nil)
+
+	if op.Decision != DecisionReview {
+		t.Errorf("Expected the most severe decision %s but got %s", DecisionReview, op.Decision)
+	}
+	if op.Score != 60 {
+		t.Errorf("Expected summed score 60 but got %.2f", op.Score)
+	}
+	if len(op.Reasons) != 2 {
+		t.Errorf("Expected 2 reasons but got %d: %v", len(op.Reasons), op.Reasons)
+	}
+}
+
+func TestWithdrawScreening(t *testing.T) {
+	account := newScreenedAccount(t, "SRC", 20000, time.Hour)
+
+	if err := account.Withdraw(9900); err == nil {
+		t.Fatal("Expected structuring withdrawal to be held for review")
+	} else {
+		var fraudErr *FraudError
+		if !errors.As(err, &fraudErr) {
+			t.Fatalf("Expected FraudError but got %T", err)
+		}
+		if fraudErr.Code != "REVIEW_REQUIRED" {
+			t.Errorf("Expected code REVIEW_REQUIRED but got %s", fraudErr.Code)
+		}
+	}
+	if account.Balance != 20000 {
+		t.Errorf("Expected balance to remain 20000.00 but got %.2f", account.Balance)
+	}
+
+	if err := account.Withdraw(123.45); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+
+	history := account.History()
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 recorded operations but got %d", len(history))
+	}
+	if history[0].Decision != DecisionReview || history[0].Executed {
+		t.Errorf("Expected first operation to be an unexecuted review, got %+v", history[0])
+	}
+	if history[1].Decision != DecisionAllow || !history[1].Executed {
+		t.Errorf("Expected second operation to be an executed allow, got %+v", history[1])
+	}
+}
+
+func TestTransferScreening(t *testing.T) {
+	source := newScreenedAccount(t, "SRC", 1000, time.Second)
+	target, _ := NewBankAccount("TGT", "Target", 0, 0)
+
+	// First transfer to a new counterparty is flagged but goes through
+	if err := source.Transfer(10, target); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+	if err := source.Transfer(10, target); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+	if err := source.Transfer(10, target); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+
+	// The fourth transfer within a minute is blocked
+	err := source.Transfer(10, target)
+	var fraudErr *FraudError
+	if !errors.As(err, &fraudErr) {
+		t.Fatalf("Expected FraudError but got %v", err)
+	}
+	if fraudErr.Code != "FRAUD_BLOCKED" {
+		t.Errorf("Expected code FRAUD_BLOCKED but got %s", fraudErr.Code)
+	}
+
+	if source.Balance != 970 {
+		t.Errorf("Expected source balance 970.00 but got %.2f", source.Balance)
+	}
+	if target.Balance != 30 {
+		t.Errorf("Expected target balance 30.00 but got %.2f", target.Balance)
+	}
+
+	history := source.History()
+	if len(history) != 4 {
+		t.Fatalf("Expected 4 recorded operations but got %d", len(history))
+	}
+	if history[0].Decision != DecisionFlag {
+		t.Errorf("Expected first transfer to be flagged but got %s", history[0].Decision)
+	}
+	if history[1].Decision != DecisionAllow {
+		t.Errorf("Expected second transfer to be allowed but got %s", history[1].Decision)
+	}
+	if history[3].Decision != DecisionBlock || history[3].Executed {
+		t.Errorf("Expected last transfer to be blocked, got %+v", history[3])
+	}
+	if len(target.History()) != 0 {
+		t.Errorf("Expected target account to have no history")
+	}
+}
+
+func TestNoScreeningWithoutDetector(t *testing.T) {
+	account, _ := NewBankAccount("ACC", "Owner", 20000, 0)
+
+	if err := account.Withdraw(9900); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+	if len(account.History()) != 0 {
+		t.Errorf("Expected no history without a fraud detector")
+	}
+}
+
+func TestHistoryIsTrimmed(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      []FraudRule
+		maxHistory int
+		expected   int
+	}{
+		// Operations are a day apart; the window keeps the last 3 days
+		{"Longest rule window", []FraudRule{
+			&RapidSuccessionRule{Window: time.Minute, MaxTransfers: 3, Decision: DecisionBlock},
+			&LargeAmountRule{Multiplier: 5, MinHistory: 3, Window: 3 * 24 * time.Hour, Decision: DecisionReview},
+		}, 100, 4},
+		{"Rules ignoring the history", []FraudRule{&StructuringRule{Threshold: 0.9, Decision: DecisionReview}}, 100, 1},
+		{"Whole history up to the cap", []FraudRule{&LargeAmountRule{Multiplier: 5, Decision: DecisionReview}}, 5, 5},
+		{"Rules without a window count as whole history", []FraudRule{&customRule{}}, 7, 7},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			account := newScreenedAccount(t, "SRC", 100000, 24*time.Hour, tc.rules...)
+			account.fraud.MaxHistory = tc.maxHistory
+			for i := 0; i < 20; i++ {
+				if err := account.Withdraw(10); err != nil {
+					t.Fatalf("Did not expect error but got: %v", err)
+				}
+			}
+			history := account.History()
+			if len(history) != tc.expected {
+				t.Fatalf("Expected %d operations to be kept but got %d", tc.expected, len(history))
+			}
+			// The newest ones are kept
+			if last := history[len(history)-1]; !last.Time.Equal(time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("Expected the last operation to be the newest, got %v", last.Time)
+			}
+		})
+	}
+}
+
+// customRule is a rule that does not say which history it needs
+type customRule struct{}
+
+func (customRule) Name() string                               { return "custom" }
+func (customRule) Evaluate(Operation, []Operation) RuleResult { return RuleResult{} }
+
+func TestApproveAndRejectHeld(t *testing.T) {
+	source := newScreenedAccount(t, "SRC", 30000, time.Hour)
+	target, _ := NewBankAccount("TGT", "Target", 0, 0)
+
+	holdOf := func(err error) int {
+		t.Helper()
+		var fraudErr *FraudError
+		if !errors.As(err, &fraudErr) || fraudErr.Code != "REVIEW_REQUIRED" || fraudErr.HoldID == 0 {
+			t.Fatalf("Expected a held operation but got %v", err)
+		}
+		return fraudErr.HoldID
+	}
+	transferHold := holdOf(source.Transfer(9900, target))
+	withdrawHold := holdOf(source.Withdraw(9800))
+
+	held := source.HeldOperations()
+	if len(held) != 2 || held[0].HoldID != transferHold || held[1].HoldID != withdrawHold {
+		t.Fatalf("Expected both operations to be held, got %+v", held)
+	}
+
+	if err := source.ApproveHeld(transferHold); err != nil {
+		t.Fatalf("Failed to approve transfer: %v", err)
+	}
+	if source.Balance != 20100 || target.Balance != 9900 {
+		t.Errorf("Expected balances 20100.00 and 9900.00 but got %.2f and %.2f", source.Balance, target.Balance)
+	}
+	history := source.History()
+	if len(history) != 2 || !history[0].Executed || history[0].HoldID != transferHold || history[0].Counterparty != "TGT" {
+		t.Errorf("Expected the held transfer to be marked as executed, got %+v", history)
+	}
+
+	if err := source.RejectHeld(withdrawHold); err != nil {
+		t.Fatalf("Failed to reject withdrawal: %v", err)
+	}
+	if source.Balance != 20100 || len(source.HeldOperations()) != 0 {
+		t.Errorf("Expected the rejected withdrawal to leave the balance alone and nothing held")
+	}
+
+	// Decided holds cannot be decided again
+	for _, err := range []error{source.ApproveHeld(transferHold), source.RejectHeld(withdrawHold)} {
+		var accErr *AccountError
+		if !errors.As(err, &accErr) || accErr.Code != "HOLD_NOT_FOUND" {
+			t.Errorf("Expected HOLD_NOT_FOUND but got %v", err)
+		}
+	}
+}
+
+func TestApproveHeldChecksFunds(t *testing.T) {
+	account := newScreenedAccount(t, "SRC", 10000, time.Hour)
+	var fraudErr *FraudError
+	if err := account.Withdraw(9900); !errors.As(err, &fraudErr) {
+		t.Fatalf("Expected a held withdrawal but got %v", err)
+	}
+	if err := account.Withdraw(500); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+
+	var fundsErr *InsufficientFundsError
+	if err := account.ApproveHeld(fraudErr.HoldID); !errors.As(err, &fundsErr) {
+		t.Errorf("Expected InsufficientFundsError but got %v", err)
+	}
+	if len(account.HeldOperations()) != 1 {
+		t.Errorf("Expected the operation to stay held")
+	}
+}
+
+// reviewTransfersAbove holds transfers larger than its limit for review
+type reviewTransfersAbove float64
+
+func (reviewTransfersAbove) Name() string { return "review_transfers_above" }
+func (r reviewTransfersAbove) Evaluate(op Operation, _ []Operation) RuleResult {
+	if op.Kind == OperationTransfer && op.Amount > float64(r) {
+		return RuleResult{Decision: DecisionReview, Reason: "large transfer"}
+	}
+	return RuleResult{}
+}
+
+func TestApprovedTransferCountsOnce(t *testing.T) {
+	source := newScreenedAccount(t, "SRC", 10000, time.Minute,
+		reviewTransfersAbove(1000),
+		&RapidSuccessionRule{Window: time.Hour, MaxTransfers: 2, Decision: DecisionBlock})
+	target, _ := NewBankAccount("TGT", "Target", 0, 0)
+
+	var fraudErr *FraudError
+	if err := source.Transfer(5000, target); !errors.As(err, &fraudErr) || fraudErr.HoldID == 0 {
+		t.Fatalf("Expected a held transfer but got %v", err)
+	}
+	if err := source.ApproveHeld(fraudErr.HoldID); err != nil {
+		t.Fatalf("Failed to approve transfer: %v", err)
+	}
+
+	// The approved transfer is one earlier transfer, so one more is allowed
+	if err := source.Transfer(10, target); err != nil {
+		t.Fatalf("Did not expect error but got: %v", err)
+	}
+	if err := source.Transfer(10, target); !errors.As(err, &fraudErr) || fraudErr.Code != "FRAUD_BLOCKED" {
+		t.Errorf("Expected the third transfer to be blocked but got %v", err)
+	}
+	if source.Balance != 4990 || target.Balance != 5010 {
+		t.Errorf("Expected balances 4990.00 and 5010.00 but got %.2f and %.2f", source.Balance, target.Balance)
+	}
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrExists is returned instead of overwriting existing files
+var ErrExists = errors.New("refusing to overwrite existing files")
+
+// File is a generated file, named relative to the challenge directory
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Generate returns the stub, the table-driven tests and the notes skeleton
+// of the challenge
+func (s *Spec) Generate() ([]File, error) {
+	stub, err := s.goFile(s.File, s.renderStub())
+	if err != nil {
+		return nil, err
+	}
+	testName := strings.TrimSuffix(s.File, ".go") + "_test.go"
+	tests, err := s.goFile(testName, s.renderTests())
+	if err != nil {
+		return nil, err
+	}
+	return []File{
+		{Name: s.File, Content: stub},
+		{Name: testName, Content: tests},
+		{Name: s.Notes, Content: []byte(s.renderNotes())},
+	}, nil
+}
+
+// goFile adds the package clause and the imports body uses, and formats it
+func (s *Spec) goFile(name, body string) ([]byte, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), name, "package p\n"+body, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("generating %s: %v", name, err)
+	}
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	var imports []string
+	for _, p := range append([]string{"fmt", "reflect", "testing"}, s.Imports...) {
+		if used[importName(p)] && !slices.Contains(imports, p) {
+			imports = append(imports, p)
+		}
+	}
+	slices.Sort(imports)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n", s.Package)
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, p := range imports {
+			fmt.Fprintf(&b, "\t%q\n", p)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body)
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %v", name, err)
+	}
+	return src, nil
+}
+
+func (s *Spec) renderStub() string {
+	var b strings.Builder
+	if s.Types != "" {
+		b.WriteString(strings.TrimSpace(s.Types) + "\n\n")
+	}
+	if s.Package == "main" {
+		b.WriteString("func main() {\n")
+		s.writeExamples(&b)
+		b.WriteString("}\n\n")
+	}
+	for _, f := range s.Functions {
+		if f.Doc == "" {
+			fmt.Fprintf(&b, "// TODO: document %s.\n", f.name)
+		} else {
+			for _, line := range strings.Split(f.Doc, "\n") {
+				b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+			}
+		}
+		fmt.Fprintf(&b, "%s {\n\t// TODO: implement\n", f.Signature)
+		if zeros := s.zeroResults(f); len(zeros) > 0 {
+			fmt.Fprintf(&b, "\treturn %s\n", strings.Join(zeros, ", "))
+		}
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+// writeExamples writes the body of main, printing what each example
+// returns
+func (s *Spec) writeExamples(b *strings.Builder) {
+	n := 0
+	for _, f := range s.Functions {
+		for _, args := range f.Examples {
+			call := fmt.Sprintf("%s(%s)", f.name, args)
+			if len(f.results) > 0 || f.hasErr {
+				call = fmt.Sprintf("fmt.Println(%s)", call)
+			}
+			fmt.Fprintf(b, "\t%s\n", call)
+			n++
+		}
+	}
+	if n == 0 {
+		b.WriteString("\t// TODO: try out the functions\n")
+	}
+}
+
+func (s *Spec) renderTests() string {
+	var b strings.Builder
+	for _, f := range s.Functions {
+		s.writeTest(&b, f)
+	}
+	if s.Package != "main" {
+		for _, f := range s.Functions {
+			if len(f.Examples) == 0 {
+				continue
+			}
+			// Examples of unexported functions need a suffix to be
+			// recognised: Example_name
+			name := f.name
+			if !token.IsExported(name) {
+				name = "_" + name
+			}
+			fmt.Fprintf(&b, "func Example%s() {\n", name)
+			for _, args := range f.Examples {
+				if len(f.results) > 0 || f.hasErr {
+					fmt.Fprintf(&b, "\tfmt.Println(%s(%s))\n", f.name, args)
+				} else {
+					fmt.Fprintf(&b, "\t%s(%s)\n", f.name, args)
+				}
+			}
+			b.WriteString("}\n\n")
+		}
+	}
+	return b.String()
+}
+
+// writeTest writes a table-driven test of f in the style of the existing
+// challenges: a row per test case, a subtest per row
+func (s *Spec) writeTest(b *strings.Builder, f *Function) {
+	fields := testFields(f)
+	expected := make([]string, len(f.results))
+	results := make([]string, len(f.results))
+	for i := range f.results {
+		expected[i], results[i] = "expected", "result"
+		if len(f.results) > 1 {
+			expected[i] += strconv.Itoa(i + 1)
+			results[i] += strconv.Itoa(i + 1)
+		}
+	}
+
+	fmt.Fprintf(b, "func Test%s(t *testing.T) {\n\ttests := []struct {\n\t\tname string\n", exported(f.name))
+	for i, p := range f.params {
+		fmt.Fprintf(b, "\t\t%s %s\n", fields[i], p.typ)
+	}
+	for i, r := range f.results {
+		fmt.Fprintf(b, "\t\t%s %s\n", expected[i], f.text(r))
+	}
+	if f.hasErr {
+		b.WriteString("\t\twantErr bool\n")
+	}
+	b.WriteString("\t}{\n")
+	if len(f.Tests) == 0 {
+		b.WriteString("\t\t// TODO: add test cases\n")
+	}
+	for _, tc := range f.Tests {
+		row := append([]string{strconv.Quote(tc.Name)}, tc.args...)
+		if tc.want != nil {
+			row = append(row, tc.want...)
+		} else {
+			row = append(row, s.zeroResults(f)[:len(f.results)]...)
+		}
+		if f.hasErr {
+			row = append(row, strconv.FormatBool(tc.Err))
+		}
+		fmt.Fprintf(b, "\t\t{%s},\n", strings.Join(row, ", "))
+	}
+	b.WriteString("\t}\n\n\tfor _, tt := range tests {\n\t\tt.Run(tt.name, func(t *testing.T) {\n")
+
+	args := make([]string, len(f.params))
+	for i, p := range f.params {
+		args[i] = "tt." + fields[i]
+		if p.variadic {
+			args[i] += "..."
+		}
+	}
+	call := fmt.Sprintf("%s(%s)", f.name, strings.Join(args, ", "))
+	lhs := results
+	if f.hasErr {
+		lhs = append(slices.Clone(results), "err")
+	}
+	if len(lhs) > 0 {
+		fmt.Fprintf(b, "\t\t\t%s := %s\n", strings.Join(lhs, ", "), call)
+	} else {
+		fmt.Fprintf(b, "\t\t\t%s\n", call)
+	}
+
+	// Messages print the arguments, like "Sum(%v, %v) = %v; want %v"
+	verbs := strings.TrimSuffix(strings.Repeat("%v, ", len(f.params)), ", ")
+	msgArgs := ""
+	for _, field := range fields {
+		msgArgs += "tt." + field + ", "
+	}
+	if f.hasErr {
+		msg := fmt.Sprintf("%s(%s) error = %%v; wantErr %%v", f.name, verbs)
+		fmt.Fprintf(b, "\t\t\tif (err != nil) != tt.wantErr {\n\t\t\t\tt.Fatalf(%s, %serr, tt.wantErr)\n\t\t\t}\n",
+			strconv.Quote(msg), msgArgs)
+		if len(f.results) > 0 {
+			b.WriteString("\t\t\tif tt.wantErr {\n\t\t\t\treturn\n\t\t\t}\n")
+		}
+	}
+	for i, r := range f.results {
+		cond := fmt.Sprintf("%s != tt.%s", results[i], expected[i])
+		if !s.isBasic(r, 0) {
+			cond = fmt.Sprintf("!reflect.DeepEqual(%s, tt.%s)", results[i], expected[i])
+		}
+		msg := fmt.Sprintf("%s(%s) = %%v; want %%v", f.name, verbs)
+		if len(f.results) > 1 {
+			msg = fmt.Sprintf("%s(%s) result %d = %%v; want %%v", f.name, verbs, i+1)
+		}
+		fmt.Fprintf(b, "\t\t\tif %s {\n\t\t\t\tt.Errorf(%s, %s%s, tt.%s)\n\t\t\t}\n",
+			cond, strconv.Quote(msg), msgArgs, results[i], expected[i])
+	}
+	b.WriteString("\t\t})\n\t}\n}\n\n")
+}
+
+// importName returns the name a package is usually imported as: the last
+// element of its path, skipping a major version such as math/rand/v2 and
+// dropping one such as gopkg.in/yaml.v3
+func importName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if isMajorVersion(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	if i := strings.LastIndex(name, "."); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	return name
+}
+
+// isMajorVersion reports whether s is a major version suffix such as v2
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// exported capitalises name, so that tests of unexported functions still
+// match Test*
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// testFields returns the names of the test table fields holding f's
+// arguments, renaming those that clash with the other fields
+func testFields(f *Function) []string {
+	fields := make([]string, len(f.params))
+	for i, p := range f.params {
+		fields[i] = p.name
+		if p.name == "name" || p.name == "wantErr" || strings.HasPrefix(p.name, "expected") {
+			fields[i] += "Arg"
+		}
+	}
+	return fields
+}
+
+func (s *Spec) renderNotes() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Challenge %d: %s\n\n[🔗link](%s)\n\n", s.Number, s.Title, s.Link)
+	if d := strings.TrimSpace(s.Description); d != "" {
+		b.WriteString(d + "\n\n")
+	}
+	for _, f := range s.Functions {
+		fmt.Fprintf(&b, "## %s\n\n```go\n%s\n```\n\n", f.name, f.Signature)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// zeroResults returns the zero values f returns, including a nil error
+func (s *Spec) zeroResults(f *Function) []string {
+	var zeros []string
+	for _, r := range f.results {
+		zeros = append(zeros, s.zeroValue(r, f.text(r), 0))
+	}
+	if f.hasErr {
+		zeros = append(zeros, "nil")
+	}
+	return zeros
+}
+
+// basicZeros are the zero values of the predeclared types
+var basicZeros = map[string]string{
+	"bool": "false", "string": `""`, "error": "nil", "any": "nil",
+	"int": "0", "int8": "0", "int16": "0", "int32": "0", "int64": "0",
+	"uint": "0", "uint8": "0", "uint16": "0", "uint32": "0", "uint64": "0", "uintptr": "0",
+	"float32": "0", "float64": "0", "complex64": "0", "complex128": "0", "byte": "0", "rune": "0",
+}
+
+// zeroValue returns an expression for the zero value of a type whose
+// source is text. Types declared in the spec are followed to find what
+// kind of type they are; other named types get *new(T), which is always
+// valid.
+func (s *Spec) zeroValue(typ ast.Expr, text string, depth int) string {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if z, ok := basicZeros[t.Name]; ok {
+			return z
+		}
+		if u, ok := s.types[t.Name]; ok && depth <= len(s.types) {
+			switch z := s.zeroValue(u, t.Name, depth+1); {
+			case strings.HasSuffix(z, "{}"):
+				return t.Name + "{}"
+			case strings.HasPrefix(z, "*new("):
+				return "*new(" + t.Name + ")"
+			default:
+				return z
+			}
+		}
+	case *ast.ParenExpr:
+		return s.zeroValue(t.X, text, depth)
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil"
+		}
+		return text + "{}"
+	case *ast.StructType:
+		return text + "{}"
+	}
+	return "*new(" + text + ")"
+}
+
+// isBasic reports whether values of a type can be compared with !=
+// meaningfully: booleans, strings and numbers
+func (s *Spec) isBasic(typ ast.Expr, depth int) bool {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if z, ok := basicZeros[t.Name]; ok {
+			return z != "nil"
+		}
+		if u, ok := s.types[t.Name]; ok && depth <= len(s.types) {
+			return s.isBasic(u, depth+1)
+		}
+	case *ast.ParenExpr:
+		return s.isBasic(t.X, depth)
+	}
+	return false
+}
+
+// WriteFiles writes files into dir, creating it if needed. If any of the
+// files exists, it writes nothing and returns an error wrapping ErrExists.
+func WriteFiles(dir string, files []File) error {
+	var existing []string
+	for _, f := range files {
+		p := filepath.Join(dir, f.Name)
+		if _, err := os.Lstat(p); err == nil {
+			existing = append(existing, p)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%w: %s", ErrExists, strings.Join(existing, ", "))
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var written []string
+	for _, f := range files {
+		p := filepath.Join(dir, f.Name)
+		if err := writeNew(p, f.Content); err != nil {
+			// Leave things as they were
+			for _, w := range written {
+				os.Remove(w)
+			}
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("%w: %s", ErrExists, p)
+			}
+			return err
+		}
+		written = append(written, p)
+	}
+	return nil
+}
+
+// writeNew writes a file that must not exist yet
+func writeNew(name string, content []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustGenerate(t *testing.T, spec string) []File {
+	t.Helper()
+	s, err := ParseSpec([]byte(spec))
+	if err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	files, err := s.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	return files
+}
+
+func TestGenerate(t *testing.T) {
+	files := mustGenerate(t, sumSpec)
+	expected := []File{
+		{"main.go", []byte(`package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println(Sum(2, 3))
+}
+
+// Sum returns the sum of a and b.
+func Sum(a int, b int) int {
+	// TODO: implement
+	return 0
+}
+`)},
+		{"main_test.go", []byte(`package main
+
+import (
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        int
+		b        int
+		expected int
+	}{
+		{"Positive numbers", 2, 3, 5},
+		{"Case 2", -2, -3, -5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Sum(tt.a, tt.b)
+			if result != tt.expected {
+				t.Errorf("Sum(%v, %v) = %v; want %v", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
+`)},
+		{"sumOfTwoNumbers.md", []byte("# Challenge 1: sumOfTwoNumbers\n\n[🔗link](https://app.gointerview.dev/challenge/1)\n\n" +
+			"## Sum\n\n```go\nfunc Sum(a int, b int) int\n```\n")},
+	}
+
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(files))
+	}
+	for i, f := range files {
+		if f.Name != expected[i].Name || string(f.Content) != string(expected[i].Content) {
+			t.Errorf("Expected %s:\n%s\ngot %s:\n%s", expected[i].Name, expected[i].Content, f.Name, f.Content)
+		}
+	}
+}
+
+// richSpec uses the features that change what is generated: declared types,
+// imports, errors, several and variadic results, and a library package
+const richSpec = `
+number: 40
+name: textTools
+package: text
+file: text.go
+imports:
+  - math/rand/v2
+  - strings
+  - time
+types: |
+  type Stats struct {
+  	Words int
+  }
+
+  type Celsius float64
+
+  type Words []string
+functions:
+  - signature: func Count(text string) (Stats, error)
+    examples:
+      - '"a b"'
+    tests:
+      - name: Two words
+        args: '"a b"'
+        want: 'Stats{Words: 2}'
+      - name: Empty
+        args: '""'
+        err: true
+  - signature: func Split(s string, seps ...string) (Words, int)
+    tests:
+      - args: '"a,b", []string{","}'
+        want: 'Words{"a", "b"}, 2'
+  - signature: func toCelsius(f float64) Celsius
+    tests:
+      - args: "212"
+        want: "100"
+  - signature: func Timeout(name string) time.Duration
+    tests:
+      - args: '"slow"'
+        want: 2 * time.Second
+  - signature: func upper(s string) string
+    examples:
+      - '"abc"'
+  - signature: func Reset()
+  - signature: func Shuffle(r *rand.Rand, words Words)
+`
+
+func TestGenerateRich(t *testing.T) {
+	files := mustGenerate(t, richSpec)
+	if files[0].Name != "text.go" || files[1].Name != "text_test.go" || files[2].Name != "textTools.md" {
+		t.Fatalf("Expected text.go, text_test.go and textTools.md, got %s, %s, %s", files[0].Name, files[1].Name, files[2].Name)
+	}
+	stub, tests := string(files[0].Content), string(files[1].Content)
+
+	for _, want := range []string{
+		"package text\n",
+		"\t\"math/rand/v2\"\n",
+		"\t\"time\"\n",
+		"type Celsius float64",
+		"return Stats{}, nil",
+		"return nil, 0",
+		"return 0\n",
+		"return *new(time.Duration)",
+		"func Reset() {\n\t// TODO: implement\n}",
+	} {
+		if !strings.Contains(stub, want) {
+			t.Errorf("Expected the stub to contain %q, got\n%s", want, stub)
+		}
+	}
+	if strings.Contains(stub, "func main") || strings.Contains(stub, "\"strings\"") {
+		t.Errorf("Expected no main and no unused imports in a library package, got\n%s", stub)
+	}
+
+	for _, want := range []string{
+		"package text\n",
+		`{"Empty", "", Stats{}, true},`,
+		"if tt.wantErr {\n\t\t\t\treturn\n\t\t\t}",
+		"result1, result2 := Split(tt.s, tt.seps...)",
+		"!reflect.DeepEqual(result1, tt.expected1)",
+		"if result2 != tt.expected2 {",
+		"func TestToCelsius(t *testing.T) {",
+		"if result != tt.expected {",
+		"func ExampleCount() {\n\tfmt.Println(Count(\"a b\"))\n}",
+		"// TODO: add test cases",
+		"func Example_upper() {",
+	} {
+		if !strings.Contains(tests, want) {
+			t.Errorf("Expected the tests to contain %q, got\n%s", want, tests)
+		}
+	}
+}
+
+// TestGeneratedCodeCompiles vets the generated files as their own module,
+// which type-checks both the stub and the tests
+func TestGeneratedCodeCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go vet in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	for name, spec := range map[string]string{"sum": sumSpec, "rich": richSpec} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := append(mustGenerate(t, spec), File{"go.mod", []byte("module scaffolded\n\ngo 1.24\n")})
+			if err := WriteFiles(dir, files); err != nil {
+				t.Fatalf("Failed to write files: %v", err)
+			}
+			cmd := exec.Command("go", "vet", ".")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("Generated code does not vet: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	s, err := ParseSpec([]byte(richSpec))
+	if err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	tests := []struct {
+		signature string
+		expected  string
+	}{
+		{"func F() bool", "false"},
+		{"func F() string", `""`},
+		{"func F() float64", "0"},
+		{"func F() any", "nil"},
+		{"func F() *Stats", "nil"},
+		{"func F() map[string]int", "nil"},
+		{"func F() []int", "nil"},
+		{"func F() [3]int", "[3]int{}"},
+		{"func F() struct{ X int }", "struct{ X int }{}"},
+		{"func F() Stats", "Stats{}"},
+		{"func F() Celsius", "0"},
+		{"func F() Words", "nil"},
+		{"func F() time.Time", "*new(time.Time)"},
+		{"func F() Unknown", "*new(Unknown)"},
+	}
+
+	for _, tt := range tests {
+		f := &Function{Signature: tt.signature}
+		if err := f.parseSignature(); err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.signature, err)
+		}
+		if result := s.zeroValue(f.results[0], f.text(f.results[0]), 0); result != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.signature, tt.expected, result)
+		}
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"fmt", "fmt"},
+		{"net/http", "http"},
+		{"math/rand/v2", "rand"},
+		{"github.com/user/project/v10", "project"},
+		{"gopkg.in/yaml.v3", "yaml"},
+	}
+
+	for _, tt := range tests {
+		if result := importName(tt.path); result != tt.expected {
+			t.Errorf("importName(%q): expected %s, got %s", tt.path, tt.expected, result)
+		}
+	}
+}
+
+func TestWriteFilesRefusesToOverwrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "1-sumOfTwoNumbers")
+	files := mustGenerate(t, sumSpec)
+	if err := WriteFiles(dir, files); err != nil {
+		t.Fatalf("Failed to write files: %v", err)
+	}
+
+	// Work in progress must survive a second run
+	notes := filepath.Join(dir, "sumOfTwoNumbers.md")
+	os.WriteFile(notes, []byte("my notes"), 0o644)
+	os.Remove(filepath.Join(dir, "main.go"))
+
+	err := WriteFiles(dir, files)
+	if !errors.Is(err, ErrExists) || !strings.Contains(err.Error(), "sumOfTwoNumbers.md") || strings.Contains(err.Error(), "main.go,") {
+		t.Errorf("Expected ErrExists naming the existing files, got %v", err)
+	}
+	if content, _ := os.ReadFile(notes); string(content) != "my notes" {
+		t.Errorf("Expected the notes to be kept, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written, but main.go exists")
+	}
+}
@@ -0,0 +1,90 @@
+// Command scaffold creates a challenge directory from a YAML spec: a stub
+// with the functions to implement, a table-driven test file and a notes
+// skeleton. It never overwrites existing files.
+//
+// Usage:
+//
+//	scaffold [-root dir] [-dry-run] spec.yaml
+//
+// A spec looks like this:
+//
+//	number: 1
+//	name: sumOfTwoNumbers     # the directory is 1-sumOfTwoNumbers
+//	title: Sum of Two Numbers # optional
+//	package: main             # optional, main by default
+//	description: |
+//	  Implement Sum, which adds two integers.
+//	functions:
+//	  - signature: func Sum(a int, b int) int
+//	    doc: Sum returns the sum of a and b.
+//	    examples:             # calls to print in main
+//	      - 2, 3
+//	    tests:
+//	      - name: Positive numbers
+//	        args: 2, 3        # Go expressions, one per parameter
+//	        want: 5           # Go expressions, one per result
+//	      - name: Negative numbers
+//	        args: -2, -3
+//	        want: -5
+//
+// Functions returning an error as their last result get a wantErr column;
+// set err: true on the cases that should fail and leave out want. Go
+// declarations the signatures need go in types, as a | block, and packages
+// they use in imports.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "scaffold:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	root := fs.String("root", "classicChallenges", "directory to create the challenge in")
+	dryRun := fs.Bool("dry-run", false, "print the files instead of writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one spec file")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	spec, err := ParseSpec(data)
+	if err != nil {
+		return err
+	}
+	files, err := spec.Generate()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(*root, spec.Dir())
+	if *dryRun {
+		for _, f := range files {
+			fmt.Fprintf(stdout, "==> %s <==\n%s\n", filepath.Join(dir, f.Name), f.Content)
+		}
+		return nil
+	}
+	if err := WriteFiles(dir, files); err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Fprintf(stdout, "Created %s\n", filepath.Join(dir, f.Name))
+	}
+	return nil
+}
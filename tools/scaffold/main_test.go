@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSpec(t *testing.T, spec string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(p, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	spec := writeSpec(t, sumSpec)
+	root := t.TempDir()
+	dir := filepath.Join(root, "1-sumOfTwoNumbers")
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-root", root, "-dry-run", spec}, &stdout, &stderr); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "==> "+filepath.Join(dir, "main_test.go")+" <==") {
+		t.Errorf("Expected the dry run to print the test file, got\n%s", stdout.String())
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the dry run to write nothing")
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-root", root, spec}, &stdout, &stderr); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, name := range []string{"main.go", "main_test.go", "sumOfTwoNumbers.md"} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("Expected %s to be created: %v", name, err)
+		}
+		if !strings.Contains(stdout.String(), "Created "+p) {
+			t.Errorf("Expected %s to be reported, got\n%s", name, stdout.String())
+		}
+	}
+
+	if err := run([]string{"-root", root, spec}, &stdout, &stderr); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected a second run to refuse to overwrite, got %v", err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"No spec", nil},
+		{"Two specs", []string{"a.yaml", "b.yaml"}},
+		{"Missing spec", []string{filepath.Join(t.TempDir(), "missing.yaml")}},
+		{"Invalid spec", []string{writeSpec(t, "number: 1\n")}},
+		{"Bad flag", []string{"-bogus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, &stdout, &stderr); err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
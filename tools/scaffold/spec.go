@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidSpec is returned for specs that cannot be generated from
+var ErrInvalidSpec = errors.New("invalid spec")
+
+// Spec describes a challenge to scaffold. In YAML, every field of a spec,
+// function and test case is written under its lowercased name.
+type Spec struct {
+	Number      int
+	Name        string // the directory is "<Number>-<Name>"
+	Title       string
+	Link        string
+	Package     string
+	Description string
+	File        string   // the stub file, main.go by default
+	Notes       string   // the notes file, <Name>.md by default
+	Imports     []string // import paths the signatures, types or tests use
+	Types       string   // Go declarations copied into the stub
+	Functions   []*Function
+
+	types map[string]ast.Expr // type declarations in Types by name
+}
+
+// Function is a function to stub and test
+type Function struct {
+	Signature string   // e.g. "func Sum(a, b int) int"
+	Doc       string   // doc comment, without the //
+	Examples  []string // argument lists to call the function with
+	Tests     []*TestCase
+
+	name    string
+	params  []param
+	results []ast.Expr // result types, without a trailing error
+	src     string     // source the positions of params and results refer to
+	hasErr  bool       // whether the last result is an error
+}
+
+// TestCase is a row of a function's test table. Args and Want are Go
+// expressions separated by commas, one per parameter and per result.
+type TestCase struct {
+	Name string
+	Args string
+	Want string
+	Err  bool // whether the function should return an error
+
+	args, want []string
+}
+
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+// Dir returns the challenge directory name, e.g. "1-sumOfTwoNumbers"
+func (s *Spec) Dir() string {
+	return fmt.Sprintf("%d-%s", s.Number, s.Name)
+}
+
+// ParseSpec reads a spec from YAML and checks that its signatures and
+// test cases are valid Go
+func ParseSpec(data []byte) (*Spec, error) {
+	s := &Spec{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	return s, nil
+}
+
+// check validates a decoded spec and fills in the defaults
+func (s *Spec) check() error {
+	if s.Number <= 0 {
+		return fmt.Errorf("spec: number must be a positive integer, got %d", s.Number)
+	}
+	if s.Name == "" || strings.ContainsAny(s.Name, `/\`) || strings.HasPrefix(s.Name, ".") {
+		return fmt.Errorf("spec: name must be a plain directory name, got %q", s.Name)
+	}
+	if s.Title == "" {
+		s.Title = s.Name
+	}
+	if s.Link == "" {
+		s.Link = fmt.Sprintf("https://app.gointerview.dev/challenge/%d", s.Number)
+	}
+	if s.Package == "" {
+		s.Package = "main"
+	}
+	if !token.IsIdentifier(s.Package) {
+		return fmt.Errorf("spec: package %q is not a valid name", s.Package)
+	}
+	if s.File == "" {
+		s.File = "main.go"
+	}
+	if s.Notes == "" {
+		s.Notes = s.Name + ".md"
+	}
+	for _, name := range []string{s.File, s.Notes} {
+		if strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("spec: file %q must be in the challenge directory", name)
+		}
+	}
+	if !strings.HasSuffix(s.File, ".go") || strings.HasSuffix(s.File, "_test.go") {
+		return fmt.Errorf("spec: file %q must be a non-test .go file", s.File)
+	}
+	if err := s.parseTypes(); err != nil {
+		return err
+	}
+
+	if len(s.Functions) == 0 {
+		return errors.New("spec: no functions")
+	}
+	seen := make(map[string]bool)
+	for i, f := range s.Functions {
+		if err := f.check(fmt.Sprintf("functions[%d]", i)); err != nil {
+			return err
+		}
+		if seen[f.name] {
+			return fmt.Errorf("functions[%d]: duplicate function %s", i, f.name)
+		}
+		if f.name == "main" && s.Package == "main" {
+			return fmt.Errorf("functions[%d]: main is generated from the examples", i)
+		}
+		seen[f.name] = true
+	}
+	return nil
+}
+
+// parseTypes checks Types and indexes its type declarations
+func (s *Spec) parseTypes() error {
+	s.types = make(map[string]ast.Expr)
+	if s.Types == "" {
+		return nil
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "types", "package p\n"+s.Types, 0)
+	if err != nil {
+		return fmt.Errorf("spec: types: %v", err)
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			s.types[ts.Name.Name] = ts.Type
+		}
+	}
+	return nil
+}
+
+// check parses the signature and test cases of a decoded function
+func (f *Function) check(where string) error {
+	if f == nil {
+		return fmt.Errorf("%s: expected a mapping", where)
+	}
+	f.Doc = strings.TrimSpace(f.Doc)
+	if err := f.parseSignature(); err != nil {
+		return fmt.Errorf("%s: %v", where, err)
+	}
+	for i, args := range f.Examples {
+		if _, err := splitExprs(args, len(f.params), f.variadic()); err != nil {
+			return fmt.Errorf("%s.examples[%d]: %v", where, i, err)
+		}
+	}
+	for i, tc := range f.Tests {
+		if err := f.checkTest(tc, fmt.Sprintf("%s.tests[%d]", where, i)); err != nil {
+			return err
+		}
+		if tc.Name == "" {
+			tc.Name = fmt.Sprintf("Case %d", i+1)
+		}
+	}
+	return nil
+}
+
+// parseSignature parses Signature into the function's name, parameters
+// and results
+func (f *Function) parseSignature() error {
+	sig := strings.TrimSpace(f.Signature)
+	if !strings.HasPrefix(sig, "func ") {
+		return fmt.Errorf("signature %q must start with func", sig)
+	}
+	f.src = "package p\n" + sig + " {}"
+	file, err := parser.ParseFile(token.NewFileSet(), "signature", f.src, 0)
+	if err != nil || len(file.Decls) != 1 {
+		return fmt.Errorf("invalid signature %q", sig)
+	}
+	decl, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok {
+		return fmt.Errorf("invalid signature %q", sig)
+	}
+	if decl.Recv != nil {
+		return fmt.Errorf("signature %q: methods are not supported", sig)
+	}
+	if decl.Type.TypeParams != nil {
+		return fmt.Errorf("signature %q: generic functions are not supported", sig)
+	}
+	f.Signature = sig
+	f.name = decl.Name.Name
+
+	for _, field := range decl.Type.Params.List {
+		typ, variadic := f.text(field.Type), false
+		if ellipsis, ok := field.Type.(*ast.Ellipsis); ok {
+			typ, variadic = "[]"+f.text(ellipsis.Elt), true
+		}
+		if len(field.Names) == 0 {
+			f.params = append(f.params, param{name: fmt.Sprintf("arg%d", len(f.params)+1), typ: typ, variadic: variadic})
+		}
+		for _, id := range field.Names {
+			name := id.Name
+			if name == "_" {
+				name = fmt.Sprintf("arg%d", len(f.params)+1)
+			}
+			f.params = append(f.params, param{name: name, typ: typ, variadic: variadic})
+		}
+	}
+	if decl.Type.Results != nil {
+		for _, field := range decl.Type.Results.List {
+			for range max(1, len(field.Names)) {
+				f.results = append(f.results, field.Type)
+			}
+		}
+	}
+	if n := len(f.results); n > 0 {
+		if id, ok := f.results[n-1].(*ast.Ident); ok && id.Name == "error" {
+			f.results, f.hasErr = f.results[:n-1], true
+		}
+	}
+	return nil
+}
+
+// text returns the source of a node of the parsed signature. The file is
+// the only one in its file set, so positions are offsets plus one.
+func (f *Function) text(n ast.Node) string {
+	return f.src[n.Pos()-1 : n.End()-1]
+}
+
+// checkTest parses the arguments and wanted results of a test case
+func (f *Function) checkTest(tc *TestCase, where string) error {
+	if tc == nil {
+		return fmt.Errorf("%s: expected a mapping", where)
+	}
+	if tc.Err && !f.hasErr {
+		return fmt.Errorf("%s: %s does not return an error", where, f.name)
+	}
+
+	var err error
+	if tc.args, err = splitExprs(tc.Args, len(f.params), false); err != nil {
+		return fmt.Errorf("%s: args: %v", where, err)
+	}
+	switch {
+	case tc.Want == "" && (tc.Err || len(f.results) == 0):
+	case tc.Want == "":
+		return fmt.Errorf("%s: want is missing", where)
+	default:
+		if tc.want, err = splitExprs(tc.Want, len(f.results), false); err != nil {
+			return fmt.Errorf("%s: want: %v", where, err)
+		}
+	}
+	return nil
+}
+
+// variadic reports whether the function's last parameter is variadic
+func (f *Function) variadic() bool {
+	return len(f.params) > 0 && f.params[len(f.params)-1].variadic
+}
+
+// splitExprs splits a comma-separated list of n Go expressions. If
+// variadic is set, the last of the n may be repeated any number of times.
+func splitExprs(list string, n int, variadic bool) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		if n == 0 || variadic && n == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("expected %d values, got none", n)
+	}
+	src := "f(" + list + ")"
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Go expressions %q", list)
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || call.Ellipsis.IsValid() {
+		return nil, fmt.Errorf("invalid Go expressions %q", list)
+	}
+	if len(call.Args) != n && !(variadic && len(call.Args) >= n-1) {
+		return nil, fmt.Errorf("expected %d values, got %d in %q", n, len(call.Args), list)
+	}
+	exprs := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		// Positions of a parsed expression are offsets into src, plus one
+		exprs[i] = src[arg.Pos()-1 : arg.End()-1]
+	}
+	return exprs, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sumSpec = `
+number: 1
+name: sumOfTwoNumbers
+functions:
+  - signature: func Sum(a int, b int) int
+    doc: Sum returns the sum of a and b.
+    examples:
+      - 2, 3
+    tests:
+      - name: Positive numbers
+        args: 2, 3
+        want: 5
+      - args: -2, -3
+        want: -5
+`
+
+func TestParseSpec(t *testing.T) {
+	s, err := ParseSpec([]byte(sumSpec))
+	if err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	if s.Dir() != "1-sumOfTwoNumbers" || s.Package != "main" || s.File != "main.go" || s.Notes != "sumOfTwoNumbers.md" {
+		t.Errorf("Expected the defaults for directory, package and files, got %s, %s, %s, %s", s.Dir(), s.Package, s.File, s.Notes)
+	}
+	if s.Title != "sumOfTwoNumbers" || s.Link != "https://app.gointerview.dev/challenge/1" {
+		t.Errorf("Expected the default title and link, got %q and %q", s.Title, s.Link)
+	}
+	if len(s.Functions) != 1 {
+		t.Fatalf("Expected 1 function, got %d", len(s.Functions))
+	}
+
+	f := s.Functions[0]
+	if f.name != "Sum" || len(f.results) != 1 || f.hasErr {
+		t.Errorf("Expected Sum returning one value, got %s returning %d (error %v)", f.name, len(f.results), f.hasErr)
+	}
+	expectedParams := []param{{name: "a", typ: "int"}, {name: "b", typ: "int"}}
+	if !reflect.DeepEqual(f.params, expectedParams) {
+		t.Errorf("Expected params %+v, got %+v", expectedParams, f.params)
+	}
+	if len(f.Tests) != 2 || f.Tests[1].Name != "Case 2" || !reflect.DeepEqual(f.Tests[1].args, []string{"-2", "-3"}) {
+		t.Errorf("Expected a named and a numbered test case, got %+v", f.Tests)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		params    []param
+		results   int
+		hasErr    bool
+	}{
+		{"No parameters or results", "func Reset()", nil, 0, false},
+		{"Grouped parameters", "func Sum(a, b int) int", []param{{"a", "int", false}, {"b", "int", false}}, 1, false},
+		{"Unnamed parameters", "func Sum(int, int) int", []param{{"arg1", "int", false}, {"arg2", "int", false}}, 1, false},
+		{"Variadic", "func Join(sep string, parts ...string) string", []param{{"sep", "string", false}, {"parts", "[]string", true}}, 1, false},
+		{"Error only", "func Check(s string) error", []param{{"s", "string", false}}, 0, true},
+		{"Named results", "func Div(a, b int) (q, r int, err error)", []param{{"a", "int", false}, {"b", "int", false}}, 2, true},
+		{"Function type", "func Apply(f func(int) int, xs []int) []int", []param{{"f", "func(int) int", false}, {"xs", "[]int", false}}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Function{Signature: tt.signature}
+			if err := f.parseSignature(); err != nil {
+				t.Fatalf("Failed to parse signature: %v", err)
+			}
+			if !reflect.DeepEqual(f.params, tt.params) || len(f.results) != tt.results || f.hasErr != tt.hasErr {
+				t.Errorf("Expected %+v, %d results (error %v), got %+v, %d results (error %v)",
+					tt.params, tt.results, tt.hasErr, f.params, len(f.results), f.hasErr)
+			}
+		})
+	}
+}
+
+func TestSplitExprs(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     string
+		n        int
+		variadic bool
+		expected []string
+		wantErr  bool
+	}{
+		{"Simple", "1, 2", 2, false, []string{"1", "2"}, false},
+		{"Commas inside literals", `[]int{1, 2}, map[string]int{"a": 1}`, 2, false, []string{"[]int{1, 2}", `map[string]int{"a": 1}`}, false},
+		{"Empty list", "", 0, false, nil, false},
+		{"Too few", "1", 2, false, nil, true},
+		{"Too many", "1, 2, 3", 2, false, nil, true},
+		{"Variadic", "1, 2, 3", 2, true, []string{"1", "2", "3"}, false},
+		{"Variadic without extra values", "1", 2, true, []string{"1"}, false},
+		{"Invalid Go", "1 +", 1, false, nil, true},
+		{"Spread", "xs...", 1, false, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := splitExprs(tt.list, tt.n, tt.variadic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	base := "number: 1\nname: x\n"
+	fn := func(lines string) string {
+		return base + "functions:\n  - signature: func F(a int) (int, error)\n" + lines
+	}
+	tests := []struct {
+		name string
+		spec string
+		err  string
+	}{
+		{"Invalid YAML", "number: 1\nnumber: 2\n", `mapping key "number" already defined`},
+		{"Unknown field", base + "titel: x\nfunctions:\n  - signature: func F()\n", "field titel not found"},
+		{"Number not an integer", "number: one\nname: x\n", "cannot unmarshal !!str `one` into int"},
+		{"Bad number", "number: -1\nname: x\n", "number must be a positive integer"},
+		{"Name with a slash", "number: 1\nname: ../x\n", "plain directory name"},
+		{"Bad package", base + "package: my-pkg\n", "not a valid name"},
+		{"Test file as stub", base + "file: main_test.go\n", "non-test .go file"},
+		{"Invalid types", base + "types: |\n  type T struct\n", "types:"},
+		{"No functions", base, "no functions"},
+		{"Invalid signature", base + "functions:\n  - signature: func F(\n", "invalid signature"},
+		{"Not a function", base + "functions:\n  - signature: F()\n", "must start with func"},
+		{"Method", base + "functions:\n  - signature: func (t T) F()\n", "methods are not supported"},
+		{"Generic", base + "functions:\n  - signature: func F[T any](x T) T\n", "generic functions are not supported"},
+		{"Duplicate function", base + "functions:\n  - signature: func F()\n  - signature: func F()\n", "duplicate function F"},
+		{"Main in package main", base + "functions:\n  - signature: func main()\n", "main is generated"},
+		{"Wrong number of args", fn("    tests:\n      - args: 1, 2\n        want: 1\n"), "tests[0]: args: expected 1 values, got 2"},
+		{"Missing want", fn("    tests:\n      - args: 1\n"), "want is missing"},
+		{"Bad want", fn("    tests:\n      - args: 1\n        want: 1 +\n"), "want: invalid Go"},
+		{"Bad err flag", fn("    tests:\n      - args: 1\n        err: maybe\n"), "cannot unmarshal !!str `maybe` into bool"},
+		{"Bad example", fn("    examples:\n      - 1, 2\n"), "examples[0]"},
+		{"Error case without an error result", base + "functions:\n  - signature: func F()\n    tests:\n      - err: true\n", "does not return an error"},
+		{"Tests not a list", fn("    tests: many\n"), "cannot unmarshal !!str `many`"},
+		{"Empty test case", fn("    tests:\n      -\n"), "tests[0]: expected a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpec([]byte(tt.spec))
+			if !errors.Is(err, ErrInvalidSpec) || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected an invalid spec error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Command tracker records practice attempts at the classic challenges and
+// suggests which one to redo next, using spaced repetition.
+//
+// Usage:
+//
+//	tracker start [flags] <challenge>   start timing a practice session
+//	tracker check [flags] <challenge>   run the challenge's tests and record the attempt
+//	tracker next [flags]                suggest challenges to do next
+//	tracker stats [flags]               show statistics per person
+//
+// Challenges are given by directory name or number, e.g. 13 for
+// 13-SQLDatabaseOperationsWithGo.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// outputTailLines is how much of failing test output check prints
+const outputTailLines = 20
+
+// app holds what the commands need, so tests can replace the clock and the
+// test runner
+type app struct {
+	stdout, stderr io.Writer
+	now            func() time.Time
+	// runTests runs the tests in dir and reports whether they passed
+	runTests func(ctx context.Context, dir string) (passed bool, output string, err error)
+}
+
+func main() {
+	a := &app{stdout: os.Stdout, stderr: os.Stderr, now: time.Now, runTests: goTest}
+	if err := a.run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "tracker:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by all commands
+type options struct {
+	db     string
+	person string
+	root   string
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected a command: start, check, next or stats")
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	var opts options
+	person := os.Getenv("USER")
+	if cmd == "stats" {
+		// Everyone unless a person is given
+		person = ""
+	}
+	fs.StringVar(&opts.db, "db", "tracker.db", "SQLite database of attempts")
+	fs.StringVar(&opts.person, "person", person, "who is practising")
+	fs.StringVar(&opts.root, "root", "classicChallenges", "directory of the challenges")
+
+	switch cmd {
+	case "start":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return a.start(opts, fs.Args())
+	case "check":
+		spent := fs.Duration("spent", 0, "time spent, instead of the time since start")
+		timeout := fs.Duration("timeout", 5*time.Minute, "how long the tests may run")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return a.check(ctx, opts, fs.Args(), *spent, *timeout)
+	case "next":
+		n := fs.Int("n", 3, "number of suggestions")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return a.next(opts, *n)
+	case "stats":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return a.stats(opts)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// openFor opens the tracker and resolves the single challenge argument
+func (a *app) openFor(opts options, args []string) (*Tracker, string, error) {
+	if len(args) != 1 {
+		return nil, "", errors.New("expected one challenge")
+	}
+	if opts.person == "" {
+		return nil, "", errors.New("no person given; use -person")
+	}
+	challenges, err := listChallenges(opts.root)
+	if err != nil {
+		return nil, "", err
+	}
+	challenge, err := resolveChallenge(challenges, args[0])
+	if err != nil {
+		return nil, "", err
+	}
+	t, err := OpenTracker(opts.db)
+	if err != nil {
+		return nil, "", err
+	}
+	return t, challenge, nil
+}
+
+func (a *app) start(opts options, args []string) error {
+	t, challenge, err := a.openFor(opts, args)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	if err := t.StartSession(opts.person, challenge, a.now()); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Started %s for %s; run check when done\n", challenge, opts.person)
+	return nil
+}
+
+func (a *app) check(ctx context.Context, opts options, args []string, spent, timeout time.Duration) error {
+	t, challenge, err := a.openFor(opts, args)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	now := a.now()
+	start, err := t.SessionStart(opts.person, challenge)
+	switch {
+	case spent > 0:
+	case err == nil:
+		spent = now.Sub(start)
+	case errors.Is(err, ErrNoSession):
+		fmt.Fprintf(a.stderr, "No session started for %s; recording no time spent\n", challenge)
+	default:
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	passed, output, err := a.runTests(ctx, filepath.Join(opts.root, challenge))
+	if err != nil {
+		return fmt.Errorf("running tests of %s: %w", challenge, err)
+	}
+
+	attempt := &Attempt{Person: opts.person, Challenge: challenge, Date: now, Passed: passed, Spent: spent}
+	if err := t.RecordAttempt(attempt); err != nil {
+		return err
+	}
+	stats, err := t.Stats(opts.person)
+	if err != nil {
+		return err
+	}
+	var cs ChallengeStats
+	for _, c := range stats.Challenges {
+		if c.Challenge == challenge {
+			cs = c
+		}
+	}
+
+	result := "FAIL"
+	if passed {
+		result = "PASS"
+	} else {
+		fmt.Fprintln(a.stdout, tail(output, outputTailLines))
+	}
+	fmt.Fprintf(a.stdout, "%s %s in %v (attempt %d, %d passed in a row); next review %s\n",
+		result, challenge, spent.Round(time.Second), cs.Attempts, cs.Streak, cs.NextReview.Local().Format(time.DateOnly))
+	return nil
+}
+
+func (a *app) next(opts options, n int) error {
+	if opts.person == "" {
+		return errors.New("no person given; use -person")
+	}
+	if n < 1 {
+		return fmt.Errorf("-n must be at least 1, got %d", n)
+	}
+	challenges, err := listChallenges(opts.root)
+	if err != nil {
+		return err
+	}
+	t, err := OpenTracker(opts.db)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	stats, err := t.Stats(opts.person)
+	if err != nil {
+		return err
+	}
+
+	suggestions := Suggest(stats, challenges, a.now(), n)
+	if len(suggestions) == 0 {
+		fmt.Fprintf(a.stdout, "Nothing due for %s\n", opts.person)
+		return nil
+	}
+	for i, s := range suggestions {
+		fmt.Fprintf(a.stdout, "%d. %s: %s\n", i+1, s.Challenge, s.Reason)
+	}
+	return nil
+}
+
+func (a *app) stats(opts options) error {
+	t, err := OpenTracker(opts.db)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	people := []string{opts.person}
+	if opts.person == "" {
+		if people, err = t.People(); err != nil {
+			return err
+		}
+	}
+	for i, person := range people {
+		stats, err := t.Stats(person)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(a.stdout)
+		}
+		writeStats(a.stdout, stats)
+	}
+	return nil
+}
+
+// writeStats prints a summary line and a table of challenges
+func writeStats(w io.Writer, s *PersonStats) {
+	fmt.Fprintf(w, "%s: %d attempts, %d passed (%.0f%%), %v spent\n",
+		s.Person, s.Attempts, s.Passes, 100*s.PassRate(), s.Spent)
+	if len(s.Challenges) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHALLENGE\tATTEMPTS\tPASSED\tSTREAK\tFASTEST\tLAST\tNEXT REVIEW")
+	for _, c := range s.Challenges {
+		fastest := "-"
+		if c.Passes > 0 {
+			fastest = c.Fastest.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", c.Challenge, c.Attempts, c.Passes, c.Streak, fastest,
+			c.LastAttempt.Local().Format(time.DateOnly), c.NextReview.Local().Format(time.DateOnly))
+	}
+	tw.Flush()
+}
+
+// goTest runs go test in dir. Failing tests are not an error; failing to
+// run them is.
+func goTest(ctx context.Context, dir string) (bool, string, error) {
+	cmd := exec.CommandContext(ctx, "go", "test", "-count=1", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return false, string(out), ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, string(out), nil
+	}
+	return err == nil, string(out), err
+}
+
+// tail returns the last n lines of s
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
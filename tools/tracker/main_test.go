@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testApp runs commands against a temporary database and challenge
+// directory, with a fixed clock and fake test results
+type testApp struct {
+	*app
+	stdout  *bytes.Buffer
+	flags   []string
+	pass    map[string]bool // test results by challenge directory name
+	runErr  error           // error of running the tests, if any
+	ranDirs []string
+	clock   time.Time
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "challenges")
+	for _, c := range []string{"1-sumOfTwoNumbers", "2-reverse-a-String", "13-SQLDatabaseOperationsWithGo"} {
+		if err := os.MkdirAll(filepath.Join(root, c), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ta := &testApp{
+		stdout: new(bytes.Buffer),
+		flags:  []string{"-db", filepath.Join(dir, "tracker.db"), "-root", root},
+		pass:   make(map[string]bool),
+		clock:  day(1),
+	}
+	ta.app = &app{
+		stdout: ta.stdout,
+		stderr: new(bytes.Buffer),
+		now:    func() time.Time { return ta.clock },
+		runTests: func(ctx context.Context, dir string) (bool, string, error) {
+			ta.ranDirs = append(ta.ranDirs, dir)
+			name := filepath.Base(dir)
+			return ta.pass[name], "--- FAIL: TestSomething\nFAIL", ta.runErr
+		},
+	}
+	return ta
+}
+
+// exec runs a command with the test flags and returns its output
+func (ta *testApp) exec(t *testing.T, cmd string, args ...string) string {
+	t.Helper()
+	ta.stdout.Reset()
+	all := append([]string{cmd}, ta.flags...)
+	if err := ta.run(context.Background(), append(all, args...)); err != nil {
+		t.Fatalf("%s %v failed: %v", cmd, args, err)
+	}
+	return ta.stdout.String()
+}
+
+func TestTrackerCommands(t *testing.T) {
+	ta := newTestApp(t)
+
+	// Alice times a session and fails, then passes the next day
+	ta.exec(t, "start", "-person", "alice", "13")
+	ta.clock = ta.clock.Add(45 * time.Minute)
+	out := ta.exec(t, "check", "-person", "alice", "13")
+	if !strings.Contains(out, "--- FAIL") || !strings.Contains(out, "FAIL 13-SQLDatabaseOperationsWithGo in 45m0s") {
+		t.Errorf("Expected the failing output and result, got\n%s", out)
+	}
+	if len(ta.ranDirs) != 1 || filepath.Base(ta.ranDirs[0]) != "13-SQLDatabaseOperationsWithGo" {
+		t.Errorf("Expected the tests of challenge 13 to run, got %v", ta.ranDirs)
+	}
+
+	ta.clock = day(2)
+	ta.pass["13-SQLDatabaseOperationsWithGo"] = true
+	out = ta.exec(t, "check", "-person", "alice", "-spent", "20m", "13-SQLDatabaseOperationsWithGo")
+	if !strings.Contains(out, "PASS 13-SQLDatabaseOperationsWithGo in 20m0s (attempt 2, 1 passed in a row)") {
+		t.Errorf("Expected a pass on the second attempt, got\n%s", out)
+	}
+
+	ta.pass["1-sumOfTwoNumbers"] = true
+	ta.exec(t, "check", "-person", "bob", "-spent", "5m", "1")
+
+	// Alice's review of 13 is due on day 3; challenges 1 and 2 are new
+	ta.clock = day(4)
+	out = ta.exec(t, "next", "-person", "alice", "-n", "2")
+	expected := "1. 13-SQLDatabaseOperationsWithGo: review due, passed 1 in a row\n2. 1-sumOfTwoNumbers: not attempted yet\n"
+	if out != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out)
+	}
+
+	out = ta.exec(t, "stats")
+	for _, want := range []string{
+		"alice: 2 attempts, 1 passed (50%), 1h5m0s spent",
+		"bob: 1 attempts, 1 passed (100%), 5m0s spent",
+		"CHALLENGE",
+		"13-SQLDatabaseOperationsWithGo",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected stats to contain %q, got\n%s", want, out)
+		}
+	}
+	if out := ta.exec(t, "stats", "-person", "bob"); strings.Contains(out, "alice") {
+		t.Errorf("Expected only bob's stats, got\n%s", out)
+	}
+}
+
+func TestCheckKeepsSessionWhenTestsCannotRun(t *testing.T) {
+	ta := newTestApp(t)
+	ta.exec(t, "start", "-person", "alice", "2")
+
+	ta.clock = ta.clock.Add(30 * time.Minute)
+	ta.runErr = errors.New("go: command not found")
+	args := append(append([]string{"check"}, ta.flags...), "-person", "alice", "2")
+	if err := ta.run(context.Background(), args); err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+
+	ta.clock = ta.clock.Add(10 * time.Minute)
+	ta.runErr = nil
+	out := ta.exec(t, "check", "-person", "alice", "2")
+	if !strings.Contains(out, "FAIL 2-reverse-a-String in 40m0s (attempt 1,") {
+		t.Errorf("Expected the session to be kept for the next check, got\n%s", out)
+	}
+}
+
+func TestTrackerErrors(t *testing.T) {
+	ta := newTestApp(t)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"No command", nil},
+		{"Unknown command", []string{"fly"}},
+		{"Unknown challenge", append([]string{"check"}, append(ta.flags, "-person", "alice", "99")...)},
+		{"Missing challenge", append([]string{"start"}, append(ta.flags, "-person", "alice")...)},
+		{"Missing person", append([]string{"next"}, append(ta.flags, "-person", "")...)},
+		{"No suggestions wanted", append([]string{"next"}, append(ta.flags, "-person", "alice", "-n", "0")...)},
+		{"Negative suggestions", append([]string{"next"}, append(ta.flags, "-person", "alice", "-n", "-1")...)},
+		{"Bad flag", []string{"stats", "-bogus"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ta.run(context.Background(), tt.args); err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("a\nb\nc\n", 2); got != "b\nc" {
+		t.Errorf("Expected %q, got %q", "b\nc", got)
+	}
+	if got := tail("a", 5); got != "a" {
+		t.Errorf("Expected %q, got %q", "a", got)
+	}
+}
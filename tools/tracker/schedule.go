@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reviewIntervals are the waits before redoing a challenge after 1, 2, 3...
+// passes in a row, as in a Leitner box system. A failed attempt makes the
+// challenge due again right away.
+var reviewIntervals = []time.Duration{
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	14 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+	60 * 24 * time.Hour,
+}
+
+// nextReview returns when a challenge last attempted at last should be
+// redone after streak passes in a row
+func nextReview(last time.Time, streak int) time.Time {
+	if streak == 0 {
+		return last
+	}
+	return last.Add(reviewIntervals[min(streak, len(reviewIntervals))-1])
+}
+
+// Suggestion is a challenge worth doing next
+type Suggestion struct {
+	Challenge string
+	Due       time.Time // zero for challenges never attempted
+	Reason    string
+}
+
+// Suggest returns up to limit challenges for a person to do at now: first
+// the ones they failed last, then reviews that are due, most overdue
+// relative to their interval first, then challenges they never attempted.
+// challenges lists all challenges, to find the new ones. A limit of zero or
+// less returns nothing.
+func Suggest(stats *PersonStats, challenges []string, now time.Time, limit int) []Suggestion {
+	if limit <= 0 {
+		return nil
+	}
+	var failed, due []Suggestion
+	overdue := make(map[string]float64)
+	attempted := make(map[string]bool)
+	for _, cs := range stats.Challenges {
+		attempted[cs.Challenge] = true
+		switch {
+		case !cs.LastPassed:
+			failed = append(failed, Suggestion{
+				Challenge: cs.Challenge,
+				Due:       cs.NextReview,
+				Reason:    fmt.Sprintf("failed last attempt on %s", cs.LastAttempt.Format(time.DateOnly)),
+			})
+		case !cs.NextReview.After(now):
+			interval := cs.NextReview.Sub(cs.LastAttempt)
+			overdue[cs.Challenge] = float64(now.Sub(cs.NextReview)) / float64(interval)
+			due = append(due, Suggestion{
+				Challenge: cs.Challenge,
+				Due:       cs.NextReview,
+				Reason:    fmt.Sprintf("review due, passed %d in a row", cs.Streak),
+			})
+		}
+	}
+	// Older failures first
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].Due.Before(failed[j].Due) })
+	sort.SliceStable(due, func(i, j int) bool { return overdue[due[i].Challenge] > overdue[due[j].Challenge] })
+
+	suggestions := append(failed, due...)
+	for _, c := range challenges {
+		if !attempted[c] {
+			suggestions = append(suggestions, Suggestion{Challenge: c, Reason: "not attempted yet"})
+		}
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// challengeNumber returns the number a challenge directory starts with
+func challengeNumber(name string) (int, bool) {
+	prefix, _, found := strings.Cut(name, "-")
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.Atoi(prefix)
+	return n, err == nil
+}
+
+// challengeLess orders challenges by number, then name
+func challengeLess(a, b string) bool {
+	na, okA := challengeNumber(a)
+	nb, okB := challengeNumber(b)
+	if okA && okB && na != nb {
+		return na < nb
+	}
+	if okA != okB {
+		return okA
+	}
+	return a < b
+}
+
+// listChallenges returns the challenge directories in root, such as
+// "1-sumOfTwoNumbers", ordered by number
+func listChallenges(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var challenges []string
+	for _, e := range entries {
+		if _, ok := challengeNumber(e.Name()); ok && e.IsDir() {
+			challenges = append(challenges, e.Name())
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool { return challengeLess(challenges[i], challenges[j]) })
+	return challenges, nil
+}
+
+// resolveChallenge finds a challenge by its directory name or number
+func resolveChallenge(challenges []string, arg string) (string, error) {
+	n, err := strconv.Atoi(arg)
+	isNumber := err == nil
+	for _, c := range challenges {
+		if c == arg {
+			return c, nil
+		}
+		if num, ok := challengeNumber(c); ok && isNumber && num == n {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown challenge %q", arg)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextReview(t *testing.T) {
+	tests := []struct {
+		streak   int
+		expected time.Time
+	}{
+		{0, day(1)},
+		{1, day(2)},
+		{2, day(4)},
+		{3, day(8)},
+		{6, day(1).Add(60 * 24 * time.Hour)},
+		{20, day(1).Add(60 * 24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		if got := nextReview(day(1), tt.streak); !got.Equal(tt.expected) {
+			t.Errorf("Streak %d: expected %v, got %v", tt.streak, tt.expected, got)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	challenges := []string{"1-a", "2-b", "3-c", "4-d", "5-e", "6-f"}
+	attempts := []*Attempt{
+		// Passed once on day 1: due on day 2, 4 days overdue by day 6
+		{Challenge: "1-a", Date: day(1), Passed: true},
+		// Passed twice, last on day 3: due on day 6, just due
+		{Challenge: "2-b", Date: day(1), Passed: true},
+		{Challenge: "2-b", Date: day(3), Passed: true},
+		// Failed last
+		{Challenge: "3-c", Date: day(1), Passed: true},
+		{Challenge: "3-c", Date: day(5), Passed: false},
+		// Passed three times: not due until day 12
+		{Challenge: "4-d", Date: day(1), Passed: true},
+		{Challenge: "4-d", Date: day(2), Passed: true},
+		{Challenge: "4-d", Date: day(5), Passed: true},
+	}
+	stats := summarise("alice", attempts)
+
+	got := Suggest(stats, challenges, day(6), 10)
+	var order []string
+	for _, s := range got {
+		order = append(order, s.Challenge)
+	}
+	expected := []string{"3-c", "1-a", "2-b", "5-e", "6-f"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+	if got[0].Reason != "failed last attempt on 2024-01-05" || got[3].Reason != "not attempted yet" {
+		t.Errorf("Expected reasons for the failure and the new challenge, got %q and %q", got[0].Reason, got[3].Reason)
+	}
+
+	if limited := Suggest(stats, challenges, day(6), 2); len(limited) != 2 {
+		t.Errorf("Expected 2 suggestions, got %d", len(limited))
+	}
+	for _, limit := range []int{0, -1} {
+		if limited := Suggest(stats, challenges, day(6), limit); len(limited) != 0 {
+			t.Errorf("Expected no suggestions for limit %d, got %d", limit, len(limited))
+		}
+	}
+	// Once everything is done and passed there is nothing to suggest
+	if got := Suggest(summarise("bob", []*Attempt{{Challenge: "1-a", Date: day(1), Passed: true}}), []string{"1-a"}, day(1), 3); len(got) != 0 {
+		t.Errorf("Expected no suggestions, got %+v", got)
+	}
+}
+
+func TestListAndResolveChallenges(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"10-shapes", "2-reverse", "1-sum", "notes"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(root, "3-file.md"), nil, 0o644)
+
+	challenges, err := listChallenges(root)
+	if err != nil {
+		t.Fatalf("Failed to list challenges: %v", err)
+	}
+	if expected := []string{"1-sum", "2-reverse", "10-shapes"}; !reflect.DeepEqual(challenges, expected) {
+		t.Errorf("Expected %v, got %v", expected, challenges)
+	}
+
+	tests := []struct {
+		arg      string
+		expected string
+		wantErr  bool
+	}{
+		{"10", "10-shapes", false},
+		{"2-reverse", "2-reverse", false},
+		{"7", "", true},
+		{"shapes", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveChallenge(challenges, tt.arg)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("%s: expected %q (error %v), got %q (%v)", tt.arg, tt.expected, tt.wantErr, got, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNoSession is returned when a person finishes a challenge they never started
+var ErrNoSession = errors.New("no practice session started")
+
+// Attempt is one try at a challenge
+type Attempt struct {
+	ID        int64
+	Person    string
+	Challenge string // directory name, e.g. "13-SQLDatabaseOperationsWithGo"
+	Date      time.Time
+	Passed    bool          // whether the challenge's tests passed
+	Spent     time.Duration // time spent practising
+}
+
+// schema holds the statements OpenTracker runs, in order
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS attempts (
+		id INTEGER PRIMARY KEY,
+		person TEXT NOT NULL,
+		challenge TEXT NOT NULL,
+		attempted_at TIMESTAMP NOT NULL,
+		passed INTEGER NOT NULL,
+		seconds INTEGER NOT NULL
+	)`,
+	"CREATE INDEX IF NOT EXISTS idx_attempts_person ON attempts(person, challenge, attempted_at)",
+
+	// Open practice sessions, so the time spent can be measured
+	`CREATE TABLE IF NOT EXISTS sessions (
+		person TEXT NOT NULL,
+		challenge TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (person, challenge)
+	)`,
+}
+
+// Tracker stores practice attempts in SQLite
+type Tracker struct {
+	db *sql.DB
+}
+
+// OpenTracker opens the database at path, creating its tables if needed
+func OpenTracker(path string) (*Tracker, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &Tracker{db: db}, nil
+}
+
+// Close closes the database
+func (t *Tracker) Close() error {
+	return t.db.Close()
+}
+
+// StartSession records that person started working on challenge at start,
+// replacing a session that was never finished
+func (t *Tracker) StartSession(person, challenge string, start time.Time) error {
+	_, err := t.db.Exec(
+		"INSERT OR REPLACE INTO sessions (person, challenge, started_at) VALUES (?, ?, ?)",
+		person, challenge, start.UTC())
+	return err
+}
+
+// SessionStart returns when person started working on challenge
+// Returns ErrNoSession if there is no open session
+func (t *Tracker) SessionStart(person, challenge string) (time.Time, error) {
+	var start time.Time
+	err := t.db.QueryRow(
+		"SELECT started_at FROM sessions WHERE person = ? AND challenge = ?", person, challenge).Scan(&start)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("%s on %s: %w", person, challenge, ErrNoSession)
+	}
+	return start, err
+}
+
+// RecordAttempt inserts an attempt and sets its ID. The attempt finishes the
+// session of its person on its challenge, which is removed in the same transaction.
+func (t *Tracker) RecordAttempt(a *Attempt) (err error) {
+	if a.Person == "" || a.Challenge == "" {
+		return errors.New("attempt needs a person and a challenge")
+	}
+	if a.Spent < 0 {
+		return fmt.Errorf("time spent must not be negative, got %v", a.Spent)
+	}
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	res, err := tx.Exec(
+		"INSERT INTO attempts (person, challenge, attempted_at, passed, seconds) VALUES (?, ?, ?, ?, ?)",
+		a.Person, a.Challenge, a.Date.UTC(), a.Passed, int64(a.Spent.Round(time.Second)/time.Second))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sessions WHERE person = ? AND challenge = ?", a.Person, a.Challenge); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	a.ID = id
+	return nil
+}
+
+// Attempts returns the attempts of person, oldest first
+func (t *Tracker) Attempts(person string) ([]*Attempt, error) {
+	rows, err := t.db.Query(
+		"SELECT id, person, challenge, attempted_at, passed, seconds FROM attempts WHERE person = ? ORDER BY attempted_at, id",
+		person)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*Attempt
+	for rows.Next() {
+		a := &Attempt{}
+		var seconds int64
+		if err := rows.Scan(&a.ID, &a.Person, &a.Challenge, &a.Date, &a.Passed, &seconds); err != nil {
+			return nil, err
+		}
+		a.Spent = time.Duration(seconds) * time.Second
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// People returns everyone who recorded an attempt, sorted by name
+func (t *Tracker) People() ([]string, error) {
+	rows, err := t.db.Query("SELECT DISTINCT person FROM attempts ORDER BY person")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var people []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		people = append(people, p)
+	}
+	return people, rows.Err()
+}
+
+// ChallengeStats summarises the attempts of one person at one challenge
+type ChallengeStats struct {
+	Challenge   string
+	Attempts    int
+	Passes      int
+	Spent       time.Duration
+	Fastest     time.Duration // fastest passing attempt, zero if none passed
+	LastAttempt time.Time
+	LastPassed  bool
+	Streak      int       // passes in a row up to the last attempt
+	NextReview  time.Time // when spaced repetition suggests a retry
+}
+
+// PersonStats summarises the attempts of one person
+type PersonStats struct {
+	Person     string
+	Attempts   int
+	Passes     int
+	Spent      time.Duration
+	Challenges []ChallengeStats // ordered by challenge number
+}
+
+// PassRate returns the share of attempts that passed, from 0 to 1
+func (s *PersonStats) PassRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Passes) / float64(s.Attempts)
+}
+
+// Stats summarises the attempts of person
+func (t *Tracker) Stats(person string) (*PersonStats, error) {
+	attempts, err := t.Attempts(person)
+	if err != nil {
+		return nil, err
+	}
+	return summarise(person, attempts), nil
+}
+
+// summarise computes the stats of attempts sorted oldest first
+func summarise(person string, attempts []*Attempt) *PersonStats {
+	s := &PersonStats{Person: person}
+	byChallenge := make(map[string]*ChallengeStats)
+	for _, a := range attempts {
+		s.Attempts++
+		s.Spent += a.Spent
+		cs, ok := byChallenge[a.Challenge]
+		if !ok {
+			cs = &ChallengeStats{Challenge: a.Challenge}
+			byChallenge[a.Challenge] = cs
+		}
+		cs.Attempts++
+		cs.Spent += a.Spent
+		cs.LastAttempt = a.Date
+		cs.LastPassed = a.Passed
+		if a.Passed {
+			s.Passes++
+			cs.Passes++
+			cs.Streak++
+			if cs.Fastest == 0 || a.Spent < cs.Fastest {
+				cs.Fastest = a.Spent
+			}
+		} else {
+			cs.Streak = 0
+		}
+	}
+	for _, cs := range byChallenge {
+		cs.NextReview = nextReview(cs.LastAttempt, cs.Streak)
+		s.Challenges = append(s.Challenges, *cs)
+	}
+	sort.Slice(s.Challenges, func(i, j int) bool {
+		return challengeLess(s.Challenges[i].Challenge, s.Challenges[j].Challenge)
+	})
+	return s
+}
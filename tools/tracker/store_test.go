@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// day returns noon UTC of a day in January 2024
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+}
+
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tr, err := OpenTracker(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatalf("Failed to open tracker: %v", err)
+	}
+	t.Cleanup(func() { tr.Close() })
+	return tr
+}
+
+func mustRecord(t *testing.T, tr *Tracker, attempts ...*Attempt) {
+	t.Helper()
+	for _, a := range attempts {
+		if err := tr.RecordAttempt(a); err != nil {
+			t.Fatalf("Failed to record attempt: %v", err)
+		}
+	}
+}
+
+func TestRecordAttempt(t *testing.T) {
+	tr := newTestTracker(t)
+	a := &Attempt{Person: "alice", Challenge: "1-sumOfTwoNumbers", Date: day(1), Passed: true, Spent: 90 * time.Second}
+	mustRecord(t, tr, a)
+	if a.ID == 0 {
+		t.Error("Expected the attempt to get an ID")
+	}
+	mustRecord(t, tr, &Attempt{Person: "bob", Challenge: "1-sumOfTwoNumbers", Date: day(2)})
+
+	attempts, err := tr.Attempts("alice")
+	if err != nil {
+		t.Fatalf("Failed to list attempts: %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", len(attempts))
+	}
+	got := attempts[0]
+	if got.Challenge != a.Challenge || !got.Date.Equal(a.Date) || !got.Passed || got.Spent != a.Spent {
+		t.Errorf("Expected %+v, got %+v", a, got)
+	}
+
+	people, err := tr.People()
+	if err != nil || len(people) != 2 || people[0] != "alice" || people[1] != "bob" {
+		t.Errorf("Expected [alice bob], got %v (%v)", people, err)
+	}
+
+	tests := []struct {
+		name    string
+		attempt *Attempt
+	}{
+		{"Missing person", &Attempt{Challenge: "1-sumOfTwoNumbers"}},
+		{"Missing challenge", &Attempt{Person: "alice"}},
+		{"Negative time", &Attempt{Person: "alice", Challenge: "1-sumOfTwoNumbers", Spent: -time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tr.RecordAttempt(tt.attempt); err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	tr := newTestTracker(t)
+	if _, err := tr.SessionStart("alice", "2-reverse-a-String"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected ErrNoSession, got %v", err)
+	}
+
+	tr.StartSession("alice", "2-reverse-a-String", day(1))
+	// Starting again replaces the session
+	tr.StartSession("alice", "2-reverse-a-String", day(2))
+	start, err := tr.SessionStart("alice", "2-reverse-a-String")
+	if err != nil || !start.Equal(day(2)) {
+		t.Errorf("Expected the session to start at %v, got %v (%v)", day(2), start, err)
+	}
+
+	// Only an attempt on the same challenge finishes the session
+	tr.RecordAttempt(&Attempt{Person: "alice", Challenge: "1-sumOfTwoNumbers", Date: day(3)})
+	if _, err := tr.SessionStart("alice", "2-reverse-a-String"); err != nil {
+		t.Errorf("Expected the session to be kept, got %v", err)
+	}
+	tr.RecordAttempt(&Attempt{Person: "alice", Challenge: "2-reverse-a-String", Date: day(3)})
+	if _, err := tr.SessionStart("alice", "2-reverse-a-String"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected the session to be finished, got %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tr := newTestTracker(t)
+	mustRecord(t, tr,
+		&Attempt{Person: "alice", Challenge: "13-SQLDatabaseOperationsWithGo", Date: day(1), Passed: false, Spent: time.Hour},
+		&Attempt{Person: "alice", Challenge: "13-SQLDatabaseOperationsWithGo", Date: day(2), Passed: true, Spent: 40 * time.Minute},
+		&Attempt{Person: "alice", Challenge: "13-SQLDatabaseOperationsWithGo", Date: day(5), Passed: true, Spent: 30 * time.Minute},
+		&Attempt{Person: "alice", Challenge: "2-reverse-a-String", Date: day(3), Passed: true, Spent: 10 * time.Minute},
+		&Attempt{Person: "bob", Challenge: "2-reverse-a-String", Date: day(3), Passed: false},
+	)
+
+	s, err := tr.Stats("alice")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if s.Attempts != 4 || s.Passes != 3 || s.Spent != 2*time.Hour+20*time.Minute || s.PassRate() != 0.75 {
+		t.Errorf("Expected 4 attempts, 3 passes and 2h20m, got %+v", s)
+	}
+	if len(s.Challenges) != 2 {
+		t.Fatalf("Expected 2 challenges, got %d", len(s.Challenges))
+	}
+
+	// Ordered by number, not by name
+	reverse, sql := s.Challenges[0], s.Challenges[1]
+	if reverse.Challenge != "2-reverse-a-String" {
+		t.Errorf("Expected challenge 2 first, got %s", reverse.Challenge)
+	}
+	expected := ChallengeStats{
+		Challenge:   "13-SQLDatabaseOperationsWithGo",
+		Attempts:    3,
+		Passes:      2,
+		Spent:       130 * time.Minute,
+		Fastest:     30 * time.Minute,
+		LastAttempt: day(5),
+		LastPassed:  true,
+		Streak:      2,
+		NextReview:  day(8),
+	}
+	if !sql.LastAttempt.Equal(expected.LastAttempt) || !sql.NextReview.Equal(expected.NextReview) {
+		t.Errorf("Expected last attempt %v and next review %v, got %v and %v",
+			expected.LastAttempt, expected.NextReview, sql.LastAttempt, sql.NextReview)
+	}
+	sql.LastAttempt, sql.NextReview = expected.LastAttempt, expected.NextReview
+	if sql != expected {
+		t.Errorf("Expected %+v, got %+v", expected, sql)
+	}
+
+	empty, err := tr.Stats("carol")
+	if err != nil || empty.Attempts != 0 || empty.PassRate() != 0 {
+		t.Errorf("Expected empty stats, got %+v (%v)", empty, err)
+	}
+}